@@ -5,6 +5,8 @@ const GRID_API_KEY = process.env.GRID_API_KEY || '';
 let ENGINE_URL = process.env.ENGINE_URL || 'ws://engine:8080/ws';
 const POLL_INTERVAL_MS = Number(process.env.GRID_POLL_INTERVAL_MS || '10000');
 const SCENARIO_ID = process.env.SCENARIO_ID || 'balanced';
+// Authenticates this adapter as a feed source; see FEED_TOKENS on the engine.
+const FEED_TOKEN = process.env.FEED_TOKEN || '';
 
 if (!ENGINE_URL.endsWith('/ws')) {
   ENGINE_URL = ENGINE_URL.replace(/\/$/, '') + '/ws';
@@ -32,6 +34,7 @@ type ScenarioFrame = {
 type SeriesStateEvent = {
   type: 'series_state';
   payload: {
+    source: string;
     series_id: string;
     timestamp: string;
     game_state: {
@@ -228,6 +231,7 @@ class MockInPlayProvider implements InPlayProvider {
       const event: SeriesStateEvent = {
         type: 'series_state',
         payload: {
+          source: 'mock_replay',
           series_id: series.id,
           timestamp: new Date().toISOString(),
           game_state: {
@@ -347,7 +351,9 @@ function sendDiscovery(seriesList: SeriesSummary[]): void {
 
 function connectToEngine(): void {
   console.log(`[ADAPTER] Connecting to Engine at ${ENGINE_URL}...`);
-  engineSocket = new WebSocket(ENGINE_URL);
+  engineSocket = new WebSocket(ENGINE_URL, {
+    headers: { Authorization: `Bearer ${FEED_TOKEN}` },
+  });
 
   engineSocket.on('open', () => {
     console.log('[ADAPTER] Connected to engine');
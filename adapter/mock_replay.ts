@@ -6,6 +6,7 @@ import { WebSocket } from 'ws';
 interface SeriesEvent {
     type: 'series_state';
     payload: {
+        source: string;
         series_id: string;
         game_state: {
             round: number;
@@ -23,11 +24,13 @@ class MockReplay {
     private ctScore: number = 0;
     private interval: NodeJS.Timeout | null = null;
 
-    constructor(private engineUrl: string, private matchId: string) { }
+    constructor(private engineUrl: string, private matchId: string, private feedToken: string) { }
 
     public start() {
         console.log(`📡 Starting Mock Replay for Match: ${this.matchId}`);
-        this.ws = new WebSocket(this.engineUrl);
+        this.ws = new WebSocket(this.engineUrl, {
+            headers: { Authorization: `Bearer ${this.feedToken}` },
+        });
 
         this.ws.on('open', () => {
             console.log('✅ Mock Replay connected to Engine');
@@ -55,6 +58,7 @@ class MockReplay {
         const event: SeriesEvent = {
             type: 'series_state',
             payload: {
+                source: 'mock_replay',
                 series_id: this.matchId,
                 game_state: {
                     round: this.currentRound,
@@ -90,6 +94,8 @@ class MockReplay {
 
 const ENGINE_URL = process.env.ENGINE_URL || 'ws://localhost:8080';
 const MATCH_ID = 'mock-match-777';
+// Authenticates the replay as a feed source; see FEED_TOKENS on the engine.
+const FEED_TOKEN = process.env.FEED_TOKEN || '';
 
-const replay = new MockReplay(ENGINE_URL, MATCH_ID);
+const replay = new MockReplay(ENGINE_URL, MATCH_ID, FEED_TOKEN);
 replay.start();
@@ -0,0 +1,355 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"cs2-prediction-engine/internal/engine"
+	"cs2-prediction-engine/internal/feedhealth"
+	"cs2-prediction-engine/internal/gateway"
+	"cs2-prediction-engine/internal/telemetry"
+)
+
+// operatorAction is an admin request applied on the market's sequencer so it
+// is ordered against the market's orders and feed events.
+type operatorAction struct {
+	OperatorID string
+	Action     string
+	Reason     string
+	Winner     string
+	FinalScore string
+}
+
+type AdminActionRequest struct {
+	Reason     string `json:"reason"`
+	Winner     string `json:"winner,omitempty"`      // settle only: YES or NO
+	FinalScore string `json:"final_score,omitempty"` // settle only
+}
+
+func operatorAuthFromEnv() *gateway.OperatorAuth {
+	tokens, err := gateway.ParseOperatorTokens(os.Getenv("OPERATOR_TOKENS"))
+	if err != nil {
+		slog.Error("invalid OPERATOR_TOKENS", "error", err)
+		os.Exit(1)
+	}
+	if len(tokens) == 0 {
+		slog.Warn("OPERATOR_TOKENS not set; admin API will reject every request")
+	}
+	return gateway.NewOperatorAuth(tokens)
+}
+
+// feedAuthFromEnv reads the feed adapters' tokens from FEED_TOKENS. Without
+// any, feed messages on /ws are refused and only the admin API can move
+// markets.
+func feedAuthFromEnv() *gateway.FeedAuth {
+	tokens, err := gateway.ParseFeedTokens(os.Getenv("FEED_TOKENS"), feedhealth.SourceManual)
+	if err != nil {
+		slog.Error("invalid FEED_TOKENS", "error", err)
+		os.Exit(1)
+	}
+	if len(tokens) == 0 {
+		slog.Warn("FEED_TOKENS not set; websocket feed messages will be rejected")
+	}
+	return gateway.NewFeedAuth(tokens)
+}
+
+func registerAdminRoutes(auth *gateway.OperatorAuth) {
+	http.Handle("/admin/markets", auth.Require(gateway.RoleOperator, http.HandlerFunc(handleAdminMarkets)))
+	http.Handle("/admin/markets/", auth.Require(gateway.RoleOperator, http.HandlerFunc(handleAdminMarketByID)))
+	http.Handle("/admin/exposure", auth.Require(gateway.RoleOperator, http.HandlerFunc(handleAdminExposure)))
+	http.Handle("/admin/users/", auth.Require(gateway.RoleOperator, http.HandlerFunc(handleAdminUsers)))
+	http.Handle("/admin/surveillance/alerts", auth.Require(gateway.RoleOperator, http.HandlerFunc(handleSurveillanceAlerts)))
+	http.Handle("/admin/surveillance/alerts/", auth.Require(gateway.RoleOperator, http.HandlerFunc(handleSurveillanceAlertByID)))
+	http.Handle("/admin/surveillance/links", auth.Require(gateway.RoleOperator, http.HandlerFunc(handleSurveillanceLinks)))
+}
+
+func operatorID(r *http.Request) string {
+	principal, _ := gateway.PrincipalFromContext(r.Context())
+	return principal.ID
+}
+
+func handleAdminMarkets(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var payload AdapterMarketCreatedPayload
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	if payload.MarketID == "" || payload.SeriesID == "" {
+		http.Error(w, "market_id and series_id are required", http.StatusBadRequest)
+		return
+	}
+	if _, exists := marketRegistry.GetMarket(payload.MarketID); exists {
+		http.Error(w, "market already exists", http.StatusConflict)
+		return
+	}
+
+	meta := createMarket(payload)
+	auditLog.LogOperatorAction(operatorID(r), meta.MarketID, "create", meta.Title)
+
+	createdMsg, _ := json.Marshal(map[string]interface{}{
+		"type":    "market_created",
+		"payload": payload,
+	})
+	hub.Broadcast(createdMsg)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(map[string]interface{}{
+		"market": meta,
+	}); err != nil {
+		http.Error(w, "failed to encode response", http.StatusInternalServerError)
+	}
+}
+
+func handleAdminMarketByID(w http.ResponseWriter, r *http.Request) {
+	// Expected: /admin/markets/{marketID} or /admin/markets/{marketID}/{suspend,resume,settle,void,close,risk,matching,series_state}
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/admin/markets/"), "/")
+	marketID := parts[0]
+	if marketID == "" || len(parts) > 2 {
+		http.Error(w, "invalid market id", http.StatusBadRequest)
+		return
+	}
+	meta, ok := marketRegistry.GetMarket(marketID)
+	if !ok {
+		http.Error(w, "market not found", http.StatusNotFound)
+		return
+	}
+
+	if len(parts) == 1 {
+		if r.Method != http.MethodPatch {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		handleAdminPatchMarket(w, r, marketID)
+		return
+	}
+
+	if parts[1] == "risk" {
+		handleAdminMarketRisk(w, r, marketID)
+		return
+	}
+	if parts[1] == "matching" {
+		handleAdminMarketMatching(w, r, marketID)
+		return
+	}
+	if parts[1] == "series_state" {
+		handleAdminSeriesState(w, r, meta)
+		return
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var req AdminActionRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+	}
+
+	action := parts[1]
+	allowed := false
+	switch action {
+	case "suspend":
+		allowed = meta.Status == engine.StatusSuspended || meta.Status.CanTransition(engine.StatusSuspended)
+	case "resume":
+		allowed = meta.Status.CanTransition(engine.StatusOpen)
+	case "close":
+		allowed = meta.Status.CanTransition(engine.StatusClosed)
+	case "void":
+		allowed = meta.Status.CanTransition(engine.StatusVoided)
+	case "settle":
+		req.Winner = strings.ToUpper(req.Winner)
+		if req.Winner != string(engine.Yes) && req.Winner != string(engine.No) {
+			http.Error(w, "winner must be YES or NO", http.StatusBadRequest)
+			return
+		}
+		allowed = canResolve(meta.Status)
+	default:
+		http.Error(w, "unknown admin action", http.StatusBadRequest)
+		return
+	}
+	if !allowed {
+		http.Error(w, fmt.Sprintf("cannot %s a %s market", action, meta.Status), http.StatusConflict)
+		return
+	}
+
+	if !sequencers.Get(marketID).Submit(engine.Command{
+		Kind: engine.CommandOperatorAction,
+		Payload: operatorAction{
+			OperatorID: operatorID(r),
+			Action:     action,
+			Reason:     req.Reason,
+			Winner:     req.Winner,
+			FinalScore: req.FinalScore,
+		},
+	}) {
+		http.Error(w, "market busy", http.StatusServiceUnavailable)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func handleAdminPatchMarket(w http.ResponseWriter, r *http.Request, marketID string) {
+	var patch engine.MarketMetadataPatch
+	if err := json.NewDecoder(r.Body).Decode(&patch); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	if patch.StartTime != nil && *patch.StartTime != "" {
+		if _, err := time.Parse(time.RFC3339, *patch.StartTime); err != nil {
+			http.Error(w, "start_time must be RFC3339", http.StatusBadRequest)
+			return
+		}
+	}
+
+	meta, ok := marketRegistry.UpdateMetadata(marketID, patch)
+	if !ok {
+		http.Error(w, "market not found", http.StatusNotFound)
+		return
+	}
+	detail, _ := json.Marshal(patch)
+	auditLog.LogOperatorAction(operatorID(r), marketID, "update", string(detail))
+
+	updatedMsg, _ := json.Marshal(map[string]interface{}{
+		"type":    "market_updated",
+		"payload": meta,
+	})
+	hub.Broadcast(updatedMsg)
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]interface{}{
+		"market": meta,
+	}); err != nil {
+		http.Error(w, "failed to encode response", http.StatusInternalServerError)
+	}
+}
+
+// applyOperatorAction runs an admin request on the market's sequencer.
+func applyOperatorAction(marketID string, action operatorAction) {
+	reason := "operator_" + action.Action
+	if action.Reason != "" {
+		reason += ":" + action.Reason
+	}
+
+	// The market may have moved on since the request was validated, so each
+	// transition is checked again here.
+	applied := false
+	switch action.Action {
+	case "suspend":
+		suspendMarket(marketID, reason)
+		applied = suspendedByReason(marketID) == reason
+	case "resume":
+		resumeMarket(marketID, reason)
+		if meta, ok := marketRegistry.GetMarket(marketID); ok && meta.Status == engine.StatusOpen {
+			publishFeedHealth(feedMonitor.Clear(marketID, "operator_resume"))
+			applied = true
+		}
+	case "close":
+		applied = transitionMarket(marketID, engine.StatusClosed, reason)
+	case "settle":
+		applied = beginResolving(marketID, reason) && transitionMarket(marketID, engine.StatusSettled, reason)
+		if applied {
+			settleMarket(marketID, action.Winner, time.Now().UTC().Format(time.RFC3339), action.FinalScore)
+			feedMonitor.Forget(marketID)
+			feedReconciler.Forget(marketID)
+		}
+	case "void":
+		applied = transitionMarket(marketID, engine.StatusVoided, reason)
+		if applied {
+			voidMarket(marketID, reason)
+			feedMonitor.Forget(marketID)
+			feedReconciler.Forget(marketID)
+		}
+	}
+	if !applied {
+		slog.Warn("operator action not applied", "operator_id", action.OperatorID, "action", action.Action, "market_id", marketID)
+		return
+	}
+
+	detail := action.Reason
+	if action.Action == "settle" {
+		detail = fmt.Sprintf("winner=%s score=%s %s", action.Winner, action.FinalScore, action.Reason)
+	}
+	auditLog.LogOperatorAction(action.OperatorID, marketID, action.Action, detail)
+}
+
+func voidMarket(marketID string, reason string) {
+	refundOpenReservesForMarket(marketID, "market_voided")
+	results := ledger.VoidMarket(marketID)
+
+	voidMsg, _ := json.Marshal(map[string]interface{}{
+		"type": "market_voided",
+		"payload": map[string]interface{}{
+			"market_id": marketID,
+			"reason":    reason,
+			"refunds":   results,
+		},
+	})
+	hub.Broadcast(voidMsg)
+	publishPortfolioUpdates(marketID)
+}
+
+// ManualSeriesStateRequest is an operator's own report of a series, fed to the
+// reconciler as the manual source.
+type ManualSeriesStateRequest struct {
+	Timestamp string    `json:"timestamp,omitempty"`
+	GameState GameState `json:"game_state"`
+}
+
+func handleAdminSeriesState(w http.ResponseWriter, r *http.Request, meta engine.MarketMetadata) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var req ManualSeriesStateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.GameState.Map == "" || req.GameState.Phase == "" {
+		http.Error(w, "game_state.map and game_state.phase are required", http.StatusBadRequest)
+		return
+	}
+	// Feed events reach a market through the ID derived from its series.
+	if meta.MarketID != "series_"+meta.SeriesID+"_winner" {
+		http.Error(w, "market does not follow a series feed", http.StatusConflict)
+		return
+	}
+	receivedAt := time.Now()
+	if req.Timestamp == "" {
+		req.Timestamp = receivedAt.UTC().Format(time.RFC3339Nano)
+	}
+
+	payload := AdapterSeriesStatePayload{
+		Source:    feedhealth.SourceManual,
+		SeriesID:  meta.SeriesID,
+		Timestamp: req.Timestamp,
+		GameState: req.GameState,
+	}
+	raw, _ := json.Marshal(map[string]interface{}{
+		"type":    "series_state",
+		"payload": payload,
+	})
+	submitFeedEvent(meta.MarketID, feedEvent{
+		raw:           raw,
+		seriesState:   &payload,
+		receivedAt:    receivedAt,
+		correlationID: telemetry.NewCorrelationID(),
+	})
+	auditLog.LogOperatorAction(operatorID(r), meta.MarketID, "series_state",
+		fmt.Sprintf("map=%s round=%d score=%d-%d phase=%s", req.GameState.Map, req.GameState.Round,
+			req.GameState.TerroristScore, req.GameState.CTScore, req.GameState.Phase))
+	w.WriteHeader(http.StatusAccepted)
+}
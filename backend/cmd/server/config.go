@@ -0,0 +1,119 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"cs2-prediction-engine/internal/config"
+)
+
+// configPollInterval is how often the config file is checked for edits. A
+// SIGHUP reloads it immediately.
+const configPollInterval = 5 * time.Second
+
+var (
+	// settings is the configuration in effect. Settings that need a restart
+	// keep their startup values here even after the file changes.
+	settings atomic.Pointer[config.Config]
+	logLevel = new(slog.LevelVar)
+)
+
+// loadConfig reads the file named by -config or CONFIG_FILE, if any, and
+// exits when it does not validate.
+func loadConfig() (config.Config, string) {
+	path := flag.String("config", os.Getenv("CONFIG_FILE"), "path to the engine config file (YAML)")
+	flag.Parse()
+	cfg, err := config.Load(*path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid configuration: %v\n", err)
+		os.Exit(1)
+	}
+	settings.Store(&cfg)
+	logLevel.UnmarshalText([]byte(cfg.Logging.Level))
+	return cfg, *path
+}
+
+// applySettings pushes the reloadable settings into the running engine.
+func applySettings(cfg config.Config) {
+	fairnessDelay.SetPolicy(cfg.DelayPolicy(), cfg.DelayOverrides())
+	riskEngine.SetConfig(cfg.RiskConfig())
+	exposureMonitor.SetThresholds(cfg.ExposureThresholds())
+	logLevel.UnmarshalText([]byte(cfg.Logging.Level))
+}
+
+// watchConfig reloads the config file on SIGHUP and whenever its
+// modification time changes.
+func watchConfig(path string) {
+	if path == "" {
+		return
+	}
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	ticker := time.NewTicker(configPollInterval)
+	defer ticker.Stop()
+
+	modTime := fileModTime(path)
+	for {
+		select {
+		case <-hup:
+			slog.Info("reloading config", "path", path, "trigger", "sighup")
+		case <-ticker.C:
+			mt := fileModTime(path)
+			if mt.Equal(modTime) {
+				continue
+			}
+			modTime = mt
+			slog.Info("reloading config", "path", path, "trigger", "file_changed")
+		}
+		reloadConfig(path)
+	}
+}
+
+func fileModTime(path string) time.Time {
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
+
+// reloadConfig applies the reloadable part of the file and logs every
+// change, flagging the ones that only take effect after a restart. An
+// invalid file leaves the running configuration untouched.
+func reloadConfig(path string) {
+	next, err := config.Load(path)
+	if err != nil {
+		slog.Error("config reload rejected", "path", path, "error", err)
+		return
+	}
+	current := *settings.Load()
+	changes := config.Diff(current, next)
+	if len(changes) == 0 {
+		slog.Info("config unchanged", "path", path)
+		return
+	}
+
+	applied, pending := 0, 0
+	for _, c := range changes {
+		if c.Reloadable() {
+			applied++
+			slog.Info("config changed", "setting", c.Path, "old", c.Old, "new", c.New)
+		} else {
+			pending++
+			slog.Warn("config change needs restart", "setting", c.Path, "old", c.Old, "new", c.New)
+		}
+	}
+	if applied == 0 {
+		return
+	}
+	updated := current.WithReloadable(next)
+	applySettings(updated)
+	settings.Store(&updated)
+	slog.Info("config reloaded", "path", path, "applied", applied, "restart_required", pending)
+}
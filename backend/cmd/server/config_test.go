@@ -0,0 +1,96 @@
+package main
+
+import (
+	"bytes"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"cs2-prediction-engine/internal/config"
+)
+
+// captureLogs routes the default logger into a buffer for the test.
+func captureLogs(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	prev := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(&buf, nil)))
+	t.Cleanup(func() { slog.SetDefault(prev) })
+	return &buf
+}
+
+func startWithSettings(t *testing.T) (config.Config, string) {
+	t.Helper()
+	startTestEngine(t)
+	cfg := config.Default()
+	settings.Store(&cfg)
+	return cfg, filepath.Join(t.TempDir(), "engine.yaml")
+}
+
+func writeFile(t *testing.T, path, body string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(body), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReloadInvalidFileKeepsConfig(t *testing.T) {
+	cfg, path := startWithSettings(t)
+	logs := captureLogs(t)
+	writeFile(t, path, "risk:\n  tiers:\n    retail:\n      max_order_quantity: 10\nlogging:\n  level: loud\n")
+
+	reloadConfig(path)
+
+	if changes := config.Diff(cfg, *settings.Load()); len(changes) != 0 {
+		t.Errorf("settings changed by an invalid file: %v", changes)
+	}
+	if got := riskEngine.Limits("u1", "m1").MaxOrderQuantity; got != cfg.Risk.Tiers["retail"].MaxOrderQuantity {
+		t.Errorf("retail max_order_quantity = %d, want it unchanged", got)
+	}
+	if !strings.Contains(logs.String(), "config reload rejected") {
+		t.Errorf("rejection not logged:\n%s", logs)
+	}
+}
+
+func TestReloadAppliesOnlyReloadableChanges(t *testing.T) {
+	cfg, path := startWithSettings(t)
+	logs := captureLogs(t)
+	writeFile(t, path, "server:\n  addr: \":9090\"\nrisk:\n  tiers:\n    retail:\n      max_order_quantity: 10\n")
+
+	reloadConfig(path)
+
+	got := settings.Load()
+	if got.Server.Addr != cfg.Server.Addr {
+		t.Errorf("server.addr = %q, want %q until restart", got.Server.Addr, cfg.Server.Addr)
+	}
+	if got.Risk.Tiers["retail"].MaxOrderQuantity != 10 {
+		t.Errorf("stored retail max_order_quantity = %d, want 10", got.Risk.Tiers["retail"].MaxOrderQuantity)
+	}
+	if limit := riskEngine.Limits("u1", "m1").MaxOrderQuantity; limit != 10 {
+		t.Errorf("risk engine max_order_quantity = %d, want 10", limit)
+	}
+	out := logs.String()
+	if !strings.Contains(out, "level=WARN msg=\"config change needs restart\" setting=server.addr") {
+		t.Errorf("restart-only change not warned about:\n%s", out)
+	}
+	if !strings.Contains(out, "setting=risk.tiers.retail.max_order_quantity") {
+		t.Errorf("applied change not logged:\n%s", out)
+	}
+}
+
+func TestReloadOnlyRestartChangesAppliesNothing(t *testing.T) {
+	cfg, path := startWithSettings(t)
+	logs := captureLogs(t)
+	writeFile(t, path, "snapshot:\n  keep: 3\n")
+
+	reloadConfig(path)
+
+	if got := settings.Load(); got.Snapshot.Keep != cfg.Snapshot.Keep {
+		t.Errorf("snapshot.keep = %d, want %d until restart", got.Snapshot.Keep, cfg.Snapshot.Keep)
+	}
+	if out := logs.String(); !strings.Contains(out, "config change needs restart") || strings.Contains(out, "config reloaded") {
+		t.Errorf("want only a restart warning:\n%s", out)
+	}
+}
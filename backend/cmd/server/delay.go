@@ -0,0 +1,86 @@
+package main
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"cs2-prediction-engine/internal/engine"
+)
+
+// delayPublishThreshold keeps small lag jitter from flooding clients with
+// fairness_delay updates.
+const delayPublishThreshold = 250 * time.Millisecond
+
+// observeFeedTiming feeds the lag of one series_state into the market's
+// fairness delay and stretches it after events that move the price sharply.
+func observeFeedTiming(marketID string, previous *engine.MarketGameState, state engine.MarketGameState, fired []engine.GameEventTrigger, receivedAt time.Time) {
+	if feedTime, ok := feedTimestamp(marketID, state); ok {
+		fairnessDelay.ObserveFeed(marketID, feedTime, receivedAt)
+		observeFeedLag(marketID, feedTime, receivedAt)
+	}
+
+	if reason := highImpactEvent(previous, state, fired); reason != "" {
+		fairnessDelay.MarkImpact(marketID, reason, receivedAt)
+		// Publish again once the stretch lapses.
+		time.AfterFunc(time.Until(receivedAt.Add(fairnessDelay.Policy(marketID).ImpactWindow)), func() {
+			publishFairnessDelay(marketID)
+		})
+	}
+	publishFairnessDelay(marketID)
+}
+
+func feedTimestamp(marketID string, state engine.MarketGameState) (time.Time, bool) {
+	if state.Timestamp == "" {
+		return time.Time{}, false
+	}
+	feedTime, err := time.Parse(time.RFC3339Nano, state.Timestamp)
+	if err != nil {
+		slog.Warn("unparseable series_state timestamp", "market_id", marketID, "timestamp", state.Timestamp, "error", err)
+		return time.Time{}, false
+	}
+	return feedTime, true
+}
+
+// highImpactEvent names the transition that warrants a longer delay, or
+// returns "" when there is none. A bare score change counts as a round end.
+func highImpactEvent(previous *engine.MarketGameState, state engine.MarketGameState, fired []engine.GameEventTrigger) string {
+	if previous != nil && state.Phase == "ended" && previous.Phase != "ended" {
+		return "series_ended"
+	}
+	if len(fired) == 0 {
+		return ""
+	}
+	if fired[0] == engine.TriggerScoreChanged {
+		return string(engine.TriggerRoundEnded)
+	}
+	return string(fired[0])
+}
+
+func publishFairnessDelay(marketID string) {
+	state, changed := fairnessDelay.Changed(marketID, delayPublishThreshold)
+	if !changed {
+		return
+	}
+	delayMsg, _ := json.Marshal(map[string]interface{}{
+		"type":    "fairness_delay",
+		"payload": state,
+	})
+	hub.Broadcast(delayMsg)
+}
+
+func handleMarketDelay(w http.ResponseWriter, r *http.Request, marketID string) {
+	if _, ok := marketRegistry.GetMarket(marketID); !ok {
+		http.Error(w, "market not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]interface{}{
+		"fairness_delay": fairnessDelay.State(marketID),
+	}); err != nil {
+		http.Error(w, "failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
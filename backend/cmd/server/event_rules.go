@@ -0,0 +1,84 @@
+package main
+
+import (
+	"encoding/json"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"cs2-prediction-engine/internal/engine"
+)
+
+func cancelRulesFor(marketID string) engine.EventRules {
+	if meta, ok := marketRegistry.GetMarket(marketID); ok && meta.CancelRules != nil {
+		return meta.CancelRules
+	}
+	return engine.DefaultEventRules
+}
+
+// bookClear records the latest game event that cleared a market's book.
+type bookClear struct {
+	at      time.Time
+	trigger engine.GameEventTrigger
+	scope   engine.CancelScope
+}
+
+// lastBookClear maps market ID to its latest bookClear.
+var lastBookClear sync.Map
+
+// clearBookOnGameEvents pulls orders priced before a material game event so
+// they cannot be picked off by whoever sees the event first: resting orders,
+// and orders still waiting out the fairness delay. It runs on the market's
+// sequencer, ahead of any order placed after the event.
+func clearBookOnGameEvents(book *engine.OrderBook, marketID string, fired []engine.GameEventTrigger, eventAt time.Time) {
+	trigger, scope, ok := cancelRulesFor(marketID).Resolve(fired)
+	if !ok {
+		return
+	}
+	lastBookClear.Store(marketID, bookClear{at: eventAt, trigger: trigger, scope: scope})
+	reason := "book_cleared_" + string(trigger)
+
+	cancelled := book.CancelWhere(scope.Applies)
+	orderIDs := make([]uint64, 0, len(cancelled))
+	for _, o := range cancelled {
+		closeOrderRecord(o.ID, engine.OrderCancelled, reason)
+		orderIDs = append(orderIDs, o.ID)
+	}
+	held := buffer.CancelWhere(func(o *engine.Order) bool {
+		return o.MarketID == marketID && o.Timestamp.Before(eventAt) && scope.Applies(o)
+	})
+	for _, o := range held {
+		closeOrderRecord(o.ID, engine.OrderCancelled, reason)
+		endOrderTrace(*o, "cancelled", reason, time.Now())
+		orderIDs = append(orderIDs, o.ID)
+	}
+	sort.Slice(orderIDs, func(i, j int) bool { return orderIDs[i] < orderIDs[j] })
+	slog.Info("book cleared on game event", "market_id", marketID, "trigger", trigger, "scope", scope, "cancelled_orders", len(orderIDs), "buffered_orders", len(held))
+
+	clearedMsg, _ := json.Marshal(map[string]interface{}{
+		"type": "book_cleared",
+		"payload": map[string]interface{}{
+			"market_id":        marketID,
+			"trigger":          trigger,
+			"scope":            scope,
+			"cancelled_orders": orderIDs,
+		},
+	})
+	hub.Broadcast(clearedMsg)
+}
+
+// clearedBeforeArrival reports why an order released from the fairness buffer
+// must not reach the book: it was placed before a game event that cleared its
+// market, but was already on its way to the sequencer when the book cleared.
+func clearedBeforeArrival(order *engine.Order) (string, bool) {
+	v, ok := lastBookClear.Load(order.MarketID)
+	if !ok {
+		return "", false
+	}
+	last := v.(bookClear)
+	if !order.Timestamp.Before(last.at) || !last.scope.Applies(order) {
+		return "", false
+	}
+	return "book_cleared_" + string(last.trigger), true
+}
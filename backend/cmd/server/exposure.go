@@ -0,0 +1,72 @@
+package main
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"cs2-prediction-engine/internal/engine"
+)
+
+const (
+	exposureTick       = 5 * time.Second
+	exposureTopHolders = 5
+)
+
+// isHouseAccount marks the platform's liquidity accounts, which trade under
+// the market-maker risk tier.
+func isHouseAccount(userID string) bool {
+	return riskEngine.UserTier(userID) == engine.TierMarketMaker
+}
+
+func buildExposureReport() []engine.MarketExposure {
+	reserves := map[string]int64{}
+	liveOrders.each(func(record *OrderRecord) {
+		reserves[record.Order.MarketID] += record.ReservedRemaining
+	})
+	return engine.BuildExposure(ledger.OpenPositions(), reserves, isHouseAccount, exposureTopHolders)
+}
+
+// runExposureChecks re-aggregates exposure and logs threshold crossings.
+// Alerts reveal the house book, so they stay on the operator API
+// (GET /admin/exposure) and are never broadcast.
+func runExposureChecks(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for now := range ticker.C {
+		for _, alert := range exposureMonitor.Evaluate(buildExposureReport(), now) {
+			if alert.Raised {
+				slog.Warn("exposure alert", "market_id", alert.MarketID, "kind", alert.Kind, "detail", alert.Detail)
+			} else {
+				slog.Info("exposure alert cleared", "market_id", alert.MarketID, "kind", alert.Kind)
+			}
+		}
+	}
+}
+
+func handleAdminExposure(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(map[string]interface{}{
+			"markets":    buildExposureReport(),
+			"alerts":     exposureMonitor.Active(),
+			"thresholds": exposureMonitor.Thresholds(),
+		}); err != nil {
+			http.Error(w, "failed to encode response", http.StatusInternalServerError)
+		}
+	case http.MethodPut:
+		var thresholds engine.ExposureThresholds
+		if err := json.NewDecoder(r.Body).Decode(&thresholds); err != nil {
+			http.Error(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+		exposureMonitor.SetThresholds(thresholds)
+		detail, _ := json.Marshal(thresholds)
+		auditLog.LogOperatorAction(operatorID(r), "", "set_exposure_thresholds", string(detail))
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"cs2-prediction-engine/internal/engine"
+	"cs2-prediction-engine/internal/feedhealth"
+)
+
+const (
+	feedHealthHistory = 200
+	feedHealthTick    = time.Second
+
+	feedIntegrityReason = "feed_integrity:"
+	disagreementReason  = feedIntegrityReason + "source_disagreement"
+)
+
+// reconcileFeedSource records a source's update, audits it, and suspends the
+// market while sources disagree. It returns the update's audit hash.
+func reconcileFeedSource(marketID, source string, state engine.MarketGameState, receivedAt time.Time) (feedhealth.Verdict, string, error) {
+	verdict, err := feedReconciler.Report(marketID, feedhealth.SourceReport{
+		Source:         source,
+		Map:            state.Map,
+		Round:          state.Round,
+		TerroristScore: state.TerroristScore,
+		CTScore:        state.CTScore,
+		Phase:          state.Phase,
+		ReceivedAt:     receivedAt,
+	})
+	if err != nil {
+		return verdict, "", err
+	}
+	hash, _ := auditLog.LogFeedState(marketID, source, state)
+
+	stateMu.Lock()
+	health, ok := marketHealthByID[marketID]
+	if !ok {
+		health = &MarketHealthState{}
+		marketHealthByID[marketID] = health
+	}
+	if health.Sources == nil {
+		health.Sources = map[string]time.Time{}
+	}
+	health.Sources[source] = receivedAt
+	health.LeadSource = verdict.Leader
+	stateMu.Unlock()
+
+	if verdict.Disagreement == "" {
+		maybeResumeFeed(marketID)
+	} else if !suspendedByOther(marketID) && suspendedByReason(marketID) != disagreementReason {
+		slog.Warn("feed sources disagree", "market_id", marketID, "detail", verdict.Disagreement)
+		suspendMarket(marketID, disagreementReason)
+	}
+	return verdict, hash, nil
+}
+
+func observeFeedHealth(marketID string, state engine.MarketGameState, receivedAt time.Time) {
+	obs := feedhealth.Observation{
+		MarketID:   marketID,
+		State:      state,
+		ReceivedAt: receivedAt,
+	}
+	if feedTime, ok := feedTimestamp(marketID, state); ok {
+		obs.FeedTime = feedTime
+	}
+	applyFeedHealth(marketID, feedMonitor.Observe(obs))
+}
+
+// runFeedHealthChecks evaluates idle rules such as the stale-feed timeout and
+// routes any transitions through the affected market's sequencer.
+func runFeedHealthChecks(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for now := range ticker.C {
+		byMarket := map[string][]feedhealth.Transition{}
+		for _, t := range feedMonitor.Tick(now) {
+			byMarket[t.MarketID] = append(byMarket[t.MarketID], t)
+		}
+		for marketID, transitions := range byMarket {
+			submitFeedEvent(marketID, feedEvent{health: transitions})
+		}
+	}
+}
+
+// applyFeedHealth suspends on critical violations and resumes once no critical
+// rule is left violated, but only when the suspension was ours: a market halted
+// by the circuit breaker stays halted.
+func applyFeedHealth(marketID string, transitions []feedhealth.Transition) {
+	if len(transitions) == 0 {
+		return
+	}
+	for _, t := range transitions {
+		if t.Violated {
+			slog.Warn("feed integrity violated", "market_id", marketID, "rule", t.Rule, "severity", t.Severity, "detail", t.Detail)
+			if t.Severity == feedhealth.SeverityCritical && !suspendedByOther(marketID) {
+				suspendMarket(marketID, feedIntegrityReason+t.Rule)
+			}
+		} else {
+			slog.Info("feed integrity recovered", "market_id", marketID, "rule", t.Rule)
+		}
+	}
+	publishFeedHealth(transitions)
+	maybeResumeFeed(marketID)
+}
+
+func maybeResumeFeed(marketID string) {
+	if !strings.HasPrefix(suspendedByReason(marketID), feedIntegrityReason) {
+		return
+	}
+	if len(feedMonitor.Blocking(marketID)) == 0 && feedReconciler.Disagreement(marketID) == "" {
+		resumeMarket(marketID, "feed_integrity_recovered")
+	}
+}
+
+func publishFeedHealth(transitions []feedhealth.Transition) {
+	for _, t := range transitions {
+		healthMsg, _ := json.Marshal(map[string]interface{}{
+			"type": "feed_health",
+			"payload": map[string]interface{}{
+				"market_id": t.MarketID,
+				"rule":      t.Rule,
+				"severity":  t.Severity,
+				"violated":  t.Violated,
+				"detail":    t.Detail,
+			},
+		})
+		hub.Broadcast(healthMsg)
+	}
+}
+
+func suspendedByReason(marketID string) string {
+	stateMu.Lock()
+	defer stateMu.Unlock()
+	if health, ok := marketHealthByID[marketID]; ok {
+		return health.SuspendedByReason
+	}
+	return ""
+}
+
+// suspendedByOther reports a suspension that feed integrity did not cause and
+// so must not lift.
+func suspendedByOther(marketID string) bool {
+	reason := suspendedByReason(marketID)
+	return reason != "" && !strings.HasPrefix(reason, feedIntegrityReason)
+}
+
+func handleMarketHealth(w http.ResponseWriter, r *http.Request, marketID string) {
+	meta, ok := marketRegistry.GetMarket(marketID)
+	if !ok {
+		http.Error(w, "market not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	stateMu.Lock()
+	var leadSource string
+	lastHeard := map[string]time.Time{}
+	if health, ok := marketHealthByID[marketID]; ok {
+		leadSource = health.LeadSource
+		for source, at := range health.Sources {
+			lastHeard[source] = at
+		}
+	}
+	stateMu.Unlock()
+
+	if err := json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":           meta.Status,
+		"suspended_by":     suspendedByReason(marketID),
+		"lead_source":      leadSource,
+		"source_last_seen": lastHeard,
+		"sources":          feedReconciler.Status(marketID),
+		"health":           feedMonitor.Report(marketID),
+	}); err != nil {
+		http.Error(w, "failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
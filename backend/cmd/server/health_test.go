@@ -0,0 +1,102 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"cs2-prediction-engine/internal/engine"
+	"cs2-prediction-engine/internal/feedhealth"
+)
+
+// A circuit_breaker resume from the feed socket must not reopen a market held
+// by a manual-recovery feed rule; only an operator resume clears it.
+func TestCircuitBreakerResumeKeepsManualRecoveryHold(t *testing.T) {
+	startTestEngine(t)
+	createMarket(AdapterMarketCreatedPayload{MarketID: "series_s1_winner", SeriesID: "s1"})
+
+	observeFeedHealth("series_s1_winner", engine.MarketGameState{Map: "mirage", TerroristScore: 10, CTScore: 3}, time.Now())
+	observeFeedHealth("series_s1_winner", engine.MarketGameState{Map: "mirage", TerroristScore: 20, CTScore: 3}, time.Now())
+	if got := marketStatus("series_s1_winner"); got != engine.StatusSuspended {
+		t.Fatalf("status after impossible score = %s, want suspended", got)
+	}
+
+	applyCircuitBreaker(AdapterCircuitBreakerPayload{MarketID: "series_s1_winner", Action: "resume"}, []byte("{}"))
+	if got := marketStatus("series_s1_winner"); got != engine.StatusSuspended {
+		t.Errorf("status after feed resume = %s, want suspended", got)
+	}
+	if blocking := feedMonitor.Blocking("series_s1_winner"); len(blocking) == 0 {
+		t.Error("feed resume cleared the manual-recovery rule")
+	}
+
+	applyOperatorAction("series_s1_winner", operatorAction{OperatorID: "op1", Action: "resume"})
+	if got := marketStatus("series_s1_winner"); got != engine.StatusOpen {
+		t.Errorf("status after operator resume = %s, want open", got)
+	}
+	if blocking := feedMonitor.Blocking("series_s1_winner"); len(blocking) != 0 {
+		t.Errorf("rules still blocking after operator resume: %v", blocking)
+	}
+}
+
+func TestFeedMessagesRequireFeedToken(t *testing.T) {
+	url := startTestEngine(t)
+	createMarket(AdapterMarketCreatedPayload{MarketID: "series_s1_winner", SeriesID: "s1"})
+
+	if _, resp, err := websocket.DefaultDialer.Dial(url, http.Header{"Authorization": {"Bearer wrong"}}); err == nil || resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("dial with a bad feed token: err %v, want 401", err)
+	}
+
+	client := dialTestEngine(t, url)
+	sendJSON(t, client, map[string]interface{}{
+		"type":    "circuit_breaker",
+		"payload": map[string]interface{}{"market_id": "series_s1_winner", "action": "suspend", "reason": "spoofed"},
+	})
+	sendJSON(t, client, map[string]interface{}{
+		"type":    "market_created",
+		"payload": map[string]interface{}{"market_id": "spoofed_market"},
+	})
+	// A client message after the feed ones shows they have been handled.
+	sendJSON(t, client, map[string]interface{}{
+		"type":    "cancel_order",
+		"payload": map[string]interface{}{"order_id": 99, "user_id": "alice"},
+	})
+	readUntil(t, client, "cancel_rejected")
+	if got := marketStatus("series_s1_winner"); got != engine.StatusOpen {
+		t.Errorf("status = %s after an unauthenticated circuit_breaker, want open", got)
+	}
+	if _, ok := marketRegistry.GetMarket("spoofed_market"); ok {
+		t.Error("unauthenticated market_created listed a market")
+	}
+}
+
+// A feed adapter reports as the source its token names, whatever its payload
+// claims, so one adapter cannot make up a settlement quorum.
+func TestFeedSourceComesFromToken(t *testing.T) {
+	url := startTestEngine(t)
+	createMarket(AdapterMarketCreatedPayload{MarketID: "series_s1_winner", SeriesID: "s1"})
+
+	feed := dialTestFeed(t, url)
+	for _, claimed := range []string{feedhealth.SourceReplay, feedhealth.SourceManual} {
+		sendJSON(t, feed, map[string]interface{}{
+			"type": "series_state",
+			"payload": map[string]interface{}{
+				"source": claimed, "series_id": "s1", "timestamp": time.Now().UTC().Format(time.RFC3339),
+				"game_state": map[string]interface{}{"map": "mirage", "round": 2, "terrorist_score": 1, "phase": "live"},
+			},
+		})
+	}
+	waitFor(t, "feed state recorded", func() bool {
+		stateMu.Lock()
+		defer stateMu.Unlock()
+		health, ok := marketHealthByID["series_s1_winner"]
+		return ok && len(health.Sources) > 0
+	})
+	stateMu.Lock()
+	sources := marketHealthByID["series_s1_winner"].Sources
+	stateMu.Unlock()
+	if _, ok := sources[feedhealth.SourceGRID]; !ok || len(sources) != 1 {
+		t.Errorf("sources = %v, want only %s", sources, feedhealth.SourceGRID)
+	}
+}
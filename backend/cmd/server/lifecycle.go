@@ -0,0 +1,103 @@
+package main
+
+import (
+	"encoding/json"
+	"log/slog"
+
+	"cs2-prediction-engine/internal/engine"
+)
+
+// registerLifecycleHooks ties book state and order handling to the market
+// lifecycle. Hooks run on the goroutine that requested the transition, which
+// for every transition after creation is the market's sequencer.
+func registerLifecycleHooks(registry *engine.MarketRegistry) {
+	// Only open markets match; every other status keeps the book frozen.
+	registry.OnEnter(engine.StatusOpen, func(marketID string, _ engine.LifecycleTransition) {
+		marketManager.GetOrderBook(marketID).ResumeTrading()
+	})
+	registry.OnExit(engine.StatusOpen, func(marketID string, _ engine.LifecycleTransition) {
+		marketManager.GetOrderBook(marketID).SuspendTrading()
+	})
+	registry.OnEnter(engine.StatusScheduled, func(marketID string, _ engine.LifecycleTransition) {
+		marketManager.GetOrderBook(marketID).SuspendTrading()
+	})
+
+	// Whatever suspended the market no longer applies once it moves on.
+	registry.OnExit(engine.StatusSuspended, func(marketID string, _ engine.LifecycleTransition) {
+		stateMu.Lock()
+		if health, ok := marketHealthByID[marketID]; ok {
+			health.SuspendedByReason = ""
+		}
+		stateMu.Unlock()
+	})
+
+	registry.OnEnter(engine.StatusClosed, cancelRestingOrders)
+
+	for _, status := range []engine.MarketStatus{
+		engine.StatusScheduled, engine.StatusOpen, engine.StatusSuspended, engine.StatusClosed,
+		engine.StatusResolving, engine.StatusSettled, engine.StatusVoided,
+	} {
+		registry.OnEnter(status, publishLifecycleTransition)
+	}
+}
+
+// cancelRestingOrders pulls every order off a closed market's book and
+// releases its reserve.
+func cancelRestingOrders(marketID string, t engine.LifecycleTransition) {
+	book := marketManager.GetOrderBook(marketID)
+	cancelled := book.CancelWhere(func(*engine.Order) bool { return true })
+	for _, o := range cancelled {
+		closeOrderRecord(o.ID, engine.OrderCancelled, "market_closed")
+	}
+	if len(cancelled) > 0 {
+		slog.Info("market closed", "market_id", marketID, "reason", t.Reason, "cancelled_orders", len(cancelled))
+	}
+}
+
+func publishLifecycleTransition(marketID string, t engine.LifecycleTransition) {
+	statusMsg, _ := json.Marshal(map[string]interface{}{
+		"type": "market_status_changed",
+		"payload": map[string]interface{}{
+			"market_id":  marketID,
+			"transition": t,
+		},
+	})
+	hub.Broadcast(statusMsg)
+}
+
+// transitionMarket applies a lifecycle transition, logging refusals.
+func transitionMarket(marketID string, status engine.MarketStatus, reason string) bool {
+	if _, err := marketRegistry.Transition(marketID, status, reason); err != nil {
+		slog.Warn("market transition refused", "market_id", marketID, "error", err)
+		return false
+	}
+	return true
+}
+
+// canResolve reports whether a market may be walked to resolving.
+func canResolve(status engine.MarketStatus) bool {
+	switch status {
+	case engine.StatusOpen, engine.StatusSuspended, engine.StatusClosed, engine.StatusResolving:
+		return true
+	}
+	return false
+}
+
+// beginResolving closes a market if it is still trading and moves it to
+// resolving. It is a no-op for markets already resolving.
+func beginResolving(marketID string, reason string) bool {
+	meta, ok := marketRegistry.GetMarket(marketID)
+	if !ok {
+		slog.Warn("cannot resolve unknown market", "market_id", marketID)
+		return false
+	}
+	switch meta.Status {
+	case engine.StatusResolving:
+		return true
+	case engine.StatusOpen, engine.StatusSuspended:
+		if !transitionMarket(marketID, engine.StatusClosed, reason) {
+			return false
+		}
+	}
+	return transitionMarket(marketID, engine.StatusResolving, reason)
+}
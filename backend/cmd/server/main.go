@@ -2,9 +2,7 @@ package main
 
 import (
 	"encoding/json"
-	"fmt"
-	"log"
-	"math"
+	"log/slog"
 	"net/http"
 	"strings"
 	"sync"
@@ -12,32 +10,155 @@ import (
 	"time"
 
 	"cs2-prediction-engine/internal/audit"
+	"cs2-prediction-engine/internal/config"
 	"cs2-prediction-engine/internal/engine"
+	"cs2-prediction-engine/internal/feedhealth"
+	"cs2-prediction-engine/internal/gateway"
+	"cs2-prediction-engine/internal/surveillance"
+	"cs2-prediction-engine/internal/telemetry"
 
 	"github.com/gorilla/websocket"
 )
 
+// upgrader.CheckOrigin is set from the configured origin allowlist in main.
 var upgrader = websocket.Upgrader{
 	ReadBufferSize:  1024,
 	WriteBufferSize: 1024,
-	CheckOrigin:     func(r *http.Request) bool { return true },
 }
 
 // Hub manages active WebSocket clients
 type Hub struct {
 	clients    map[*websocket.Conn]bool
 	broadcast  chan []byte
+	direct     chan directMessage
 	register   chan *websocket.Conn
 	unregister chan *websocket.Conn
+	ping       chan chan struct{}
+	closeAll   chan closeRequest
 	mu         sync.Mutex
+
+	// portfolioSubs maps a connection to the users whose portfolio it follows.
+	portfolioSubs map[*websocket.Conn]map[string]engine.MarkSource
+}
+
+// directMessage is delivered to a single connection rather than broadcast.
+type directMessage struct {
+	conn    *websocket.Conn
+	message []byte
+}
+
+// closeRequest asks Run to close every connection with a close frame.
+type closeRequest struct {
+	code int
+	text string
+	done chan struct{}
+}
+
+type portfolioSubscription struct {
+	conn   *websocket.Conn
+	userID string
+	mark   engine.MarkSource
 }
 
+const (
+	// hubQueueSize bounds the messages waiting for Run; senders drop rather
+	// than wait once it is full.
+	hubQueueSize = 4096
+	// hubWriteTimeout caps a single client write so one stalled socket cannot
+	// hold up delivery to everyone else.
+	hubWriteTimeout = 5 * time.Second
+)
+
 func NewHub() *Hub {
 	return &Hub{
-		clients:    make(map[*websocket.Conn]bool),
-		broadcast:  make(chan []byte),
-		register:   make(chan *websocket.Conn),
-		unregister: make(chan *websocket.Conn),
+		clients:       make(map[*websocket.Conn]bool),
+		broadcast:     make(chan []byte, hubQueueSize),
+		direct:        make(chan directMessage, hubQueueSize),
+		register:      make(chan *websocket.Conn),
+		unregister:    make(chan *websocket.Conn),
+		ping:          make(chan chan struct{}),
+		closeAll:      make(chan closeRequest),
+		portfolioSubs: make(map[*websocket.Conn]map[string]engine.MarkSource),
+	}
+}
+
+func (h *Hub) SubscribePortfolio(conn *websocket.Conn, userID string, mark engine.MarkSource) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	subs, ok := h.portfolioSubs[conn]
+	if !ok {
+		subs = make(map[string]engine.MarkSource)
+		h.portfolioSubs[conn] = subs
+	}
+	subs[userID] = mark
+}
+
+func (h *Hub) UnsubscribePortfolio(conn *websocket.Conn, userID string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if subs, ok := h.portfolioSubs[conn]; ok {
+		delete(subs, userID)
+	}
+}
+
+func (h *Hub) PortfolioSubscriptions() []portfolioSubscription {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	var out []portfolioSubscription
+	for conn, subs := range h.portfolioSubs {
+		for userID, mark := range subs {
+			out = append(out, portfolioSubscription{conn: conn, userID: userID, mark: mark})
+		}
+	}
+	return out
+}
+
+func (h *Hub) ClientCount() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.clients)
+}
+
+// Ping reports whether Run answers within timeout.
+func (h *Hub) Ping(timeout time.Duration) bool {
+	reply := make(chan struct{})
+	select {
+	case h.ping <- reply:
+	case <-time.After(timeout):
+		return false
+	}
+	select {
+	case <-reply:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
+
+// CloseAll sends every client a close frame and disconnects it. Run keeps
+// going, so later broadcasts are simply delivered to no one.
+func (h *Hub) CloseAll(code int, text string) {
+	done := make(chan struct{})
+	h.closeAll <- closeRequest{code: code, text: text, done: done}
+	<-done
+}
+
+// Broadcast queues message for every client. It never blocks: when Run has
+// fallen behind the message is dropped and counted.
+func (h *Hub) Broadcast(message []byte) {
+	select {
+	case h.broadcast <- message:
+	default:
+		hubDropped.Inc("broadcast", "queue_full")
+	}
+}
+
+// Send queues a message for one client without blocking, like Broadcast.
+func (h *Hub) Send(dm directMessage) {
+	select {
+	case h.direct <- dm:
+	default:
+		hubDropped.Inc("direct", "queue_full")
 	}
 }
 
@@ -48,7 +169,7 @@ func (h *Hub) Run() {
 			h.mu.Lock()
 			h.clients[client] = true
 			h.mu.Unlock()
-			fmt.Println("Client Connected")
+			slog.Debug("websocket client connected", "remote_addr", client.RemoteAddr().String())
 
 		case client := <-h.unregister:
 			h.mu.Lock()
@@ -56,16 +177,51 @@ func (h *Hub) Run() {
 				delete(h.clients, client)
 				client.Close()
 			}
+			delete(h.portfolioSubs, client)
+			h.mu.Unlock()
+			slog.Debug("websocket client disconnected", "remote_addr", client.RemoteAddr().String())
+
+		case dm := <-h.direct:
+			h.mu.Lock()
+			if _, ok := h.clients[dm.conn]; ok {
+				dm.conn.SetWriteDeadline(time.Now().Add(hubWriteTimeout))
+				if err := dm.conn.WriteMessage(websocket.TextMessage, dm.message); err != nil {
+					hubDropped.Inc("direct", "write_error")
+					dm.conn.Close()
+					delete(h.clients, dm.conn)
+					delete(h.portfolioSubs, dm.conn)
+				}
+			} else {
+				hubDropped.Inc("direct", "disconnected")
+			}
 			h.mu.Unlock()
-			fmt.Println("Client Disconnected")
+
+		case reply := <-h.ping:
+			close(reply)
+
+		case req := <-h.closeAll:
+			h.mu.Lock()
+			frame := websocket.FormatCloseMessage(req.code, req.text)
+			for client := range h.clients {
+				client.WriteControl(websocket.CloseMessage, frame, time.Now().Add(time.Second))
+				client.Close()
+				delete(h.clients, client)
+				delete(h.portfolioSubs, client)
+			}
+			h.mu.Unlock()
+			close(req.done)
 
 		case message := <-h.broadcast:
 			h.mu.Lock()
+			deadline := time.Now().Add(hubWriteTimeout)
 			for client := range h.clients {
+				client.SetWriteDeadline(deadline)
 				err := client.WriteMessage(websocket.TextMessage, message)
 				if err != nil {
+					hubDropped.Inc("broadcast", "write_error")
 					client.Close()
 					delete(h.clients, client)
+					delete(h.portfolioSubs, client)
 				}
 			}
 			h.mu.Unlock()
@@ -74,6 +230,7 @@ func (h *Hub) Run() {
 }
 
 type AdapterSeriesStatePayload struct {
+	Source    string    `json:"source,omitempty"` // Set from the adapter's feed token; a claimed value is ignored
 	SeriesID  string    `json:"series_id"`
 	Timestamp string    `json:"timestamp"`
 	GameState GameState `json:"game_state"`
@@ -96,6 +253,11 @@ type AdapterMarketCreatedPayload struct {
 	Tournament string   `json:"tournament"`
 	Teams      []string `json:"teams"`
 	StartTime  string   `json:"start_time"`
+
+	MatchingMode    string `json:"matching_mode,omitempty"`
+	BatchIntervalMs int64  `json:"batch_interval_ms,omitempty"`
+
+	CancelRules engine.EventRules `json:"cancel_rules,omitempty"`
 }
 
 type AdapterCircuitBreakerPayload struct {
@@ -106,15 +268,25 @@ type AdapterCircuitBreakerPayload struct {
 }
 
 type MarketHealthState struct {
-	LastState         GameState
-	HasLastState      bool
 	SuspendedByReason string
-	HealthyStreak     int
+	// LeadSource is the feed source currently driving the market; Sources
+	// records when each source was last heard from.
+	LeadSource string
+	Sources    map[string]time.Time
 }
 
+// OrderRecord tracks a live order until it is filled, cancelled or rejected,
+// at which point it is compacted into orderHistory.
 type OrderRecord struct {
 	Order             engine.Order
 	ReservedRemaining int64
+	FilledQuantity    int64
+	FillNotional      int64
+	UpdatedAt         time.Time
+
+	// conn receives the order's private lifecycle events; nil for orders
+	// that did not arrive over a WebSocket.
+	conn *websocket.Conn
 }
 
 var (
@@ -123,48 +295,120 @@ var (
 	marketRegistry   *engine.MarketRegistry
 	ledger           *engine.Ledger
 	buffer           *engine.FairnessBuffer
+	fairnessDelay    *engine.DelayController
+	tradeStore       *engine.TradeStore
+	sequencers       *engine.SequencerPool
 	auditLog         *audit.VeritasChain
+	feedMonitor      *feedhealth.Monitor
+	feedReconciler   *feedhealth.Reconciler
+	schedulePolicy   = engine.DefaultSchedulePolicy
+	riskEngine       *engine.RiskEngine
+	exposureMonitor  *engine.ExposureMonitor
+	marketWatch      *surveillance.Monitor
+	tracer           *telemetry.Tracer
+	snapshotStore    *engine.SnapshotStore
+	feedAuth         *gateway.FeedAuth
+	draining         atomic.Bool
 	marketHealthByID = map[string]*MarketHealthState{}
-	orderRecords     = map[uint64]*OrderRecord{}
-	orderMu          sync.Mutex
+	liveOrders       *orderStore
+	orderHistory     engine.OrderHistoryStore
 	nextOrderID      uint64
 	stateMu          sync.Mutex
 )
 
-const (
-	defaultUserID         = "demo_user_1"
-	defaultInitialBalance = int64(1000000) // 10,000 IFC with 2 implied decimals
+const sequencerQueueSize = 4096
+
+// Account defaults are read from config at startup.
+var (
+	defaultUserID         string
+	defaultInitialBalance int64
 )
 
 func main() {
+	cfg, configPath := loadConfig()
+
+	var logger *slog.Logger
+	logger, tracer = setupTelemetry(cfg)
+	initEngine(cfg)
+
+	go hub.Run()
+	go buffer.Run(releaseBufferedOrders)
+	go runFeedHealthChecks(feedHealthTick)
+	go runMarketScheduler(scheduleTick)
+	go runExposureChecks(exposureTick)
+	go runSnapshots(cfg.Snapshot.Interval, cfg.Snapshot.Keep)
+	go watchConfig(configPath)
+
+	origins := gateway.NewOriginPolicy(cfg.Server.AllowedOrigins())
+	upgrader.CheckOrigin = origins.CheckOrigin
+	slog.Info("origin allowlist", "environment", cfg.Server.Environment, "origins", cfg.Server.AllowedOrigins())
+
+	feedAuth = feedAuthFromEnv()
+	http.HandleFunc("/ws", handleWebSocket)
+	http.Handle("/markets", origins.CORS(http.HandlerFunc(handleMarkets)))
+	http.Handle("/markets/", origins.CORS(http.HandlerFunc(handleMarketByID)))
+	http.Handle("/users/", origins.CORS(http.HandlerFunc(handleUserBalance)))
+	http.Handle("/metrics", metricsRegistry.Handler())
+	http.HandleFunc("/healthz", handleHealthz)
+	http.HandleFunc("/readyz", handleReadyz)
+	registerAdminRoutes(operatorAuthFromEnv())
+
+	slog.Info("engine listening", "addr", cfg.Server.Addr)
+	serveUntilSignal(newHTTPServer(cfg.Server, telemetry.Middleware(logger, tracer, http.DefaultServeMux)), cfg.Server.ShutdownTimeout)
+}
+
+// initEngine builds the engine's shared state from cfg without starting any
+// goroutines.
+func initEngine(cfg config.Config) {
+	defaultUserID = cfg.Accounts.DefaultUserID
+	defaultInitialBalance = cfg.Accounts.DefaultBalance
 	hub = NewHub()
 	marketManager = engine.NewMarketManager()
 	marketRegistry = engine.NewMarketRegistry()
+	registerLifecycleHooks(marketRegistry)
 	ledger = engine.NewLedger()
 	ledger.EnsureUser(defaultUserID, defaultInitialBalance)
-	buffer = engine.NewFairnessBuffer(3 * time.Second)
+	buffer = engine.NewFairnessBuffer(cfg.Fairness.BaseDelay)
+	fairnessDelay = engine.NewDelayController(cfg.DelayPolicy())
+	tradeStore = engine.NewTradeStore()
+	orderHistory = engine.NewMemoryOrderHistory(cfg.Accounts.OrderHistoryPerUser)
+	liveOrders = newOrderStore(cfg.Accounts.OrderHistoryPerUser)
+	sequencers = engine.NewSequencerPool(marketManager, sequencerQueueSize, handleSequencedCommand)
 	auditLog = audit.NewVeritasChain()
+	feedMonitor = feedhealth.NewMonitor(feedhealth.Rules(cfg.FeedRuleSettings()), feedHealthHistory)
+	feedReconciler = feedhealth.NewReconciler(cfg.ReconcileConfig())
+	riskEngine = engine.NewRiskEngine(cfg.RiskConfig())
+	exposureMonitor = engine.NewExposureMonitor(cfg.ExposureThresholds())
+	marketWatch = surveillance.NewMonitor(surveillance.DefaultConfig)
+	snapshotStore = engine.NewSnapshotStore(cfg.Snapshot.Path)
+	applySettings(cfg)
+}
 
-	go hub.Run()
-	go processBuffer()
-
-	http.HandleFunc("/ws", handleWebSocket)
-	http.HandleFunc("/markets", handleMarkets)
-	http.HandleFunc("/markets/", handleMarketByID)
-	http.HandleFunc("/users/", handleUserBalance)
-
-	fmt.Println("Information Finance Engine Live on :8080")
-	if err := http.ListenAndServe(":8080", nil); err != nil {
-		log.Fatalf("Server Failed: %v", err)
-	}
+// feedMessages may only be sent by a connection that authenticated as a feed
+// adapter; everything else on /ws is client traffic.
+var feedMessages = map[string]bool{
+	"market_created":  true,
+	"series_state":    true,
+	"circuit_breaker": true,
+	"series_status":   true,
+	"game_event":      true,
 }
 
 func handleWebSocket(w http.ResponseWriter, r *http.Request) {
+	feedSource, presented, isFeed := feedAuth.Source(r)
+	if presented && !isFeed {
+		http.Error(w, "invalid feed token", http.StatusUnauthorized)
+		return
+	}
 	conn, err := upgrader.Upgrade(w, r, nil)
 	if err != nil {
-		log.Printf("Upgrade Error: %v", err)
+		slog.Warn("websocket upgrade failed", "correlation_id", telemetry.CorrelationID(r.Context()), "error", err)
 		return
 	}
+	connLogger := slog.With("conn_id", telemetry.CorrelationID(r.Context()))
+	if isFeed {
+		connLogger = connLogger.With("feed_source", feedSource)
+	}
 	hub.register <- conn
 
 	defer func() {
@@ -179,6 +423,14 @@ func handleWebSocket(w http.ResponseWriter, r *http.Request) {
 
 		var msg map[string]interface{}
 		if err := json.Unmarshal(message, &msg); err != nil {
+			connLogger.Debug("ignoring malformed websocket message", "error", err)
+			continue
+		}
+		// Every message gets its own correlation ID at receipt.
+		correlationID := telemetry.NewCorrelationID()
+		msgLogger := connLogger.With("correlation_id", correlationID, "message_type", msg["type"])
+		if msgType, _ := msg["type"].(string); feedMessages[msgType] && !isFeed {
+			msgLogger.Warn("rejected feed message from unauthenticated connection")
 			continue
 		}
 
@@ -187,160 +439,244 @@ func handleWebSocket(w http.ResponseWriter, r *http.Request) {
 			var order engine.Order
 			json.Unmarshal(orderBytes, &order)
 			order.Timestamp = time.Now()
+			// Correlation IDs key traces and audit entries, so the engine
+			// always assigns them; a caller's own ID is kept alongside.
+			order.ClientCorrelationID = telemetry.ClientCorrelationID(order.CorrelationID)
+			order.CorrelationID = correlationID
 
 			if order.UserID == "" {
 				order.UserID = defaultUserID
 			}
+			// The book indexes resting orders by ID, so the engine always
+			// assigns it; callers correlate through client_order_id.
+			order.ID = atomic.AddUint64(&nextOrderID, 1)
+			if draining.Load() {
+				rejectOrder(conn, order, "server_shutting_down")
+				continue
+			}
 			if order.Quantity <= 0 || order.Price <= 0 || order.Price >= 100 {
-				sendOrderRejected(conn, order.MarketID, "invalid_order_payload")
+				rejectOrder(conn, order, "invalid_order_payload")
 				continue
 			}
-			if order.ID == 0 {
-				order.ID = atomic.AddUint64(&nextOrderID, 1)
+			if _, err := engine.ParseSelfTradeMode(string(order.SelfTradePrevention)); err != nil {
+				rejectOrder(conn, order, "invalid_self_trade_prevention")
+				continue
 			}
 
+			// Books and sequencers are only ever created for listed markets.
+			meta, ok := marketRegistry.GetMarket(order.MarketID)
+			if !ok {
+				rejectOrder(conn, order, "unknown_market")
+				continue
+			}
 			ob := marketManager.GetOrderBook(order.MarketID)
 			if ob.IsTradingSuspended() {
-				sendOrderRejected(conn, order.MarketID, "trading_suspended")
+				rejectOrder(conn, order, "trading_suspended")
 				continue
 			}
-			if meta, ok := marketRegistry.GetMarket(order.MarketID); ok && meta.Status == "settled" {
-				sendOrderRejected(conn, order.MarketID, "market_settled")
+			if !meta.Status.AcceptsOrders() {
+				rejectOrder(conn, order, "market_"+string(meta.Status))
 				continue
 			}
 
 			requiredReserve := requiredReserveForOrder(order)
 			ledger.EnsureUser(order.UserID, defaultInitialBalance)
-			if !ledger.Reserve(order.UserID, requiredReserve) {
-				sendOrderRejected(conn, order.MarketID, "insufficient_balance")
+			if rejection, reason := admitOrder(order, requiredReserve, conn); rejection != nil {
+				rejectOrderForRisk(conn, order, rejection)
+				continue
+			} else if reason != "" {
+				rejectOrder(conn, order, reason)
 				continue
 			}
-			storeOrderRecord(order, requiredReserve)
 
-			buffer.Add(&order)
-			fmt.Printf("Order Buffered: %s %s @ %d (Market: %s)\n", order.Side, order.Outcome, order.Price, order.MarketID)
+			delay := orderDelay(order.MarketID)
+			if !buffer.AddWithDelay(&order, delay) {
+				// Shutdown stopped the buffer after the draining check.
+				closeOrderRecord(order.ID, engine.OrderRejected, "server_shutting_down")
+				endOrderTrace(order, "rejected", "server_shutting_down", time.Now())
+				continue
+			}
+			ordersAccepted.Inc(order.MarketID)
+			acceptedAt := time.Now()
+			traceOrderStage(order, "order.accept", order.Timestamp, acceptedAt, nil)
+			sendOrderEvent(order.ID, "order_accepted", map[string]interface{}{
+				"fairness_delay_ms": delay.Milliseconds(),
+			})
+			orderLogger(order).Debug("order buffered",
+				"user_id", order.UserID, "side", order.Side, "outcome", order.Outcome,
+				"price", order.Price, "quantity", order.Quantity, "fairness_delay", delay)
 		} else if msg["type"] == "market_created" {
 			payloadBytes, _ := json.Marshal(msg["payload"])
 			var payload AdapterMarketCreatedPayload
 			if err := json.Unmarshal(payloadBytes, &payload); err != nil {
-				log.Printf("Failed to parse market_created payload: %v", err)
+				msgLogger.Warn("invalid payload", "error", err)
 				continue
 			}
-			marketManager.GetOrderBook(payload.MarketID)
-			marketRegistry.UpsertMarket(engine.MarketMetadata{
-				MarketID:   payload.MarketID,
-				SeriesID:   payload.SeriesID,
-				Title:      payload.Title,
-				Tournament: payload.Tournament,
-				Teams:      payload.Teams,
-				StartTime:  payload.StartTime,
-				Status:     "active",
-			})
-			hub.broadcast <- message
+			createMarket(payload)
+			hub.Broadcast(message)
 		} else if msg["type"] == "series_state" {
 			payloadBytes, _ := json.Marshal(msg["payload"])
 			var payload AdapterSeriesStatePayload
 			if err := json.Unmarshal(payloadBytes, &payload); err != nil {
-				log.Printf("Failed to parse series_state payload: %v", err)
+				msgLogger.Warn("invalid payload", "error", err)
 				continue
 			}
 
-			marketID := "series_" + payload.SeriesID + "_winner"
-			marketManager.GetOrderBook(marketID)
+			// Each source counts once toward settlement quorum, so the source
+			// is the adapter's authenticated identity, never the payload's.
+			if payload.Source != "" && payload.Source != feedSource {
+				msgLogger.Warn("ignoring claimed feed source", "claimed", payload.Source, "series_id", payload.SeriesID)
+			}
+			payload.Source = feedSource
 
-			if meta, ok := marketRegistry.GetMarket(marketID); ok && meta.Status == "settled" {
+			marketID := "series_" + payload.SeriesID + "_winner"
+			submitFeedEvent(marketID, feedEvent{raw: message, seriesState: &payload, receivedAt: time.Now(), correlationID: correlationID})
+		} else if msg["type"] == "circuit_breaker" {
+			payloadBytes, _ := json.Marshal(msg["payload"])
+			var payload AdapterCircuitBreakerPayload
+			if err := json.Unmarshal(payloadBytes, &payload); err != nil {
+				msgLogger.Warn("invalid payload", "error", err)
 				continue
 			}
-
-			marketRegistry.UpdateMarketGameState(marketID, engine.MarketGameState{
-				Map:            payload.GameState.Map,
-				Round:          payload.GameState.Round,
-				TerroristScore: payload.GameState.TerroristScore,
-				CTScore:        payload.GameState.CTScore,
-				BombPlanted:    payload.GameState.BombPlanted,
-				Phase:          payload.GameState.Phase,
-				LastAction:     payload.GameState.LastAction,
-				Timestamp:      payload.Timestamp,
-			})
-
-			if isScoreAnomalous(marketID, payload.GameState) {
-				suspendMarket(marketID, "score_anomaly")
-			} else {
-				maybeResumeAfterHealthyUpdates(marketID)
+			submitFeedEvent(payload.MarketID, feedEvent{raw: message, circuitBreaker: &payload, correlationID: correlationID})
+		} else if msg["type"] == "series_status" {
+			payloadBytes, _ := json.Marshal(msg["payload"])
+			var payload AdapterSeriesStatusPayload
+			if err := json.Unmarshal(payloadBytes, &payload); err != nil {
+				msgLogger.Warn("invalid payload", "error", err)
+				continue
 			}
-			if payload.GameState.Phase == "ended" {
-				marketRegistry.UpdateMarketStatus(marketID, "settled")
-				settleMarket(marketID, payload)
+			if payload.MarketID == "" {
+				payload.MarketID = "series_" + payload.SeriesID + "_winner"
 			}
-
-			gameEventMsg, _ := json.Marshal(map[string]interface{}{
-				"type": "game_event",
-				"payload": map[string]interface{}{
-					"series_id": payload.SeriesID,
-					"game_state": map[string]interface{}{
-						"round":           payload.GameState.Round,
-						"terrorist_score": payload.GameState.TerroristScore,
-						"ct_score":        payload.GameState.CTScore,
-						"bomb_planted":    payload.GameState.BombPlanted,
-					},
-					"last_action": payload.GameState.LastAction,
-				},
-			})
-			hub.broadcast <- gameEventMsg
-			hub.broadcast <- message
-		} else if msg["type"] == "circuit_breaker" {
+			submitFeedEvent(payload.MarketID, feedEvent{raw: message, seriesStatus: &payload, correlationID: correlationID})
+		} else if msg["type"] == "subscribe_portfolio" {
 			payloadBytes, _ := json.Marshal(msg["payload"])
-			var payload AdapterCircuitBreakerPayload
+			var payload PortfolioSubscribePayload
+			if err := json.Unmarshal(payloadBytes, &payload); err != nil {
+				continue
+			}
+			if payload.UserID == "" {
+				payload.UserID = defaultUserID
+			}
+			mark, ok := engine.ParseMarkSource(payload.Mark)
+			if !ok {
+				mark = engine.MarkMid
+			}
+			hub.SubscribePortfolio(conn, payload.UserID, mark)
+			if update, ok := portfolioUpdateMessage(payload.UserID, mark); ok {
+				hub.Send(directMessage{conn: conn, message: update})
+			}
+		} else if msg["type"] == "unsubscribe_portfolio" {
+			payloadBytes, _ := json.Marshal(msg["payload"])
+			var payload PortfolioSubscribePayload
+			if err := json.Unmarshal(payloadBytes, &payload); err != nil {
+				continue
+			}
+			if payload.UserID == "" {
+				payload.UserID = defaultUserID
+			}
+			hub.UnsubscribePortfolio(conn, payload.UserID)
+		} else if msg["type"] == "cancel_order" {
+			payloadBytes, _ := json.Marshal(msg["payload"])
+			var payload CancelOrderPayload
 			if err := json.Unmarshal(payloadBytes, &payload); err != nil {
-				log.Printf("Failed to parse circuit_breaker payload: %v", err)
 				continue
 			}
-			if payload.Action == "suspend" {
-				suspendMarket(payload.MarketID, payload.Reason)
-			} else if payload.Action == "resume" {
-				resumeMarket(payload.MarketID, payload.Reason)
+			if payload.UserID == "" {
+				payload.UserID = defaultUserID
 			}
-			hub.broadcast <- message
+			msgLogger.Debug("cancel requested", "order_id", payload.OrderID, "user_id", payload.UserID)
+			submitCancel(conn, payload)
 		} else if msg["type"] == "game_event" {
-			hub.broadcast <- message
+			hub.Broadcast(message)
 		}
 	}
 }
 
-func sendOrderRejected(conn *websocket.Conn, marketID string, reason string) {
-	rejectMsg, _ := json.Marshal(map[string]interface{}{
-		"type": "order_rejected",
-		"payload": map[string]interface{}{
-			"market_id": marketID,
-			"reason":    reason,
-		},
+// createMarket registers a market and its book. Re-sending an existing market
+// refreshes its metadata but keeps its lifecycle state.
+func createMarket(payload AdapterMarketCreatedPayload) engine.MarketMetadata {
+	mode, err := engine.ParseMatchingMode(payload.MatchingMode)
+	if err != nil {
+		slog.Warn("ignoring matching_mode", "market_id", payload.MarketID, "matching_mode", payload.MatchingMode, "error", err)
+		mode = engine.MatchingContinuous
+	}
+	var batchInterval time.Duration
+	if mode == engine.MatchingBatch {
+		batchInterval = batchIntervalOrDefault(payload.BatchIntervalMs)
+	}
+	if err := payload.CancelRules.Validate(); err != nil {
+		slog.Warn("ignoring cancel_rules", "market_id", payload.MarketID, "error", err)
+		payload.CancelRules = nil
+	}
+	_, existed := marketRegistry.GetMarket(payload.MarketID)
+	start, hasStart := engine.MarketMetadata{StartTime: payload.StartTime}.ScheduledStart()
+	status, phase := schedulePolicy.Initial(start, hasStart, time.Now())
+	marketManager.GetOrderBook(payload.MarketID)
+	marketRegistry.UpsertMarket(engine.MarketMetadata{
+		MarketID:        payload.MarketID,
+		SeriesID:        payload.SeriesID,
+		Title:           payload.Title,
+		Tournament:      payload.Tournament,
+		Teams:           payload.Teams,
+		StartTime:       payload.StartTime,
+		Status:          status,
+		Phase:           phase,
+		MatchingMode:    mode,
+		BatchIntervalMs: batchInterval.Milliseconds(),
+		CancelRules:     payload.CancelRules,
 	})
-	if err := conn.WriteMessage(websocket.TextMessage, rejectMsg); err != nil {
-		log.Printf("Failed to send order rejection: %v", err)
+	if !existed && mode == engine.MatchingBatch {
+		submitMatchingMode(payload.MarketID, mode, batchInterval)
 	}
+	meta, _ := marketRegistry.GetMarket(payload.MarketID)
+	return meta
+}
+
+func rejectOrder(conn *websocket.Conn, order engine.Order, reason string) {
+	recordRejectedOrder(order, reason)
+	record := OrderRecord{Order: order, conn: conn}
+	var out outbox
+	emitOrderEvent(&out, &record, "order_rejected", map[string]interface{}{
+		"reason": reason,
+	})
+	out.send()
 }
 
-func storeOrderRecord(order engine.Order, reserved int64) {
-	orderMu.Lock()
-	defer orderMu.Unlock()
-	orderRecords[order.ID] = &OrderRecord{
+// storeOrderRecord adds an accepted order to the live set. It reports false,
+// storing nothing, when the user already used the order's client_order_id;
+// the check and the insert share the shard's critical section so concurrent
+// submissions cannot both claim an ID.
+func storeOrderRecord(order engine.Order, reserved int64, conn *websocket.Conn) bool {
+	sh := liveOrders.shard(order.MarketID)
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	stored := liveOrders.addLocked(sh, &OrderRecord{
 		Order:             order,
 		ReservedRemaining: reserved,
+		UpdatedAt:         order.Timestamp,
+		conn:              conn,
+	})
+	if stored {
+		observeOrder(order)
 	}
+	return stored
 }
 
-func settleMarket(marketID string, payload AdapterSeriesStatePayload) {
-	winner := engine.No
-	winnerLabel := "NO"
+func settlementWinner(payload AdapterSeriesStatePayload) string {
 	if payload.GameState.TerroristScore > payload.GameState.CTScore {
-		winner = engine.Yes
-		winnerLabel = "YES"
+		return "YES"
 	}
+	return "NO"
+}
+
+func settleMarket(marketID string, winnerLabel string, settledAt string, finalScore string) {
+	winner := engine.Outcome(winnerLabel)
 
-	refundOpenReservesForMarket(marketID)
+	refundOpenReservesForMarket(marketID, "market_settled")
 	results := ledger.SettleMarket(marketID, winner)
-	finalScore := fmt.Sprintf("%d-%d", payload.GameState.TerroristScore, payload.GameState.CTScore)
-	marketRegistry.UpdateSettlement(marketID, winnerLabel, payload.Timestamp, finalScore)
+	marketRegistry.UpdateSettlement(marketID, winnerLabel, settledAt, finalScore)
 
 	settlementMsg, _ := json.Marshal(map[string]interface{}{
 		"type": "market_settled",
@@ -348,48 +684,67 @@ func settleMarket(marketID string, payload AdapterSeriesStatePayload) {
 			"market_id":   marketID,
 			"winner":      winnerLabel,
 			"final_score": finalScore,
-			"settled_at":  payload.Timestamp,
+			"settled_at":  settledAt,
 			"payouts":     results,
 		},
 	})
-	hub.broadcast <- settlementMsg
+	hub.Broadcast(settlementMsg)
+	publishPortfolioUpdates(marketID)
 }
 
-func refundOpenReservesForMarket(marketID string) {
-	orderMu.Lock()
-	defer orderMu.Unlock()
-
-	for _, record := range orderRecords {
-		if record.Order.MarketID != marketID || record.ReservedRemaining <= 0 {
-			continue
+func refundOpenReservesForMarket(marketID string, reason string) {
+	liveOrders.withMarket(marketID, func(sh *orderShard, out *outbox) {
+		for orderID := range sh.records {
+			closeOrderRecordLocked(sh, orderID, engine.OrderCancelled, reason, out)
 		}
-		ledger.ReleaseReserved(record.Order.UserID, record.ReservedRemaining)
-		record.ReservedRemaining = 0
-	}
+	})
 }
 
+// applyMatchAccounting books both legs of a match. It locks only the
+// market's own order shard.
 func applyMatchAccounting(marketID string, match engine.Match) {
-	orderMu.Lock()
-	defer orderMu.Unlock()
+	liveOrders.withMarket(marketID, func(sh *orderShard, out *outbox) {
+		applyMatchLegLocked(sh, marketID, match, match.Maker.OrderID, match.Maker.Price, out)
+		applyMatchLegLocked(sh, marketID, match, match.Taker.OrderID, match.Taker.Price, out)
+	})
+	slog.Debug("match applied",
+		"market_id", marketID,
+		"maker_order_id", match.MakerOrderID, "maker_correlation_id", match.Maker.CorrelationID,
+		"taker_order_id", match.TakerOrderID, "taker_correlation_id", match.Taker.CorrelationID,
+		"price", match.Price, "quantity", match.Quantity)
+}
 
-	applyForOrder := func(orderID uint64) {
-		record, ok := orderRecords[orderID]
-		if !ok {
-			return
-		}
-		effectiveOutcome, cost := effectiveOutcomeAndCost(record.Order, match.Price, match.Quantity)
-		if cost > record.ReservedRemaining {
-			cost = record.ReservedRemaining
-		}
-		if cost > 0 {
-			ledger.MoveReservedToSpent(record.Order.UserID, cost)
-			record.ReservedRemaining -= cost
-		}
-		ledger.AddFill(record.Order.UserID, marketID, effectiveOutcome, match.Quantity, cost)
+// applyMatchLegLocked charges one leg at its own execution price; on
+// complementary fills the maker and taker prices differ and sum to 100.
+// Callers must hold sh.mu.
+func applyMatchLegLocked(sh *orderShard, marketID string, match engine.Match, orderID uint64, legPrice int64, out *outbox) {
+	record, ok := sh.records[orderID]
+	if !ok {
+		return
 	}
+	effectiveOutcome, cost := effectiveOutcomeAndCost(record.Order, legPrice, match.Quantity)
+	if cost > record.ReservedRemaining {
+		cost = record.ReservedRemaining
+	}
+	if cost > 0 {
+		ledger.MoveReservedToSpent(record.Order.UserID, cost)
+		record.ReservedRemaining -= cost
+	}
+	ledger.AddFill(record.Order.UserID, marketID, effectiveOutcome, match.Quantity, cost)
 
-	applyForOrder(match.MakerOrderID)
-	applyForOrder(match.TakerOrderID)
+	record.FilledQuantity += match.Quantity
+	record.FillNotional += legPrice * match.Quantity
+	record.UpdatedAt = match.Timestamp
+	fill := map[string]interface{}{
+		"fill_price":    legPrice,
+		"fill_quantity": match.Quantity,
+	}
+	if record.FilledQuantity >= record.Order.Quantity {
+		emitOrderEvent(out, record, "order_filled", fill)
+		closeOrderRecordLocked(sh, orderID, engine.OrderFilled, "", out)
+	} else {
+		emitOrderEvent(out, record, "order_partially_filled", fill)
+	}
 }
 
 func requiredReserveForOrder(order engine.Order) int64 {
@@ -415,61 +770,18 @@ func effectiveOutcomeAndCost(order engine.Order, executionPrice int64, quantity
 	return opp, (100 - executionPrice) * quantity
 }
 
-func isScoreAnomalous(marketID string, state GameState) bool {
-	stateMu.Lock()
-	defer stateMu.Unlock()
-
-	health, ok := marketHealthByID[marketID]
-	if !ok {
-		health = &MarketHealthState{}
-		marketHealthByID[marketID] = health
-	}
-
-	if !health.HasLastState {
-		health.LastState = state
-		health.HasLastState = true
-		return false
-	}
-
-	prev := health.LastState
-	roundDelta := state.Round - prev.Round
-	deltaT := int(math.Abs(float64(state.TerroristScore - prev.TerroristScore)))
-	deltaCT := int(math.Abs(float64(state.CTScore - prev.CTScore)))
-	totalScoreDelta := deltaT + deltaCT
-
-	health.LastState = state
-
-	// A round cannot go backwards in a valid stream.
-	if roundDelta < 0 {
-		health.HealthyStreak = 0
-		return true
-	}
-
-	// If round doesn't advance, score should not advance either.
-	if roundDelta == 0 && totalScoreDelta > 0 {
-		health.HealthyStreak = 0
-		return true
-	}
-
-	// Across N rounds, score can increase by at most N total points.
-	if roundDelta > 0 && totalScoreDelta > roundDelta {
-		health.HealthyStreak = 0
-		return true
-	}
-
-	health.HealthyStreak++
-	return false
-}
-
 func suspendMarket(marketID string, reason string) {
-	if meta, ok := marketRegistry.GetMarket(marketID); ok && meta.Status == "settled" {
+	meta, ok := marketRegistry.GetMarket(marketID)
+	switch {
+	case !ok:
+		// Circuit breakers may arrive before market_created.
+		marketManager.GetOrderBook(marketID).SuspendTrading()
+	case meta.Status == engine.StatusSuspended:
+		// Already suspended; the newer reason takes over.
+	case !transitionMarket(marketID, engine.StatusSuspended, reason):
 		return
 	}
 
-	ob := marketManager.GetOrderBook(marketID)
-	ob.SuspendTrading()
-	marketRegistry.UpdateMarketStatus(marketID, "suspended")
-
 	stateMu.Lock()
 	health, ok := marketHealthByID[marketID]
 	if !ok {
@@ -477,45 +789,26 @@ func suspendMarket(marketID string, reason string) {
 		marketHealthByID[marketID] = health
 	}
 	health.SuspendedByReason = reason
-	health.HealthyStreak = 0
 	stateMu.Unlock()
 
-	log.Printf("Market suspended: %s (reason=%s)", marketID, reason)
+	suspensions.Inc(reason)
+	slog.Info("market suspended", "market_id", marketID, "reason", reason)
 }
 
 func resumeMarket(marketID string, reason string) {
-	if meta, ok := marketRegistry.GetMarket(marketID); ok && meta.Status == "settled" {
+	if _, ok := marketRegistry.GetMarket(marketID); !ok {
+		marketManager.GetOrderBook(marketID).ResumeTrading()
+	} else if !transitionMarket(marketID, engine.StatusOpen, reason) {
 		return
 	}
 
-	ob := marketManager.GetOrderBook(marketID)
-	ob.ResumeTrading()
-	marketRegistry.UpdateMarketStatus(marketID, "active")
-
 	stateMu.Lock()
 	if health, ok := marketHealthByID[marketID]; ok {
 		health.SuspendedByReason = ""
-		health.HealthyStreak = 0
-	}
-	stateMu.Unlock()
-
-	log.Printf("Market resumed: %s (reason=%s)", marketID, reason)
-}
-
-func maybeResumeAfterHealthyUpdates(marketID string) {
-	stateMu.Lock()
-	health, ok := marketHealthByID[marketID]
-	if !ok {
-		stateMu.Unlock()
-		return
 	}
-
-	shouldResume := health.SuspendedByReason == "score_anomaly" && health.HealthyStreak >= 3
 	stateMu.Unlock()
 
-	if shouldResume {
-		resumeMarket(marketID, "auto_recovered_after_healthy_streak")
-	}
+	slog.Info("market resumed", "market_id", marketID, "reason", reason)
 }
 
 func handleMarkets(w http.ResponseWriter, r *http.Request) {
@@ -534,14 +827,36 @@ func handleMarkets(w http.ResponseWriter, r *http.Request) {
 }
 
 func handleMarketByID(w http.ResponseWriter, r *http.Request) {
+	// Expected: /markets/{marketID} or /markets/{marketID}/{trades,candles,book,sequencer,delay,health,matching}
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/markets/"), "/")
+	marketID := parts[0]
+	if marketID == "" || len(parts) > 2 {
+		http.Error(w, "invalid market id", http.StatusBadRequest)
+		return
+	}
 	if r.Method != http.MethodGet {
 		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
-
-	marketID := strings.TrimPrefix(r.URL.Path, "/markets/")
-	if marketID == "" || strings.Contains(marketID, "/") {
-		http.Error(w, "invalid market id", http.StatusBadRequest)
+	if len(parts) == 2 {
+		switch parts[1] {
+		case "trades":
+			handleMarketTrades(w, r, marketID)
+		case "candles":
+			handleMarketCandles(w, r, marketID)
+		case "book":
+			handleMarketBook(w, r, marketID)
+		case "sequencer":
+			handleMarketSequencer(w, r, marketID)
+		case "delay":
+			handleMarketDelay(w, r, marketID)
+		case "health":
+			handleMarketHealth(w, r, marketID)
+		case "matching":
+			handleMarketMatching(w, r, marketID)
+		default:
+			http.Error(w, "unknown market resource", http.StatusBadRequest)
+		}
 		return
 	}
 
@@ -566,7 +881,7 @@ func handleUserBalance(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Expected: /users/{userID}/balance or /users/{userID}/positions
+	// Expected: /users/{userID}/balance, /positions, /portfolio, /orders or /risk
 	path := strings.TrimPrefix(r.URL.Path, "/users/")
 	parts := strings.Split(path, "/")
 	if len(parts) != 2 || parts[0] == "" {
@@ -597,29 +912,24 @@ func handleUserBalance(w http.ResponseWriter, r *http.Request) {
 			http.Error(w, "failed to encode response", http.StatusInternalServerError)
 			return
 		}
+	case "portfolio":
+		handleUserPortfolio(w, r, parts[0])
+	case "orders":
+		handleUserOrders(w, r, parts[0])
+	case "risk":
+		handleUserRisk(w, r, parts[0])
 	default:
 		http.Error(w, "unknown user resource", http.StatusBadRequest)
 	}
 }
 
-func processBuffer() {
-	for {
-		batch := buffer.GetReadyOrders()
-		for _, order := range batch {
-			ob := marketManager.GetOrderBook(order.MarketID)
-			matches := ob.ProcessOrder(order)
-
-			for _, m := range matches {
-				auditLog.LogMatch(m)
-				applyMatchAccounting(order.MarketID, m)
-
-				matchMsg, _ := json.Marshal(map[string]interface{}{
-					"type":    "match_occurred",
-					"payload": m,
-				})
-				hub.broadcast <- matchMsg
-			}
-		}
-		time.Sleep(100 * time.Millisecond)
+// releaseBufferedOrders hands a shuffled FairnessBuffer batch to the market
+// sequencers.
+func releaseBufferedOrders(batch []*engine.Order) {
+	now := time.Now()
+	for _, order := range batch {
+		bufferWait.Observe(now.Sub(order.Timestamp).Seconds())
+		traceOrderStage(*order, "fairness_buffer.wait", order.Timestamp, now, nil)
+		submitOrder(order)
 	}
 }
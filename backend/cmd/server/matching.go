@@ -0,0 +1,132 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"cs2-prediction-engine/internal/engine"
+)
+
+const (
+	defaultBatchInterval = time.Second
+	minBatchInterval     = 100 * time.Millisecond
+)
+
+type MatchingModeRequest struct {
+	Mode            string `json:"mode"`
+	BatchIntervalMs int64  `json:"batch_interval_ms"`
+}
+
+func batchIntervalOrDefault(ms int64) time.Duration {
+	if ms <= 0 {
+		return defaultBatchInterval
+	}
+	interval := time.Duration(ms) * time.Millisecond
+	if interval < minBatchInterval {
+		return minBatchInterval
+	}
+	return interval
+}
+
+func submitMatchingMode(marketID string, mode engine.MatchingMode, interval time.Duration) bool {
+	return sequencers.Get(marketID).Submit(engine.Command{
+		Kind: engine.CommandSetMatchingMode,
+		Payload: engine.MatchingModeChange{
+			Mode:          mode,
+			BatchInterval: interval,
+		},
+	})
+}
+
+// runAuction clears a batch-mode book. Self-trade preventions are applied
+// before the matches so decremented orders are accounted at their new size.
+func runAuction(book *engine.OrderBook, marketID string, sequence uint64) {
+	matches, prevented := book.RunAuction()
+	applySelfTradePreventions(marketID, prevented)
+	emitMatches(marketID, matches, sequence)
+	if len(matches) > 0 || len(prevented) > 0 {
+		publishPortfolioUpdates(marketID)
+	}
+}
+
+// applyMatchingMode switches a market between continuous matching and batch
+// auctions on its sequencer. Leaving batch mode runs a final auction first so
+// continuous matching never starts from a crossed book.
+func applyMatchingMode(book *engine.OrderBook, cmd engine.Command) {
+	change := cmd.Payload.(engine.MatchingModeChange)
+	s := sequencers.Get(cmd.MarketID)
+
+	switch change.Mode {
+	case engine.MatchingBatch:
+		book.SetMatchingMode(engine.MatchingBatch)
+		s.StartAuctions(change.BatchInterval)
+	case engine.MatchingContinuous:
+		s.StopAuctions()
+		if book.MatchingMode() == engine.MatchingBatch {
+			runAuction(book, cmd.MarketID, cmd.Sequence)
+		}
+		book.SetMatchingMode(engine.MatchingContinuous)
+	}
+	marketRegistry.UpdateMatchingMode(cmd.MarketID, change.Mode, change.BatchInterval)
+	slog.Info("matching mode changed", "market_id", cmd.MarketID, "mode", change.Mode)
+
+	modeMsg, _ := json.Marshal(map[string]interface{}{
+		"type": "matching_mode_changed",
+		"payload": map[string]interface{}{
+			"market_id":         cmd.MarketID,
+			"mode":              change.Mode,
+			"batch_interval_ms": change.BatchInterval.Milliseconds(),
+		},
+	})
+	hub.Broadcast(modeMsg)
+}
+
+func handleMarketMatching(w http.ResponseWriter, r *http.Request, marketID string) {
+	meta, ok := marketRegistry.GetMarket(marketID)
+	if !ok {
+		http.Error(w, "market not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]interface{}{
+		"market_id":         marketID,
+		"mode":              marketManager.GetOrderBook(marketID).MatchingMode(),
+		"batch_interval_ms": meta.BatchIntervalMs,
+	}); err != nil {
+		http.Error(w, "failed to encode response", http.StatusInternalServerError)
+	}
+}
+
+// handleAdminMarketMatching switches a market's matching mode. It is an
+// operator action: the change is audited before it is queued.
+func handleAdminMarketMatching(w http.ResponseWriter, r *http.Request, marketID string) {
+	if r.Method != http.MethodPut {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var req MatchingModeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	mode, err := engine.ParseMatchingMode(req.Mode)
+	if err != nil {
+		http.Error(w, "invalid mode (continuous, batch)", http.StatusBadRequest)
+		return
+	}
+	var interval time.Duration
+	if mode == engine.MatchingBatch {
+		interval = batchIntervalOrDefault(req.BatchIntervalMs)
+	}
+	if !submitMatchingMode(marketID, mode, interval) {
+		http.Error(w, "market busy", http.StatusServiceUnavailable)
+		return
+	}
+	auditLog.LogOperatorAction(operatorID(r), marketID, "matching_mode",
+		fmt.Sprintf("mode=%s batch_interval_ms=%d", mode, interval.Milliseconds()))
+	w.WriteHeader(http.StatusAccepted)
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"time"
+
+	"cs2-prediction-engine/internal/engine"
+	"cs2-prediction-engine/internal/metrics"
+)
+
+// Engine metrics, served in Prometheus text format on /metrics.
+var (
+	metricsRegistry = metrics.NewRegistry()
+
+	ordersAccepted = metricsRegistry.NewCounterVec("engine_orders_accepted_total",
+		"Orders accepted into the fairness buffer.", "market_id")
+	ordersRejected = metricsRegistry.NewCounterVec("engine_orders_rejected_total",
+		"Orders rejected before or at the book, by reason.", "reason")
+	matchesTotal = metricsRegistry.NewCounterVec("engine_matches_total",
+		"Matches executed.", "market_id")
+	matchedVolume = metricsRegistry.NewCounterVec("engine_matched_contracts_total",
+		"Contracts matched.", "market_id")
+	matchedNotional = metricsRegistry.NewCounterVec("engine_matched_notional_cents_total",
+		"YES-price notional matched, in cents.", "market_id")
+	orderToMatchLatency = metricsRegistry.NewHistogramVec("engine_order_to_match_latency_seconds",
+		"Time from order receipt to each of its fills as taker.", metrics.DefaultLatencyBuckets, "market_id")
+	bufferWait = metricsRegistry.NewHistogramVec("engine_fairness_buffer_wait_seconds",
+		"Time orders spend in the fairness buffer before release.", metrics.DefaultLatencyBuckets)
+	hubDropped = metricsRegistry.NewCounterVec("hub_dropped_messages_total",
+		"WebSocket messages not delivered, by kind and cause.", "kind", "cause")
+	feedLag = metricsRegistry.NewGaugeVec("feed_lag_seconds",
+		"Delay between a series_state timestamp and its receipt.", "series_id")
+	suspensions = metricsRegistry.NewCounterVec("engine_market_suspensions_total",
+		"Market suspensions, by reason.", "reason")
+)
+
+func init() {
+	metricsRegistry.NewGaugeFunc("engine_fairness_buffer_depth",
+		"Orders currently held in the fairness buffer.", nil,
+		func() []metrics.Sample {
+			if buffer == nil {
+				return nil
+			}
+			return []metrics.Sample{{Value: float64(buffer.Len())}}
+		})
+	metricsRegistry.NewGaugeFunc("hub_clients",
+		"Connected WebSocket clients.", nil,
+		func() []metrics.Sample {
+			if hub == nil {
+				return nil
+			}
+			return []metrics.Sample{{Value: float64(hub.ClientCount())}}
+		})
+	metricsRegistry.NewGaugeFunc("ledger_accounts",
+		"Accounts known to the ledger.", nil,
+		func() []metrics.Sample {
+			if ledger == nil {
+				return nil
+			}
+			return []metrics.Sample{{Value: float64(ledger.Totals().Accounts)}}
+		})
+	metricsRegistry.NewGaugeFunc("ledger_balance_cents",
+		"Ledger totals across all accounts, by balance.", []string{"balance"},
+		func() []metrics.Sample {
+			if ledger == nil {
+				return nil
+			}
+			t := ledger.Totals()
+			return []metrics.Sample{
+				{Labels: []string{"available"}, Value: float64(t.Available)},
+				{Labels: []string{"reserved"}, Value: float64(t.Reserved)},
+				{Labels: []string{"spent"}, Value: float64(t.Spent)},
+				{Labels: []string{"realized_pnl"}, Value: float64(t.RealizedPnL)},
+			}
+		})
+}
+
+func observeMatchMetrics(marketID string, m engine.Match) {
+	matchesTotal.Inc(marketID)
+	matchedVolume.Add(float64(m.Quantity), marketID)
+	yesPrice := m.Price
+	if m.Taker.Outcome == engine.No {
+		yesPrice = 100 - m.Price
+	}
+	matchedNotional.Add(float64(yesPrice*m.Quantity), marketID)
+}
+
+// observeFeedLag records how far behind the feed's own clock an update arrived.
+func observeFeedLag(marketID string, feedTime, receivedAt time.Time) {
+	seriesID := marketID
+	if meta, ok := marketRegistry.GetMarket(marketID); ok && meta.SeriesID != "" {
+		seriesID = meta.SeriesID
+	}
+	feedLag.Set(receivedAt.Sub(feedTime).Seconds(), seriesID)
+}
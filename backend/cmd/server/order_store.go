@@ -0,0 +1,188 @@
+package main
+
+import (
+	"sync"
+
+	"cs2-prediction-engine/internal/engine"
+)
+
+// orderShard holds the live orders of one market. The market's sequencer is
+// its main writer, so fills in one market never wait on another market.
+type orderShard struct {
+	mu      sync.Mutex
+	records map[uint64]*OrderRecord
+}
+
+// orderStore holds the live order records, sharded by market. Lifecycle
+// events raised while a shard is locked go into an outbox that is delivered
+// after the lock is released. Delivery queues on the hub without blocking and
+// the hub bounds each write, so a slow client never stalls a sequencer.
+type orderStore struct {
+	mu     sync.RWMutex
+	shards map[string]*orderShard
+	// byID locates an order's shard from its ID alone, since cancels arrive
+	// without a market.
+	byID sync.Map // uint64 -> *orderShard
+
+	// clientMu guards clientIDs and is only taken while holding a shard lock.
+	clientMu  sync.Mutex
+	clientIDs map[string]*userClientIDs
+	// clientRetain is how many closed orders per user keep their
+	// client_order_id reserved, matching the order history's retention.
+	clientRetain int
+}
+
+// userClientIDs holds the client_order_ids a user may not reuse: those of
+// live orders and of the most recent closed ones, oldest closed first.
+type userClientIDs struct {
+	ids    map[string]uint64 // client_order_id -> order ID
+	closed []string
+}
+
+// outbox collects order lifecycle events raised under a shard lock.
+type outbox []directMessage
+
+func (o outbox) send() {
+	for _, m := range o {
+		hub.Send(m)
+	}
+}
+
+func newOrderStore(clientRetain int) *orderStore {
+	return &orderStore{
+		shards:       make(map[string]*orderShard),
+		clientIDs:    make(map[string]*userClientIDs),
+		clientRetain: clientRetain,
+	}
+}
+
+func (s *orderStore) shard(marketID string) *orderShard {
+	s.mu.RLock()
+	sh, ok := s.shards[marketID]
+	s.mu.RUnlock()
+	if ok {
+		return sh
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if sh, ok := s.shards[marketID]; ok {
+		return sh
+	}
+	sh = &orderShard{records: make(map[uint64]*OrderRecord)}
+	s.shards[marketID] = sh
+	return sh
+}
+
+func (s *orderStore) allShards() []*orderShard {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make([]*orderShard, 0, len(s.shards))
+	for _, sh := range s.shards {
+		out = append(out, sh)
+	}
+	return out
+}
+
+// withMarket runs fn with the market's shard locked, then delivers the
+// events fn queued.
+func (s *orderStore) withMarket(marketID string, fn func(sh *orderShard, out *outbox)) {
+	sh := s.shard(marketID)
+	var out outbox
+	sh.mu.Lock()
+	fn(sh, &out)
+	sh.mu.Unlock()
+	out.send()
+}
+
+// update runs fn on a live order with its shard locked, then delivers the
+// events fn queued. It reports whether the order was live.
+func (s *orderStore) update(orderID uint64, fn func(sh *orderShard, record *OrderRecord, out *outbox)) bool {
+	v, ok := s.byID.Load(orderID)
+	if !ok {
+		return false
+	}
+	sh := v.(*orderShard)
+	var out outbox
+	sh.mu.Lock()
+	record, ok := sh.records[orderID]
+	if ok {
+		fn(sh, record, &out)
+	}
+	sh.mu.Unlock()
+	out.send()
+	return ok
+}
+
+// get returns a copy of a live order's record.
+func (s *orderStore) get(orderID uint64) (OrderRecord, bool) {
+	var copied OrderRecord
+	ok := s.update(orderID, func(_ *orderShard, record *OrderRecord, _ *outbox) {
+		copied = *record
+	})
+	return copied, ok
+}
+
+// each calls fn for every live record, holding one shard lock at a time.
+func (s *orderStore) each(fn func(record *OrderRecord)) {
+	for _, sh := range s.allShards() {
+		sh.mu.Lock()
+		for _, record := range sh.records {
+			fn(record)
+		}
+		sh.mu.Unlock()
+	}
+}
+
+// addLocked stores a record in sh. It reports false, storing nothing, when
+// the user already used the order's client_order_id. Callers must hold sh.mu.
+func (s *orderStore) addLocked(sh *orderShard, record *OrderRecord) bool {
+	if id := record.Order.ClientOrderID; id != "" {
+		s.clientMu.Lock()
+		user := s.clientIDs[record.Order.UserID]
+		if user == nil {
+			user = &userClientIDs{ids: make(map[string]uint64)}
+			s.clientIDs[record.Order.UserID] = user
+		}
+		if _, taken := user.ids[id]; taken {
+			s.clientMu.Unlock()
+			return false
+		}
+		user.ids[id] = record.Order.ID
+		s.clientMu.Unlock()
+	}
+	sh.records[record.Order.ID] = record
+	s.byID.Store(record.Order.ID, sh)
+	return true
+}
+
+// removeLocked drops a record from sh. Rejected orders never traded, so their
+// client_order_id becomes available again at once; other closed orders keep
+// theirs until they age out of the user's retained history. Callers must hold
+// sh.mu.
+func (s *orderStore) removeLocked(sh *orderShard, order engine.Order, status engine.OrderStatus) {
+	delete(sh.records, order.ID)
+	s.byID.Delete(order.ID)
+	if order.ClientOrderID == "" {
+		return
+	}
+	s.clientMu.Lock()
+	defer s.clientMu.Unlock()
+	user := s.clientIDs[order.UserID]
+	if user == nil || user.ids[order.ClientOrderID] != order.ID {
+		return
+	}
+	if status == engine.OrderRejected {
+		delete(user.ids, order.ClientOrderID)
+	} else {
+		user.closed = append(user.closed, order.ClientOrderID)
+		for len(user.closed) > s.clientRetain {
+			oldest := user.closed[0]
+			user.closed = user.closed[1:]
+			delete(user.ids, oldest)
+		}
+	}
+	if len(user.ids) == 0 {
+		delete(s.clientIDs, order.UserID)
+	}
+}
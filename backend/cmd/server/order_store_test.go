@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	"cs2-prediction-engine/internal/engine"
+)
+
+// Closed orders keep their client_order_id only while they are within the
+// user's retained history, so the index cannot grow without bound.
+func TestClientOrderIDsExpireWithHistory(t *testing.T) {
+	store := newOrderStore(2)
+	sh := store.shard("m1")
+	add := func(id uint64, clientID string) bool {
+		return store.addLocked(sh, &OrderRecord{Order: engine.Order{ID: id, MarketID: "m1", UserID: "alice", ClientOrderID: clientID}})
+	}
+	remove := func(id uint64, clientID string, status engine.OrderStatus) {
+		store.removeLocked(sh, engine.Order{ID: id, MarketID: "m1", UserID: "alice", ClientOrderID: clientID}, status)
+	}
+
+	for i := uint64(1); i <= 3; i++ {
+		clientID := fmt.Sprintf("c%d", i)
+		if !add(i, clientID) {
+			t.Fatalf("%s refused", clientID)
+		}
+		remove(i, clientID, engine.OrderFilled)
+	}
+	if !add(4, "c1") {
+		t.Error("c1 still reserved after aging out of retention")
+	}
+	if add(5, "c3") {
+		t.Error("c3 reusable while still in retention")
+	}
+	if n := len(store.clientIDs["alice"].ids); n != 3 {
+		t.Errorf("alice holds %d client IDs, want 3 (one live, two retained)", n)
+	}
+
+	// A rejected order frees its ID at once.
+	if !add(6, "r1") {
+		t.Fatal("r1 refused")
+	}
+	remove(6, "r1", engine.OrderRejected)
+	if !add(7, "r1") {
+		t.Error("r1 still reserved after rejection")
+	}
+}
+
+func TestClientOrderIDsDropIdleUsers(t *testing.T) {
+	store := newOrderStore(1)
+	sh := store.shard("m1")
+	for i := uint64(1); i <= 2; i++ {
+		order := engine.Order{ID: i, MarketID: "m1", UserID: fmt.Sprintf("u%d", i), ClientOrderID: "c"}
+		store.addLocked(sh, &OrderRecord{Order: order})
+		store.removeLocked(sh, order, engine.OrderRejected)
+	}
+	if n := len(store.clientIDs); n != 0 {
+		t.Errorf("%d users left in the index, want 0", n)
+	}
+}
@@ -0,0 +1,237 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"time"
+
+	"cs2-prediction-engine/internal/engine"
+)
+
+type CancelOrderPayload struct {
+	OrderID uint64 `json:"order_id"`
+	UserID  string `json:"user_id"`
+}
+
+// cancelOrder pulls a resting order from its book and releases its reserve.
+// It runs on the market's sequencer and returns a rejection reason, or "" on
+// success.
+func cancelOrder(book *engine.OrderBook, userID string, orderID uint64, reason string) string {
+	record, ok := liveOrders.get(orderID)
+	if !ok || record.Order.UserID != userID {
+		return "unknown_order"
+	}
+
+	if _, ok := book.CancelOrder(orderID); !ok {
+		// Already filled, or released by the FairnessBuffer but not yet applied.
+		return "order_not_resting"
+	}
+	closeOrderRecord(orderID, engine.OrderCancelled, reason)
+	return ""
+}
+
+func (r *OrderRecord) summary(status engine.OrderStatus, reason string) engine.OrderSummary {
+	s := engine.OrderSummary{
+		OrderID:           r.Order.ID,
+		ClientOrderID:     r.Order.ClientOrderID,
+		MarketID:          r.Order.MarketID,
+		UserID:            r.Order.UserID,
+		Side:              r.Order.Side,
+		Outcome:           r.Order.Outcome,
+		Price:             r.Order.Price,
+		Quantity:          r.Order.Quantity,
+		FilledQuantity:    r.FilledQuantity,
+		ReservedRemaining: r.ReservedRemaining,
+		Status:            status,
+		Reason:            reason,
+		SubmittedAt:       r.Order.Timestamp,
+		UpdatedAt:         r.UpdatedAt,
+	}
+	if r.FilledQuantity > 0 {
+		s.AvgFillPrice = float64(r.FillNotional) / float64(r.FilledQuantity)
+	}
+	return s
+}
+
+func closeOrderRecord(orderID uint64, status engine.OrderStatus, reason string) {
+	liveOrders.update(orderID, func(sh *orderShard, _ *OrderRecord, out *outbox) {
+		closeOrderRecordLocked(sh, orderID, status, reason, out)
+	})
+}
+
+// closeOrderRecordLocked releases any reserve the order still holds and moves
+// it from the live set into orderHistory. Callers must hold sh.mu.
+func closeOrderRecordLocked(sh *orderShard, orderID uint64, status engine.OrderStatus, reason string, out *outbox) {
+	record, ok := sh.records[orderID]
+	if !ok {
+		return
+	}
+	if record.ReservedRemaining > 0 {
+		ledger.ReleaseReserved(record.Order.UserID, record.ReservedRemaining)
+		record.ReservedRemaining = 0
+	}
+	record.UpdatedAt = time.Now()
+	switch status {
+	case engine.OrderCancelled:
+		observeCancel(record, reason)
+		emitOrderEvent(out, record, "order_cancelled", map[string]interface{}{"reason": reason})
+	case engine.OrderRejected:
+		ordersRejected.Inc(reason)
+		emitOrderEvent(out, record, "order_rejected", map[string]interface{}{"reason": reason})
+	}
+	if status != engine.OrderCancelled {
+		marketWatch.ObserveOrderClosed(orderID)
+	}
+	orderHistory.Append(record.summary(status, reason))
+	liveOrders.removeLocked(sh, record.Order, status)
+}
+
+func sendOrderEvent(orderID uint64, eventType string, extra map[string]interface{}) {
+	liveOrders.update(orderID, func(_ *orderShard, record *OrderRecord, out *outbox) {
+		emitOrderEvent(out, record, eventType, extra)
+	})
+}
+
+// emitOrderEvent queues a lifecycle event for the connection that placed the
+// order. Every event carries the cumulative filled quantity. Callers passing a
+// live record must hold its shard lock.
+func emitOrderEvent(out *outbox, record *OrderRecord, eventType string, extra map[string]interface{}) {
+	if record.conn == nil {
+		return
+	}
+	payload := map[string]interface{}{
+		"order_id":        record.Order.ID,
+		"client_order_id": record.Order.ClientOrderID,
+		"market_id":       record.Order.MarketID,
+		"side":            record.Order.Side,
+		"outcome":         record.Order.Outcome,
+		"price":           record.Order.Price,
+		"quantity":        record.Order.Quantity,
+		"filled_quantity": record.FilledQuantity,
+		"correlation_id":  record.Order.CorrelationID,
+	}
+	if record.Order.ClientCorrelationID != "" {
+		payload["client_correlation_id"] = record.Order.ClientCorrelationID
+	}
+	for k, v := range extra {
+		payload[k] = v
+	}
+	msg, _ := json.Marshal(map[string]interface{}{
+		"type":    eventType,
+		"payload": payload,
+	})
+	*out = append(*out, directMessage{conn: record.conn, message: msg})
+}
+
+func recordRejectedOrder(order engine.Order, reason string) {
+	ordersRejected.Inc(reason)
+	orderLogger(order).Info("order rejected", "user_id", order.UserID, "reason", reason)
+	endOrderTrace(order, "rejected", reason, time.Now())
+	record := OrderRecord{Order: order, UpdatedAt: order.Timestamp}
+	orderHistory.Append(record.summary(engine.OrderRejected, reason))
+}
+
+func listUserOrders(userID string, status engine.OrderStatus) []engine.OrderSummary {
+	out := make([]engine.OrderSummary, 0)
+
+	liveOrders.each(func(record *OrderRecord) {
+		if record.Order.UserID == userID {
+			out = append(out, record.summary(engine.OrderOpen, ""))
+		}
+	})
+
+	out = append(out, orderHistory.ListByUser(userID)...)
+
+	if status != "" {
+		filtered := out[:0]
+		for _, s := range out {
+			if s.Status == status {
+				filtered = append(filtered, s)
+			}
+		}
+		out = filtered
+	}
+
+	sort.Slice(out, func(i, j int) bool { return out[i].OrderID > out[j].OrderID })
+	return out
+}
+
+func handleUserOrders(w http.ResponseWriter, r *http.Request, userID string) {
+	var status engine.OrderStatus
+	if raw := r.URL.Query().Get("status"); raw != "" {
+		parsed, ok := engine.ParseOrderStatus(raw)
+		if !ok {
+			http.Error(w, "invalid status (open, filled, cancelled, rejected)", http.StatusBadRequest)
+			return
+		}
+		status = parsed
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]interface{}{
+		"orders": listUserOrders(userID, status),
+	}); err != nil {
+		http.Error(w, "failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// applySelfTradePreventions reports each prevented self-trade on both orders'
+// event streams and releases the reserve for whatever quantity it removed.
+// It runs after the command's matches are booked.
+func applySelfTradePreventions(marketID string, prevented []engine.SelfTradePrevention) {
+	if len(prevented) == 0 {
+		return
+	}
+	liveOrders.withMarket(marketID, func(sh *orderShard, out *outbox) {
+		for _, p := range prevented {
+			event := map[string]interface{}{
+				"mode":           p.Mode,
+				"maker_order_id": p.MakerOrderID,
+				"taker_order_id": p.TakerOrderID,
+				"quantity":       p.Quantity,
+				"complementary":  p.Complementary,
+			}
+			reduceForSelfTrade(sh, p.MakerOrderID, p.MakerReduced, p.MakerCancelled, event, out)
+			reduceForSelfTrade(sh, p.TakerOrderID, p.TakerReduced, p.TakerCancelled, event, out)
+		}
+	})
+}
+
+// reduceForSelfTrade shrinks one order's record after a prevented self-trade.
+// Callers must hold sh.mu.
+func reduceForSelfTrade(sh *orderShard, orderID uint64, reduced int64, cancelled bool, event map[string]interface{}, out *outbox) {
+	record, ok := sh.records[orderID]
+	if !ok {
+		return
+	}
+	emitOrderEvent(out, record, "self_trade_prevented", event)
+	if cancelled {
+		closeOrderRecordLocked(sh, orderID, engine.OrderCancelled, "self_trade_prevented", out)
+		return
+	}
+	if reduced <= 0 {
+		return
+	}
+	release := requiredReserveForOrder(engine.Order{Side: record.Order.Side, Price: record.Order.Price, Quantity: reduced})
+	if release > record.ReservedRemaining {
+		release = record.ReservedRemaining
+	}
+	ledger.ReleaseReserved(record.Order.UserID, release)
+	record.ReservedRemaining -= release
+	record.Order.Quantity -= reduced
+	record.UpdatedAt = time.Now()
+
+	// Decrement can leave nothing to trade: the order's fills, accounted
+	// before the prevention, now cover its reduced quantity.
+	if record.Order.Quantity > record.FilledQuantity {
+		return
+	}
+	if record.FilledQuantity == 0 {
+		closeOrderRecordLocked(sh, orderID, engine.OrderCancelled, "self_trade_prevented", out)
+		return
+	}
+	emitOrderEvent(out, record, "order_filled", nil)
+	closeOrderRecordLocked(sh, orderID, engine.OrderFilled, "", out)
+}
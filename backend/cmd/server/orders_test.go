@@ -0,0 +1,107 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"cs2-prediction-engine/internal/audit"
+	"cs2-prediction-engine/internal/engine"
+	"cs2-prediction-engine/internal/surveillance"
+	"cs2-prediction-engine/internal/telemetry"
+)
+
+// setupOrderState installs the globals the order lifecycle touches.
+func setupOrderState(t *testing.T) {
+	t.Helper()
+	ledger = engine.NewLedger()
+	liveOrders = newOrderStore(100)
+	orderHistory = engine.NewMemoryOrderHistory(100)
+	auditLog = audit.NewVeritasChain()
+	marketWatch = surveillance.NewMonitor(surveillance.DefaultConfig)
+}
+
+// placeOrder reserves for and records an order, then runs it through the book
+// the way executeOrder does.
+func placeOrder(t *testing.T, book *engine.OrderBook, order engine.Order) {
+	t.Helper()
+	order.MarketID = "m1"
+	order.Timestamp = time.Now()
+	reserve := requiredReserveForOrder(order)
+	ledger.EnsureUser(order.UserID, 100000)
+	if !ledger.Reserve(order.UserID, reserve) {
+		t.Fatalf("order %d: reserve %d failed", order.ID, reserve)
+	}
+	if !storeOrderRecord(order, reserve, nil) {
+		t.Fatalf("order %d: not stored", order.ID)
+	}
+	matches, prevented, err := book.ProcessOrder(&order)
+	if err != nil {
+		t.Fatalf("order %d: %v", order.ID, err)
+	}
+	for _, m := range matches {
+		applyMatchAccounting(order.MarketID, m)
+	}
+	applySelfTradePreventions(order.MarketID, prevented)
+}
+
+func TestDecrementClosesOrderFilledAfterReduction(t *testing.T) {
+	setupOrderState(t)
+	book := engine.NewOrderBook()
+
+	// alice's own ask sits ahead of bob's, so her buy first decrements
+	// against it and then fills the rest against bob.
+	placeOrder(t, book, engine.Order{ID: 1, UserID: "alice", Side: engine.Sell, Outcome: engine.Yes, Price: 50, Quantity: 3})
+	placeOrder(t, book, engine.Order{ID: 2, UserID: "bob", Side: engine.Sell, Outcome: engine.Yes, Price: 55, Quantity: 10})
+	placeOrder(t, book, engine.Order{
+		ID: 3, UserID: "alice", Side: engine.Buy, Outcome: engine.Yes, Price: 60, Quantity: 10,
+		SelfTradePrevention: engine.STPDecrement,
+	})
+
+	for _, id := range []uint64{1, 3} {
+		if record, open := liveOrders.get(id); open {
+			t.Errorf("order %d still open: quantity %d filled %d reserved %d",
+				id, record.Order.Quantity, record.FilledQuantity, record.ReservedRemaining)
+		}
+	}
+	history := orderHistory.ListByUser("alice")
+	statuses := map[uint64]engine.OrderStatus{}
+	for _, summary := range history {
+		statuses[summary.OrderID] = summary.Status
+	}
+	if statuses[1] != engine.OrderCancelled || statuses[3] != engine.OrderFilled {
+		t.Errorf("history statuses = %v, want order 1 cancelled and order 3 filled", statuses)
+	}
+
+	account, _ := ledger.GetAccount("alice")
+	if account.Reserved != 0 {
+		t.Errorf("alice reserved = %d, want 0", account.Reserved)
+	}
+	if want := int64(55 * 7); account.Spent != want {
+		t.Errorf("alice spent = %d, want %d", account.Spent, want)
+	}
+}
+
+// A caller's correlation_id is echoed back but never becomes the order's
+// trace ID, even when it is well formed.
+func TestOrderCorrelationIDIsServerAssigned(t *testing.T) {
+	url := startTestEngine(t)
+	createMarket(AdapterMarketCreatedPayload{MarketID: "m1"})
+	conn := dialTestEngine(t, url)
+
+	const clientID = "0123456789abcdef0123456789abcdef"
+	sendJSON(t, conn, map[string]interface{}{
+		"type": "place_order",
+		"payload": map[string]interface{}{
+			"market_id": "m1", "user_id": "alice", "side": "BUY", "outcome": "YES", "price": 40, "quantity": 5,
+			"correlation_id": clientID,
+		},
+	})
+	accepted := readUntil(t, conn, "order_accepted")
+	id, _ := accepted["correlation_id"].(string)
+	if id == clientID || !telemetry.ValidCorrelationID(id) {
+		t.Errorf("correlation_id = %q, want a server-assigned ID", id)
+	}
+	if accepted["client_correlation_id"] != clientID {
+		t.Errorf("client_correlation_id = %v, want %s", accepted["client_correlation_id"], clientID)
+	}
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"cs2-prediction-engine/internal/engine"
+)
+
+type PortfolioSubscribePayload struct {
+	UserID string `json:"user_id"`
+	Mark   string `json:"mark"`
+}
+
+func buildPortfolio(userID string, source engine.MarkSource) (engine.Portfolio, bool) {
+	account, ok := ledger.GetAccount(userID)
+	if !ok {
+		return engine.Portfolio{}, false
+	}
+	positions := ledger.GetPositions(userID)
+	return engine.BuildPortfolio(account, positions, source, func(marketID string) (int64, engine.MarkSource) {
+		return markPriceForMarket(marketID, source)
+	}), true
+}
+
+func markPriceForMarket(marketID string, source engine.MarkSource) (int64, engine.MarkSource) {
+	quote := marketManager.GetOrderBook(marketID).Quote()
+	var gs *engine.MarketGameState
+	if meta, ok := marketRegistry.GetMarket(marketID); ok {
+		gs = meta.GameState
+	}
+	return engine.ResolveMark(quote, gs, source)
+}
+
+func handleUserPortfolio(w http.ResponseWriter, r *http.Request, userID string) {
+	source, ok := engine.ParseMarkSource(r.URL.Query().Get("mark"))
+	if !ok {
+		http.Error(w, "invalid mark source (mid, last, model)", http.StatusBadRequest)
+		return
+	}
+
+	portfolio, ok := buildPortfolio(userID, source)
+	if !ok {
+		http.Error(w, "user not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]interface{}{
+		"portfolio": portfolio,
+	}); err != nil {
+		http.Error(w, "failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+func portfolioUpdateMessage(userID string, source engine.MarkSource) ([]byte, bool) {
+	portfolio, ok := buildPortfolio(userID, source)
+	if !ok {
+		return nil, false
+	}
+	msg, _ := json.Marshal(map[string]interface{}{
+		"type":    "portfolio_update",
+		"payload": portfolio,
+	})
+	return msg, true
+}
+
+// publishPortfolioUpdates pushes fresh valuations to subscribers holding a
+// position in marketID after its prices or game state move.
+func publishPortfolioUpdates(marketID string) {
+	for _, sub := range hub.PortfolioSubscriptions() {
+		if !holdsPosition(sub.userID, marketID) {
+			continue
+		}
+		if update, ok := portfolioUpdateMessage(sub.userID, sub.mark); ok {
+			hub.Send(directMessage{conn: sub.conn, message: update})
+		}
+	}
+}
+
+func holdsPosition(userID string, marketID string) bool {
+	for _, p := range ledger.GetPositions(userID) {
+		if p.MarketID == marketID {
+			return true
+		}
+	}
+	return false
+}
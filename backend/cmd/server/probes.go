@@ -0,0 +1,126 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"time"
+
+	"cs2-prediction-engine/internal/engine"
+)
+
+const (
+	hubPingTimeout = time.Second
+	// bufferStallLimit is how far past its release time the oldest buffered
+	// order may be before the buffer counts as stalled.
+	bufferStallLimit = 2 * time.Second
+	// feedStaleAfter flags in-play markets whose sources have all gone quiet.
+	feedStaleAfter = 30 * time.Second
+)
+
+type probeCheck struct {
+	OK     bool        `json:"ok"`
+	Detail interface{} `json:"detail,omitempty"`
+}
+
+type feedFreshness struct {
+	MarketID   string    `json:"market_id"`
+	LeadSource string    `json:"lead_source,omitempty"`
+	LastSeen   time.Time `json:"last_seen,omitempty"`
+	AgeSec     float64   `json:"age_sec"`
+	Stale      bool      `json:"stale"`
+}
+
+func hubCheck() probeCheck {
+	if !hub.Ping(hubPingTimeout) {
+		return probeCheck{OK: false, Detail: "hub loop not responding"}
+	}
+	return probeCheck{OK: true, Detail: map[string]interface{}{"clients": hub.ClientCount()}}
+}
+
+func bufferCheck(now time.Time) probeCheck {
+	overdue := buffer.Overdue(now)
+	return probeCheck{
+		OK: overdue <= bufferStallLimit,
+		Detail: map[string]interface{}{
+			"depth":       buffer.Len(),
+			"overdue_sec": overdue.Seconds(),
+		},
+	}
+}
+
+func persistenceCheck() probeCheck {
+	status := snapshotStore.Status()
+	return probeCheck{OK: status.LastError == "", Detail: status}
+}
+
+// feedCheck lists in-play markets by feed age. Stale feeds already suspend
+// their markets, so they are reported without failing readiness.
+func feedCheck(now time.Time) probeCheck {
+	markets := make([]feedFreshness, 0)
+	for _, meta := range marketRegistry.ListMarkets() {
+		if meta.Phase != engine.PhaseInPlay || meta.Status.Terminal() {
+			continue
+		}
+		f := feedFreshness{MarketID: meta.MarketID}
+		stateMu.Lock()
+		if health, ok := marketHealthByID[meta.MarketID]; ok {
+			f.LeadSource = health.LeadSource
+			for _, at := range health.Sources {
+				if at.After(f.LastSeen) {
+					f.LastSeen = at
+				}
+			}
+		}
+		stateMu.Unlock()
+		if f.LastSeen.IsZero() {
+			f.Stale = true
+		} else {
+			f.AgeSec = now.Sub(f.LastSeen).Seconds()
+			f.Stale = now.Sub(f.LastSeen) > feedStaleAfter
+		}
+		markets = append(markets, f)
+	}
+	sort.Slice(markets, func(i, j int) bool { return markets[i].AgeSec > markets[j].AgeSec })
+	return probeCheck{OK: true, Detail: markets}
+}
+
+func writeProbe(w http.ResponseWriter, ok bool, checks map[string]probeCheck) {
+	status := "ok"
+	code := http.StatusOK
+	if !ok {
+		status = "unavailable"
+		code = http.StatusServiceUnavailable
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status": status,
+		"checks": checks,
+	})
+}
+
+// handleHealthz is the liveness probe: the process is up and its event loop
+// is turning.
+func handleHealthz(w http.ResponseWriter, r *http.Request) {
+	hubStatus := hubCheck()
+	writeProbe(w, hubStatus.OK, map[string]probeCheck{"hub": hubStatus})
+}
+
+// handleReadyz is the readiness probe: the engine can take orders now.
+func handleReadyz(w http.ResponseWriter, r *http.Request) {
+	now := time.Now()
+	checks := map[string]probeCheck{
+		"hub":         hubCheck(),
+		"buffer":      bufferCheck(now),
+		"persistence": persistenceCheck(),
+		"feeds":       feedCheck(now),
+		"draining":    {OK: !draining.Load()},
+	}
+	ready := true
+	for _, c := range checks {
+		ready = ready && c.OK
+	}
+	writeProbe(w, ready, checks)
+}
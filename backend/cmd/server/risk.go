@@ -0,0 +1,158 @@
+package main
+
+import (
+	"encoding/json"
+	"hash/fnv"
+	"net/http"
+	"strings"
+	"sync"
+
+	"cs2-prediction-engine/internal/engine"
+
+	"github.com/gorilla/websocket"
+)
+
+// admissionLocks serialise order admission per user. They are striped by
+// user ID so the set stays fixed however many users trade.
+var admissionLocks [64]sync.Mutex
+
+// admitOrder runs the risk check, reserves the order's funds and records it as
+// one step per user, so concurrent orders from the same user cannot each pass
+// against exposure the other is about to add. It returns the risk rejection
+// or the reason the order was refused, if any. Only an accepted order counts
+// towards the user's order rate.
+func admitOrder(order engine.Order, reserve int64, conn *websocket.Conn) (*engine.RiskRejection, string) {
+	h := fnv.New32a()
+	h.Write([]byte(order.UserID))
+	mu := &admissionLocks[h.Sum32()%uint32(len(admissionLocks))]
+	mu.Lock()
+	defer mu.Unlock()
+
+	if rejection := riskEngine.Check(order, reserve, riskExposure(order), order.Timestamp); rejection != nil {
+		return rejection, ""
+	}
+	if !ledger.Reserve(order.UserID, reserve) {
+		return nil, "insufficient_balance"
+	}
+	if !storeOrderRecord(order, reserve, conn) {
+		ledger.ReleaseReserved(order.UserID, reserve)
+		return nil, "duplicate_client_order_id"
+	}
+	riskEngine.RecordOrder(order.UserID, order.Timestamp)
+	return nil, ""
+}
+
+// riskExposure gathers what a user already has at stake in an order's market.
+func riskExposure(order engine.Order) engine.RiskExposure {
+	exposure := engine.RiskExposure{
+		Position:  engine.MarketPosition{MarketID: order.MarketID},
+		Reference: riskReferencePrice(order.MarketID),
+	}
+	if account, ok := ledger.GetAccount(order.UserID); ok {
+		exposure.OpenNotional = account.Reserved
+	}
+	for _, p := range ledger.GetPositions(order.UserID) {
+		if p.MarketID == order.MarketID && !p.Settled {
+			exposure.Position = p
+		}
+	}
+
+	liveOrders.withMarket(order.MarketID, func(sh *orderShard, _ *outbox) {
+		for _, record := range sh.records {
+			if record.Order.UserID != order.UserID {
+				continue
+			}
+			open := record.Order
+			open.Quantity -= record.FilledQuantity
+			if open.Quantity > 0 {
+				exposure.OpenOrders = append(exposure.OpenOrders, open)
+			}
+		}
+	})
+	return exposure
+}
+
+// riskReferencePrice anchors the fat-finger band on the last trade, or on the
+// model fair value before the market has traded.
+func riskReferencePrice(marketID string) int64 {
+	if last := marketManager.GetOrderBook(marketID).Quote().LastPrice; last > 0 {
+		return last
+	}
+	var gs *engine.MarketGameState
+	if meta, ok := marketRegistry.GetMarket(marketID); ok {
+		gs = meta.GameState
+	}
+	return engine.FairValue(gs)
+}
+
+func rejectOrderForRisk(conn *websocket.Conn, order engine.Order, rejection *engine.RiskRejection) {
+	reason := "risk_limit:" + string(rejection.Limit)
+	recordRejectedOrder(order, reason)
+	record := OrderRecord{Order: order, conn: conn}
+	var out outbox
+	emitOrderEvent(&out, &record, "order_rejected", map[string]interface{}{
+		"reason":      reason,
+		"limit":       rejection.Limit,
+		"limit_value": rejection.Max,
+		"value":       rejection.Value,
+		"detail":      rejection.Detail,
+	})
+	out.send()
+}
+
+func handleUserRisk(w http.ResponseWriter, r *http.Request, userID string) {
+	marketID := r.URL.Query().Get("market_id")
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]interface{}{
+		"user_id":   userID,
+		"tier":      riskEngine.UserTier(userID),
+		"market_id": marketID,
+		"limits":    riskEngine.Limits(userID, marketID),
+	}); err != nil {
+		http.Error(w, "failed to encode response", http.StatusInternalServerError)
+	}
+}
+
+type UserTierRequest struct {
+	Tier string `json:"tier"`
+}
+
+func handleAdminUsers(w http.ResponseWriter, r *http.Request) {
+	// Expected: /admin/users/{userID}/tier
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/admin/users/"), "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] != "tier" {
+		http.Error(w, "invalid user resource path", http.StatusBadRequest)
+		return
+	}
+	if r.Method != http.MethodPut {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var req UserTierRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	if err := riskEngine.SetUserTier(parts[0], engine.RiskTier(req.Tier)); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	auditLog.LogOperatorAction(operatorID(r), "", "set_tier", parts[0]+"="+req.Tier)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func handleAdminMarketRisk(w http.ResponseWriter, r *http.Request, marketID string) {
+	if r.Method != http.MethodPut {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var limits engine.RiskLimits
+	if err := json.NewDecoder(r.Body).Decode(&limits); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	riskEngine.SetMarketLimits(marketID, limits)
+	detail, _ := json.Marshal(limits)
+	auditLog.LogOperatorAction(operatorID(r), marketID, "set_risk_limits", string(detail))
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"cs2-prediction-engine/internal/engine"
+)
+
+func riskTestOrder(id uint64, price int64) engine.Order {
+	return engine.Order{ID: id, MarketID: "m1", UserID: "alice", Side: engine.Buy, Outcome: engine.Yes, Price: price, Quantity: 10, Timestamp: time.Now()}
+}
+
+// Concurrent orders from one user are checked against each other's
+// reservations, so together they cannot exceed a limit each passes alone.
+func TestAdmitOrderIsAtomicPerUser(t *testing.T) {
+	startTestEngine(t)
+	createMarket(AdapterMarketCreatedPayload{MarketID: "m1"})
+	riskEngine = engine.NewRiskEngine(engine.RiskConfig{
+		DefaultTier: engine.TierRetail,
+		Tiers:       map[engine.RiskTier]engine.RiskLimits{engine.TierRetail: {MaxOpenNotional: 1000}},
+	})
+	ledger.EnsureUser("alice", 100000)
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	accepted := 0
+	start := make(chan struct{})
+	for i := uint64(1); i <= 200; i++ {
+		wg.Add(1)
+		go func(id uint64) {
+			defer wg.Done()
+			<-start
+			if rejection, reason := admitOrder(riskTestOrder(id, 40), 400, nil); rejection == nil && reason == "" {
+				mu.Lock()
+				accepted++
+				mu.Unlock()
+			}
+		}(i)
+	}
+	close(start)
+	wg.Wait()
+
+	if accepted != 2 {
+		t.Errorf("accepted %d orders of 400 under a 1000 open-notional limit, want 2", accepted)
+	}
+	if account, _ := ledger.GetAccount("alice"); account.Reserved != 800 {
+		t.Errorf("reserved %d, want 800", account.Reserved)
+	}
+}
+
+func TestAdmitOrderCountsRateOnlyOnAcceptance(t *testing.T) {
+	startTestEngine(t)
+	createMarket(AdapterMarketCreatedPayload{MarketID: "m1"})
+	riskEngine = engine.NewRiskEngine(engine.RiskConfig{
+		DefaultTier: engine.TierRetail,
+		Tiers:       map[engine.RiskTier]engine.RiskLimits{engine.TierRetail: {MaxOrdersPerSecond: 1}},
+	})
+	ledger.EnsureUser("alice", 500)
+
+	if _, reason := admitOrder(riskTestOrder(1, 60), 600, nil); reason != "insufficient_balance" {
+		t.Fatalf("reason = %q, want insufficient_balance", reason)
+	}
+	if rejection, reason := admitOrder(riskTestOrder(2, 40), 400, nil); rejection != nil || reason != "" {
+		t.Errorf("order after an insufficient_balance rejection refused: %v %q", rejection, reason)
+	}
+	if rejection, _ := admitOrder(riskTestOrder(3, 10), 100, nil); rejection == nil || rejection.Limit != engine.LimitOrderRate {
+		t.Errorf("second accepted order in a second: %v, want order_rate", rejection)
+	}
+}
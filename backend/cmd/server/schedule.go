@@ -0,0 +1,125 @@
+package main
+
+import (
+	"encoding/json"
+	"log/slog"
+	"time"
+
+	"cs2-prediction-engine/internal/engine"
+)
+
+const (
+	scheduleTick   = time.Second
+	noLiveFeedRule = "no_live_feed"
+)
+
+type AdapterSeriesStatusPayload struct {
+	SeriesID string `json:"series_id"`
+	MarketID string `json:"market_id"`
+	Status   string `json:"status"` // cancelled or postponed suspends the market pending an operator void
+	Reason   string `json:"reason,omitempty"`
+}
+
+// runMarketScheduler submits lifecycle steps that have fallen due from each
+// market's StartTime to the market's sequencer.
+func runMarketScheduler(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for now := range ticker.C {
+		for _, meta := range marketRegistry.ListMarkets() {
+			action, ok := schedulePolicy.Due(meta, now)
+			if !ok {
+				continue
+			}
+			sequencers.Get(meta.MarketID).Submit(engine.Command{
+				Kind:    engine.CommandScheduled,
+				Payload: action,
+			})
+		}
+	}
+}
+
+// applyScheduledAction runs on the market's sequencer. The step is checked
+// again because the scheduler may submit it more than once before it applies.
+func applyScheduledAction(book *engine.OrderBook, marketID string, action engine.ScheduleAction) {
+	meta, ok := marketRegistry.GetMarket(marketID)
+	if !ok {
+		return
+	}
+	if due, ok := schedulePolicy.Due(meta, time.Now()); !ok || due != action {
+		return
+	}
+
+	switch action {
+	case engine.ScheduleOpenPreMatch:
+		transitionMarket(marketID, engine.StatusOpen, "pre_match_open")
+	case engine.ScheduleGoInPlay:
+		goInPlay(book, marketID, "match_started")
+	case engine.ScheduleNoLiveFeed:
+		slog.Warn("no series_state since scheduled start", "market_id", marketID, "grace", schedulePolicy.LiveGrace)
+		if schedulePolicy.GraceAction == engine.StatusClosed {
+			transitionMarket(marketID, engine.StatusClosed, noLiveFeedRule)
+		} else if !suspendedByOther(marketID) {
+			// Feed-owned, so the first live series_state lifts it.
+			suspendMarket(marketID, feedIntegrityReason+noLiveFeedRule)
+		}
+	}
+}
+
+// goInPlay switches a market to in-play rules. Resting pre-match orders keep
+// their place in the book; with schedulePolicy.CancelOnInPlay they are
+// cancelled instead, since they were priced without the live feed, and each
+// owner is sent order_cancelled.
+func goInPlay(book *engine.OrderBook, marketID string, reason string) {
+	if !marketRegistry.UpdateTradingPhase(marketID, engine.PhaseInPlay) {
+		return
+	}
+	var cancelled []engine.Order
+	if schedulePolicy.CancelOnInPlay {
+		cancelled = book.CancelWhere(func(*engine.Order) bool { return true })
+	}
+	for _, o := range cancelled {
+		closeOrderRecord(o.ID, engine.OrderCancelled, "market_in_play")
+	}
+	slog.Info("market in play", "market_id", marketID, "reason", reason, "cancelled_orders", len(cancelled))
+
+	inPlayMsg, _ := json.Marshal(map[string]interface{}{
+		"type": "market_in_play",
+		"payload": map[string]interface{}{
+			"market_id":        marketID,
+			"reason":           reason,
+			"cancelled_orders": len(cancelled),
+		},
+	})
+	hub.Broadcast(inPlayMsg)
+}
+
+// orderDelay is the fairness delay for a new order: feed-derived in play,
+// fixed before the match when there is no feed to race.
+func orderDelay(marketID string) time.Duration {
+	if meta, ok := marketRegistry.GetMarket(marketID); ok && meta.Phase == engine.PhasePreMatch {
+		return settings.Load().PreMatchDelay(marketID)
+	}
+	return fairnessDelay.Delay(marketID)
+}
+
+// applySeriesStatus suspends markets whose series will not be played as
+// listed. Voiding refunds every holder and cannot be undone, so a feed message
+// alone never does it: the market stays suspended until an operator voids it
+// through POST /admin/markets/{id}/void, or resumes it.
+func applySeriesStatus(payload AdapterSeriesStatusPayload, raw []byte) {
+	if payload.Status != "cancelled" && payload.Status != "postponed" {
+		return
+	}
+	meta, ok := marketRegistry.GetMarket(payload.MarketID)
+	if !ok || meta.Status.Terminal() {
+		return
+	}
+	reason := "series_" + payload.Status
+	if payload.Reason != "" {
+		reason += ":" + payload.Reason
+	}
+	suspendMarket(payload.MarketID, reason)
+	slog.Warn("series not played as listed; market awaits operator void", "market_id", payload.MarketID, "reason", reason)
+	hub.Broadcast(raw)
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"cs2-prediction-engine/internal/engine"
+)
+
+func TestWebSocketSeriesStatusCannotVoid(t *testing.T) {
+	url := startTestEngine(t)
+	createMarket(AdapterMarketCreatedPayload{MarketID: "series_s1_winner", SeriesID: "s1", Title: "A vs B"})
+
+	conn := dialTestFeed(t, url)
+	sendJSON(t, conn, map[string]interface{}{
+		"type": "series_status",
+		"payload": map[string]interface{}{
+			"market_id": "series_s1_winner",
+			"status":    "cancelled",
+		},
+	})
+
+	waitFor(t, "market suspended", func() bool {
+		return marketStatus("series_s1_winner") == engine.StatusSuspended
+	})
+	if got := marketStatus("series_s1_winner"); got == engine.StatusVoided {
+		t.Fatalf("market voided by an unauthenticated series_status")
+	}
+	if reason := suspendedByReason("series_s1_winner"); reason != "series_cancelled" {
+		t.Errorf("suspended by %q, want series_cancelled", reason)
+	}
+
+	// An operator can still void it.
+	applyOperatorAction("series_s1_winner", operatorAction{OperatorID: "op1", Action: "void"})
+	if got := marketStatus("series_s1_winner"); got != engine.StatusVoided {
+		t.Errorf("status after operator void = %s, want voided", got)
+	}
+}
+
+func TestGoInPlayRestingOrders(t *testing.T) {
+	for _, cancel := range []bool{false, true} {
+		startTestEngine(t)
+		prev := schedulePolicy
+		schedulePolicy.CancelOnInPlay = cancel
+		t.Cleanup(func() { schedulePolicy = prev })
+
+		start := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+		createMarket(AdapterMarketCreatedPayload{MarketID: "m1", StartTime: start})
+		book := engine.NewOrderBook()
+		placeOrder(t, book, engine.Order{ID: 1, UserID: "alice", Side: engine.Buy, Outcome: engine.Yes, Price: 40, Quantity: 5})
+
+		goInPlay(book, "m1", "match_started")
+
+		if meta, _ := marketRegistry.GetMarket("m1"); meta.Phase != engine.PhaseInPlay {
+			t.Fatalf("CancelOnInPlay=%t: phase = %s, want in_play", cancel, meta.Phase)
+		}
+		_, open := liveOrders.get(1)
+		if open == cancel {
+			t.Errorf("CancelOnInPlay=%t: order open = %t", cancel, open)
+		}
+		if !cancel {
+			continue
+		}
+		history := orderHistory.ListByUser("alice")
+		if len(history) != 1 || history[0].Status != engine.OrderCancelled || history[0].Reason != "market_in_play" {
+			t.Errorf("history = %+v, want order 1 cancelled with market_in_play", history)
+		}
+		if account, _ := ledger.GetAccount("alice"); account.Reserved != 0 {
+			t.Errorf("alice reserved = %d after cancellation, want 0", account.Reserved)
+		}
+	}
+}
@@ -0,0 +1,331 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"cs2-prediction-engine/internal/engine"
+	"cs2-prediction-engine/internal/feedhealth"
+
+	"github.com/gorilla/websocket"
+)
+
+// feedEvent is an adapter message routed through a market's sequencer so it
+// is ordered against that market's orders and cancels.
+type feedEvent struct {
+	raw            []byte
+	seriesState    *AdapterSeriesStatePayload
+	circuitBreaker *AdapterCircuitBreakerPayload
+	seriesStatus   *AdapterSeriesStatusPayload
+	health         []feedhealth.Transition
+	receivedAt     time.Time
+	correlationID  string
+}
+
+type cancelRequest struct {
+	conn   *websocket.Conn
+	userID string
+}
+
+// refusedReason explains a command the sequencer would not take: its queue
+// was full, or the engine is shutting down and no longer runs commands.
+func refusedReason() string {
+	if draining.Load() {
+		return "server_shutting_down"
+	}
+	return "market_busy"
+}
+
+// marketSequencer returns the sequencer of a listed market. Sequencers hold a
+// goroutine and a command ring, so none is started for an unknown market ID.
+func marketSequencer(marketID string) (*engine.Sequencer, bool) {
+	if _, ok := marketRegistry.GetMarket(marketID); !ok {
+		return nil, false
+	}
+	return sequencers.Get(marketID), true
+}
+
+func submitOrder(order *engine.Order) {
+	s, ok := marketSequencer(order.MarketID)
+	if !ok {
+		closeOrderRecord(order.ID, engine.OrderRejected, "unknown_market")
+		endOrderTrace(*order, "rejected", "unknown_market", time.Now())
+		return
+	}
+	if !s.Submit(engine.Command{
+		Kind:    engine.CommandPlaceOrder,
+		Order:   order,
+		OrderID: order.ID,
+	}) {
+		reason := refusedReason()
+		closeOrderRecord(order.ID, engine.OrderRejected, reason)
+		endOrderTrace(*order, "rejected", reason, time.Now())
+	}
+}
+
+func submitCancel(conn *websocket.Conn, payload CancelOrderPayload) {
+	record, ok := liveOrders.get(payload.OrderID)
+	marketID := ""
+	if ok && record.Order.UserID == payload.UserID {
+		marketID = record.Order.MarketID
+	}
+
+	if marketID == "" {
+		sendCancelRejected(conn, payload.OrderID, "unknown_order")
+		return
+	}
+	// Orders still waiting out the fairness delay never reached the book.
+	if order, ok := buffer.Cancel(payload.OrderID); ok {
+		closeOrderRecord(payload.OrderID, engine.OrderCancelled, "user_cancelled")
+		endOrderTrace(*order, "cancelled", "user_cancelled", time.Now())
+		return
+	}
+	if !sequencers.Get(marketID).Submit(engine.Command{
+		Kind:    engine.CommandCancelOrder,
+		OrderID: payload.OrderID,
+		Payload: cancelRequest{conn: conn, userID: payload.UserID},
+	}) {
+		sendCancelRejected(conn, payload.OrderID, refusedReason())
+	}
+}
+
+func submitFeedEvent(marketID string, event feedEvent) {
+	s, ok := marketSequencer(marketID)
+	if !ok {
+		slog.Warn("dropping feed event for unknown market", "market_id", marketID, "correlation_id", event.correlationID)
+		return
+	}
+	if !s.Submit(engine.Command{
+		Kind:    engine.CommandFeedEvent,
+		Payload: event,
+	}) {
+		slog.Warn("sequencer refused feed event", "market_id", marketID, "reason", refusedReason(), "correlation_id", event.correlationID)
+	}
+}
+
+func sendCancelRejected(conn *websocket.Conn, orderID uint64, reason string) {
+	rejectMsg, _ := json.Marshal(map[string]interface{}{
+		"type": "cancel_rejected",
+		"payload": map[string]interface{}{
+			"order_id": orderID,
+			"reason":   reason,
+		},
+	})
+	hub.Send(directMessage{conn: conn, message: rejectMsg})
+}
+
+// handleSequencedCommand is the single entry point for every state change to
+// a market's book. It runs on that market's sequencer goroutine.
+func handleSequencedCommand(book *engine.OrderBook, cmd engine.Command) {
+	switch cmd.Kind {
+	case engine.CommandPlaceOrder:
+		executeOrder(book, cmd.Order, cmd.Sequence)
+	case engine.CommandCancelOrder:
+		req := cmd.Payload.(cancelRequest)
+		if reason := cancelOrder(book, req.userID, cmd.OrderID, "user_cancelled"); reason != "" {
+			sendCancelRejected(req.conn, cmd.OrderID, reason)
+		}
+	case engine.CommandRunAuction:
+		runAuction(book, cmd.MarketID, cmd.Sequence)
+	case engine.CommandSetMatchingMode:
+		applyMatchingMode(book, cmd)
+	case engine.CommandScheduled:
+		applyScheduledAction(book, cmd.MarketID, cmd.Payload.(engine.ScheduleAction))
+	case engine.CommandOperatorAction:
+		applyOperatorAction(cmd.MarketID, cmd.Payload.(operatorAction))
+	case engine.CommandFeedEvent:
+		event := cmd.Payload.(feedEvent)
+		switch {
+		case event.seriesState != nil:
+			applySeriesState(book, *event.seriesState, event.raw, event.receivedAt, event.correlationID)
+		case event.circuitBreaker != nil:
+			applyCircuitBreaker(*event.circuitBreaker, event.raw)
+		case event.seriesStatus != nil:
+			applySeriesStatus(*event.seriesStatus, event.raw)
+		case event.health != nil:
+			applyFeedHealth(cmd.MarketID, event.health)
+		}
+	}
+}
+
+func executeOrder(book *engine.OrderBook, order *engine.Order, sequence uint64) {
+	if reason, cleared := clearedBeforeArrival(order); cleared {
+		closeOrderRecord(order.ID, engine.OrderCancelled, reason)
+		endOrderTrace(*order, "cancelled", reason, time.Now())
+		return
+	}
+	sendOrderEvent(order.ID, "order_released", nil)
+	start := time.Now()
+	matches, prevented, err := book.ProcessOrder(order)
+	processed := time.Now()
+	traceOrderStage(*order, "orderbook.process", start, processed, map[string]string{
+		"sequence": fmt.Sprint(sequence),
+		"matches":  fmt.Sprint(len(matches)),
+	})
+	if err != nil {
+		closeOrderRecord(order.ID, engine.OrderRejected, "trading_suspended")
+		endOrderTrace(*order, "rejected", "trading_suspended", processed)
+		return
+	}
+	endOrderTrace(*order, "processed", "", processed)
+
+	for _, m := range matches {
+		orderToMatchLatency.Observe(m.Timestamp.Sub(order.Timestamp).Seconds(), order.MarketID)
+	}
+	emitMatches(order.MarketID, matches, sequence)
+	applySelfTradePreventions(order.MarketID, prevented)
+	if order.Quantity > 0 {
+		sendOrderEvent(order.ID, "order_rested", map[string]interface{}{
+			"resting_quantity": order.Quantity,
+		})
+	}
+	publishPortfolioUpdates(order.MarketID)
+}
+
+// emitMatches books, records and broadcasts the matches produced by one
+// sequenced command.
+func emitMatches(marketID string, matches []engine.Match, sequence uint64) {
+	if len(matches) == 0 {
+		return
+	}
+	round := 0
+	if meta, ok := marketRegistry.GetMarket(marketID); ok && meta.GameState != nil {
+		round = meta.GameState.Round
+	}
+	for _, m := range matches {
+		m.Sequence = sequence
+		hash, _ := auditLog.LogMatch(m)
+		observeMatch(marketID, m, hash)
+		observeMatchMetrics(marketID, m)
+		applyMatchAccounting(marketID, m)
+		tradeStore.Record(marketID, m, round)
+
+		matchMsg, _ := json.Marshal(map[string]interface{}{
+			"type":    "match_occurred",
+			"payload": m,
+		})
+		hub.Broadcast(matchMsg)
+	}
+}
+
+func applySeriesState(book *engine.OrderBook, payload AdapterSeriesStatePayload, raw []byte, receivedAt time.Time, correlationID string) {
+	marketID := "series_" + payload.SeriesID + "_winner"
+
+	var previous *engine.MarketGameState
+	preMatch := false
+	if meta, ok := marketRegistry.GetMarket(marketID); ok {
+		if meta.Status.Terminal() {
+			return
+		}
+		previous = meta.GameState
+		preMatch = meta.Phase == engine.PhasePreMatch
+	}
+	source := payload.Source
+	if source == "" {
+		source = feedReconciler.DefaultSource()
+	}
+	gameState := engine.MarketGameState{
+		Map:            payload.GameState.Map,
+		Round:          payload.GameState.Round,
+		TerroristScore: payload.GameState.TerroristScore,
+		CTScore:        payload.GameState.CTScore,
+		BombPlanted:    payload.GameState.BombPlanted,
+		Phase:          payload.GameState.Phase,
+		LastAction:     payload.GameState.LastAction,
+		Timestamp:      payload.Timestamp,
+	}
+	verdict, feedHash, err := reconcileFeedSource(marketID, source, gameState, receivedAt)
+	if err != nil {
+		slog.Warn("dropping series_state", "market_id", marketID, "source", source, "correlation_id", correlationID, "error", err)
+		return
+	}
+
+	// Only the leading source drives the book; the others are cross-checks
+	// and settlement votes.
+	if verdict.Lead {
+		if preMatch {
+			// The match started ahead of schedule.
+			goInPlay(book, marketID, "live_feed")
+		}
+		fired := engine.DetectGameEvents(previous, gameState)
+		observeFeedTiming(marketID, previous, gameState, fired, receivedAt)
+
+		marketRegistry.UpdateMarketGameState(marketID, gameState)
+		observeGameState(marketID, previous, gameState, source, feedHash, receivedAt)
+		tradeStore.RecordRound(marketID, gameState, time.Now())
+		clearBookOnGameEvents(book, marketID, fired, receivedAt)
+
+		observeFeedHealth(marketID, gameState, receivedAt)
+	}
+	// Settle only on a quorum, and never on a feed the integrity rules reject.
+	if verdict.Settle && len(feedMonitor.Blocking(marketID)) == 0 {
+		if beginResolving(marketID, "series_ended") && transitionMarket(marketID, engine.StatusSettled, "feed_quorum") {
+			auditLog.LogSettlement(marketID, settlementWinner(payload), verdict.SettleSources)
+			finalScore := fmt.Sprintf("%d-%d", payload.GameState.TerroristScore, payload.GameState.CTScore)
+			settleMarket(marketID, settlementWinner(payload), payload.Timestamp, finalScore)
+			feedMonitor.Forget(marketID)
+			feedReconciler.Forget(marketID)
+		}
+	} else if verdict.Lead && payload.GameState.Phase == "ended" {
+		// Trading stops at the end of the series; settlement waits for quorum.
+		beginResolving(marketID, "awaiting_quorum")
+	}
+	if !verdict.Lead {
+		return
+	}
+
+	gameEventMsg, _ := json.Marshal(map[string]interface{}{
+		"type": "game_event",
+		"payload": map[string]interface{}{
+			"series_id": payload.SeriesID,
+			"game_state": map[string]interface{}{
+				"round":           payload.GameState.Round,
+				"terrorist_score": payload.GameState.TerroristScore,
+				"ct_score":        payload.GameState.CTScore,
+				"bomb_planted":    payload.GameState.BombPlanted,
+			},
+			"last_action": payload.GameState.LastAction,
+		},
+	})
+	hub.Broadcast(gameEventMsg)
+	hub.Broadcast(raw)
+	publishPortfolioUpdates(marketID)
+}
+
+// applyCircuitBreaker handles the feed's own suspend and resume. A feed resume
+// never lifts a feed-integrity or operator suspension: those wait for the
+// monitor to recover or for an operator resume through the admin API, which
+// is also the only path that clears rules needing manual recovery.
+func applyCircuitBreaker(payload AdapterCircuitBreakerPayload, raw []byte) {
+	if payload.Action == "suspend" {
+		suspendMarket(payload.MarketID, payload.Reason)
+	} else if payload.Action == "resume" {
+		held := suspendedByReason(payload.MarketID)
+		if strings.HasPrefix(held, feedIntegrityReason) || strings.HasPrefix(held, "operator_") {
+			slog.Warn("ignoring circuit_breaker resume", "market_id", payload.MarketID, "suspended_by", held)
+			return
+		}
+		resumeMarket(payload.MarketID, payload.Reason)
+	}
+	hub.Broadcast(raw)
+}
+
+func handleMarketSequencer(w http.ResponseWriter, r *http.Request, marketID string) {
+	s, ok := sequencers.Lookup(marketID)
+	if !ok {
+		http.Error(w, "market has no sequencer", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]interface{}{
+		"sequencer": s.Stats(),
+	}); err != nil {
+		http.Error(w, "failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+// Orders and feed events naming a market that was never listed are refused
+// without starting a sequencer for it.
+func TestUnknownMarketsGetNoSequencer(t *testing.T) {
+	url := startTestEngine(t)
+	conn := dialTestFeed(t, url)
+
+	sendJSON(t, conn, map[string]interface{}{
+		"type":    "series_state",
+		"payload": map[string]interface{}{"series_id": "made_up", "source": "grid_adapter"},
+	})
+	sendJSON(t, conn, map[string]interface{}{
+		"type":    "circuit_breaker",
+		"payload": map[string]interface{}{"market_id": "ghost_cb", "action": "suspend", "reason": "x"},
+	})
+	sendJSON(t, conn, map[string]interface{}{
+		"type":    "series_status",
+		"payload": map[string]interface{}{"market_id": "ghost_status", "status": "cancelled"},
+	})
+	sendJSON(t, conn, map[string]interface{}{
+		"type": "place_order",
+		"payload": map[string]interface{}{
+			"market_id": "ghost_order", "user_id": "alice", "side": "BUY", "outcome": "YES", "price": 40, "quantity": 5,
+		},
+	})
+
+	// Messages on one connection are handled in order, so by the time the
+	// order is rejected the feed events have been handled too.
+	if rejected := readUntil(t, conn, "order_rejected"); rejected["reason"] != "unknown_market" {
+		t.Errorf("rejected with %v, want unknown_market", rejected["reason"])
+	}
+	for _, id := range []string{"series_made_up_winner", "ghost_cb", "ghost_status", "ghost_order"} {
+		if _, ok := sequencers.Lookup(id); ok {
+			t.Errorf("sequencer started for unknown market %s", id)
+		}
+	}
+}
+
+// Sequencers publish through the hub, so a hub that has stopped draining must
+// cost them dropped messages rather than a stall.
+func TestHubSendNeverBlocks(t *testing.T) {
+	h := NewHub() // Run is never started
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i <= hubQueueSize; i++ {
+			h.Broadcast([]byte("{}"))
+			h.Send(directMessage{message: []byte("{}")})
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("hub sends blocked with Run stalled")
+	}
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"cs2-prediction-engine/internal/config"
+	"cs2-prediction-engine/internal/engine"
+	"cs2-prediction-engine/internal/feedhealth"
+	"cs2-prediction-engine/internal/gateway"
+	"cs2-prediction-engine/internal/telemetry"
+)
+
+// startTestEngine initialises the engine from the default config and serves
+// /ws from an httptest server. It returns the server's WebSocket URL.
+func startTestEngine(t *testing.T) string {
+	t.Helper()
+	cfg := config.Default()
+	cfg.Snapshot.Path = t.TempDir() + "/snapshot.json"
+	tracer = telemetry.NewTracer(telemetry.DefaultTracerConfig, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	initEngine(cfg)
+	feedAuth = gateway.NewFeedAuth(map[string]string{testFeedToken: feedhealth.SourceGRID})
+	go hub.Run()
+
+	// Wait for connection handlers to return so they cannot touch the next
+	// test's globals; test connections close before this cleanup runs.
+	var handlers sync.WaitGroup
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handlers.Add(1)
+		defer handlers.Done()
+		handleWebSocket(w, r)
+	}))
+	t.Cleanup(func() {
+		srv.Close()
+		handlers.Wait()
+	})
+	return "ws" + strings.TrimPrefix(srv.URL, "http")
+}
+
+// testFeedToken authenticates a test connection as the GRID adapter.
+const testFeedToken = "feed-test-token"
+
+func dialTestEngine(t *testing.T, url string) *websocket.Conn {
+	t.Helper()
+	return dialWithHeader(t, url, nil)
+}
+
+// dialTestFeed connects as a feed adapter.
+func dialTestFeed(t *testing.T, url string) *websocket.Conn {
+	t.Helper()
+	return dialWithHeader(t, url, http.Header{"Authorization": {"Bearer " + testFeedToken}})
+}
+
+func dialWithHeader(t *testing.T, url string, header http.Header) *websocket.Conn {
+	t.Helper()
+	conn, _, err := websocket.DefaultDialer.Dial(url, header)
+	if err != nil {
+		t.Fatalf("dial %s: %v", url, err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func sendJSON(t *testing.T, conn *websocket.Conn, msg interface{}) {
+	t.Helper()
+	if err := conn.WriteJSON(msg); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+}
+
+// waitFor polls cond until it holds or the deadline passes.
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func marketStatus(marketID string) engine.MarketStatus {
+	meta, _ := marketRegistry.GetMarket(marketID)
+	return meta.Status
+}
+
+// readUntil reads messages from conn until one of type msgType arrives and
+// returns its payload.
+func readUntil(t *testing.T, conn *websocket.Conn, msgType string) map[string]interface{} {
+	t.Helper()
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	defer conn.SetReadDeadline(time.Time{})
+	for {
+		var msg struct {
+			Type    string                 `json:"type"`
+			Payload map[string]interface{} `json:"payload"`
+		}
+		if err := conn.ReadJSON(&msg); err != nil {
+			t.Fatalf("waiting for %s: %v", msgType, err)
+		}
+		if msg.Type == msgType {
+			return msg.Payload
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"cs2-prediction-engine/internal/config"
+	"cs2-prediction-engine/internal/engine"
+
+	"github.com/gorilla/websocket"
+)
+
+func buildSnapshot(now time.Time) engine.Snapshot {
+	snap := engine.Snapshot{
+		TakenAt:     now,
+		Markets:     marketRegistry.ListMarkets(),
+		Ledger:      ledger.Snapshot(),
+		OpenOrders:  make([]engine.Order, 0),
+		AuditRoot:   auditLog.GetMerkleRoot(),
+		AuditEvents: auditLog.Len(),
+	}
+	liveOrders.each(func(record *OrderRecord) {
+		open := record.Order
+		open.Quantity -= record.FilledQuantity
+		snap.OpenOrders = append(snap.OpenOrders, open)
+	})
+	return snap
+}
+
+func saveSnapshot() error {
+	err := snapshotStore.Save(buildSnapshot(time.Now()))
+	if err != nil {
+		slog.Error("snapshot failed", "path", snapshotStore.Status().Path, "error", err)
+	}
+	return err
+}
+
+// runSnapshots persists state on startup and then every interval, so the
+// readiness probe reflects whether persistence is working. The engine does not
+// restore from snapshots, so the previous run's final snapshot is archived
+// first rather than overwritten.
+func runSnapshots(interval time.Duration, keep int) {
+	if archived, err := snapshotStore.Archive(keep); err != nil {
+		slog.Error("archiving previous snapshot failed", "path", snapshotStore.Status().Path, "error", err)
+	} else if archived != "" {
+		slog.Info("archived previous snapshot", "path", archived)
+	}
+	saveSnapshot()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		if draining.Load() {
+			return
+		}
+		saveSnapshot()
+	}
+}
+
+func newHTTPServer(cfg config.Server, handler http.Handler) *http.Server {
+	return &http.Server{
+		Addr:              cfg.Addr,
+		Handler:           handler,
+		ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+		ReadTimeout:       cfg.ReadTimeout,
+		WriteTimeout:      cfg.WriteTimeout,
+		IdleTimeout:       cfg.IdleTimeout,
+	}
+}
+
+// serveUntilSignal runs srv until SIGTERM or SIGINT, then shuts down.
+func serveUntilSignal(srv *http.Server, shutdownTimeout time.Duration) {
+	errs := make(chan error, 1)
+	go func() { errs <- srv.ListenAndServe() }()
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGTERM, syscall.SIGINT)
+	select {
+	case err := <-errs:
+		slog.Error("server failed", "error", err)
+		os.Exit(1)
+	case sig := <-signals:
+		slog.Info("shutting down", "signal", sig.String())
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := shutdown(ctx, srv); err != nil {
+		slog.Error("shutdown incomplete", "error", err)
+		os.Exit(1)
+	}
+	slog.Info("shutdown complete")
+}
+
+// shutdown drains the engine: no new orders or connections, buffered orders
+// cancelled with their reserves released, queued commands applied, a final
+// snapshot written, and clients told the server is going away.
+func shutdown(ctx context.Context, srv *http.Server) error {
+	draining.Store(true)
+
+	// WebSocket connections are hijacked, so this only stops the listener
+	// and waits for REST requests in flight.
+	var errs []error
+	if err := srv.Shutdown(ctx); err != nil {
+		errs = append(errs, err)
+	}
+
+	buffer.Stop()
+	held := buffer.Drain()
+	now := time.Now()
+	for _, order := range held {
+		closeOrderRecord(order.ID, engine.OrderCancelled, "server_shutdown")
+		endOrderTrace(*order, "cancelled", "server_shutdown", now)
+	}
+	slog.Info("fairness buffer drained", "cancelled_orders", len(held))
+
+	sequencers.StopAll()
+	if err := saveSnapshot(); err != nil {
+		errs = append(errs, err)
+	}
+
+	hub.CloseAll(websocket.CloseGoingAway, "server shutting down")
+	if err := tracer.Shutdown(ctx); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"testing"
+
+	"cs2-prediction-engine/internal/engine"
+)
+
+// An order that passed the draining check just before shutdown stopped the
+// buffer is rejected and its reserve released, not stranded in the buffer.
+func TestOrderAfterBufferStopIsRejected(t *testing.T) {
+	url := startTestEngine(t)
+	createMarket(AdapterMarketCreatedPayload{MarketID: "m1", SeriesID: "s1"})
+	go buffer.Run(releaseBufferedOrders)
+	buffer.Stop()
+
+	conn := dialTestEngine(t, url)
+	sendJSON(t, conn, map[string]interface{}{
+		"type": "place_order",
+		"payload": map[string]interface{}{
+			"market_id": "m1", "user_id": "alice", "side": "BUY", "outcome": "YES", "price": 40, "quantity": 5,
+		},
+	})
+
+	rejected := readUntil(t, conn, "order_rejected")
+	if rejected["reason"] != "server_shutting_down" {
+		t.Errorf("rejected with %v, want server_shutting_down", rejected["reason"])
+	}
+	if account, _ := ledger.GetAccount("alice"); account.Reserved != 0 {
+		t.Errorf("alice reserved = %d, want 0", account.Reserved)
+	}
+	if n := buffer.Len(); n != 0 {
+		t.Errorf("buffer holds %d orders after stop", n)
+	}
+	if _, open := liveOrders.get(uint64(nextOrderID)); open {
+		t.Error("rejected order still has a live record")
+	}
+}
+
+func TestCancelAfterSequencersStopIsRejected(t *testing.T) {
+	startTestEngine(t)
+	createMarket(AdapterMarketCreatedPayload{MarketID: "m1", SeriesID: "s1"})
+	order := engine.Order{ID: 1, MarketID: "m1", UserID: "alice", Side: engine.Buy, Outcome: engine.Yes, Price: 40, Quantity: 5}
+	ledger.EnsureUser("alice", 100000)
+	storeOrderRecord(order, 0, nil)
+
+	draining.Store(true)
+	t.Cleanup(func() { draining.Store(false) })
+	sequencers.StopAll()
+
+	if sequencers.Get("m1").Submit(engine.Command{Kind: engine.CommandCancelOrder, OrderID: 1}) {
+		t.Error("cancel accepted by a stopped sequencer")
+	}
+	if got := refusedReason(); got != "server_shutting_down" {
+		t.Errorf("refusedReason() = %q, want server_shutting_down", got)
+	}
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"cs2-prediction-engine/internal/engine"
+	"cs2-prediction-engine/internal/surveillance"
+)
+
+// observeOrder audits an accepted order and hands it to surveillance.
+func observeOrder(order engine.Order) {
+	hash, _ := auditLog.LogOrder(order)
+	marketWatch.ObserveOrder(order, order.Timestamp, hash)
+}
+
+// observeCancel audits a cancel. Only user cancels can be feints; system
+// cancels just drop the order from surveillance. Callers must hold the
+// order's shard lock.
+func observeCancel(record *OrderRecord, reason string) {
+	remaining := record.Order.Quantity - record.FilledQuantity
+	hash, _ := auditLog.LogCancel(record.Order, remaining, reason)
+	if reason != "user_cancelled" {
+		marketWatch.ObserveOrderClosed(record.Order.ID)
+		return
+	}
+	marketWatch.ObserveCancel(record.Order.ID, remaining, record.UpdatedAt, hash)
+}
+
+func observeMatch(marketID string, m engine.Match, auditHash string) {
+	reportSurveillanceAlerts(marketWatch.ObserveMatch(marketID, m, auditHash))
+}
+
+// observeGameState flags fills that anticipated a large fair-value move.
+func observeGameState(marketID string, previous *engine.MarketGameState, state engine.MarketGameState, source, auditHash string, receivedAt time.Time) {
+	if previous == nil {
+		return
+	}
+	before, after := engine.FairValue(previous), engine.FairValue(&state)
+	alerts := marketWatch.ObserveGameState(marketID, before, after, receivedAt, surveillance.Evidence{
+		Kind:      "feed",
+		Ref:       source,
+		Detail:    fmt.Sprintf("round %d %d-%d, fair value %d -> %d", state.Round, state.TerroristScore, state.CTScore, before, after),
+		AuditHash: auditHash,
+	})
+	reportSurveillanceAlerts(alerts)
+}
+
+// reportSurveillanceAlerts logs new alerts. They are reviewed through the
+// admin queue and are never broadcast to traders.
+func reportSurveillanceAlerts(alerts []surveillance.Alert) {
+	for _, a := range alerts {
+		slog.Warn("surveillance alert", "alert_id", a.ID, "kind", a.Kind, "market_id", a.MarketID, "users", a.Users, "detail", a.Detail)
+	}
+}
+
+func handleSurveillanceAlerts(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	status := surveillance.AlertStatus(r.URL.Query().Get("status"))
+	alerts := marketWatch.Alerts(status)
+	if marketID := r.URL.Query().Get("market_id"); marketID != "" {
+		filtered := alerts[:0]
+		for _, a := range alerts {
+			if a.MarketID == marketID {
+				filtered = append(filtered, a)
+			}
+		}
+		alerts = filtered
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]interface{}{
+		"alerts": alerts,
+	}); err != nil {
+		http.Error(w, "failed to encode response", http.StatusInternalServerError)
+	}
+}
+
+type AlertReviewRequest struct {
+	Note string `json:"note,omitempty"`
+}
+
+func handleSurveillanceAlertByID(w http.ResponseWriter, r *http.Request) {
+	// Expected: /admin/surveillance/alerts/{id} or /admin/surveillance/alerts/{id}/{acknowledge|dismiss}
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/admin/surveillance/alerts/"), "/")
+	id, err := strconv.ParseUint(parts[0], 10, 64)
+	if err != nil || len(parts) > 2 {
+		http.Error(w, "invalid alert resource path", http.StatusBadRequest)
+		return
+	}
+
+	if len(parts) == 1 {
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		alert, ok := marketWatch.Alert(id)
+		if !ok {
+			http.Error(w, "alert not found", http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(alert); err != nil {
+			http.Error(w, "failed to encode response", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var status surveillance.AlertStatus
+	switch parts[1] {
+	case "acknowledge":
+		status = surveillance.StatusAcknowledged
+	case "dismiss":
+		status = surveillance.StatusDismissed
+	default:
+		http.Error(w, "unknown alert action", http.StatusBadRequest)
+		return
+	}
+	var req AlertReviewRequest
+	if r.ContentLength > 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+	}
+
+	operator := operatorID(r)
+	alert, err := marketWatch.Review(id, status, operator, req.Note, time.Now())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	auditLog.LogOperatorAction(operator, alert.MarketID, "surveillance_"+parts[1], fmt.Sprintf("alert=%d %s", id, req.Note))
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(alert); err != nil {
+		http.Error(w, "failed to encode response", http.StatusInternalServerError)
+	}
+}
+
+type AccountLinkRequest struct {
+	Users []string `json:"users"`
+}
+
+// handleSurveillanceLinks records accounts controlled by one party, so trades
+// between them are flagged as wash trades.
+func handleSurveillanceLinks(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var req AccountLinkRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || len(req.Users) < 2 {
+		http.Error(w, "at least two users are required", http.StatusBadRequest)
+		return
+	}
+	marketWatch.LinkAccounts(req.Users...)
+	auditLog.LogOperatorAction(operatorID(r), "", "link_accounts", strings.Join(req.Users, ","))
+	w.WriteHeader(http.StatusNoContent)
+}
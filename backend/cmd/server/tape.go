@@ -0,0 +1,127 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultTradePageSize = 100
+	maxTradePageSize     = 500
+)
+
+var candleIntervals = map[string]time.Duration{
+	"1m": time.Minute,
+	"5m": 5 * time.Minute,
+	"1h": time.Hour,
+}
+
+func handleMarketTrades(w http.ResponseWriter, r *http.Request, marketID string) {
+	if _, ok := marketRegistry.GetMarket(marketID); !ok {
+		http.Error(w, "market not found", http.StatusNotFound)
+		return
+	}
+
+	query := r.URL.Query()
+	var cursor uint64
+	if raw := query.Get("cursor"); raw != "" {
+		parsed, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil {
+			http.Error(w, "invalid cursor", http.StatusBadRequest)
+			return
+		}
+		cursor = parsed
+	}
+	limit := defaultTradePageSize
+	if raw := query.Get("limit"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed <= 0 {
+			http.Error(w, "invalid limit", http.StatusBadRequest)
+			return
+		}
+		limit = min(parsed, maxTradePageSize)
+	}
+
+	trades, next := tradeStore.ListTrades(marketID, cursor, limit)
+	response := map[string]interface{}{
+		"market_id": marketID,
+		"trades":    trades,
+	}
+	if next > 0 {
+		response["next_cursor"] = strconv.FormatUint(next, 10)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		http.Error(w, "failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+func handleMarketCandles(w http.ResponseWriter, r *http.Request, marketID string) {
+	if _, ok := marketRegistry.GetMarket(marketID); !ok {
+		http.Error(w, "market not found", http.StatusNotFound)
+		return
+	}
+
+	query := r.URL.Query()
+	intervalName := query.Get("interval")
+	if intervalName == "" {
+		intervalName = "1m"
+	}
+	interval, ok := candleIntervals[intervalName]
+	if !ok {
+		http.Error(w, "invalid interval (1m, 5m, 1h)", http.StatusBadRequest)
+		return
+	}
+	var since time.Time
+	if raw := query.Get("since"); raw != "" {
+		parsed, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			http.Error(w, "invalid since (RFC3339)", http.StatusBadRequest)
+			return
+		}
+		since = parsed
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]interface{}{
+		"market_id": marketID,
+		"interval":  intervalName,
+		"candles":   tradeStore.Candles(marketID, interval, since),
+		"rounds":    tradeStore.RoundMarkers(marketID, since),
+	}); err != nil {
+		http.Error(w, "failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+func handleMarketBook(w http.ResponseWriter, r *http.Request, marketID string) {
+	if _, ok := marketRegistry.GetMarket(marketID); !ok {
+		http.Error(w, "market not found", http.StatusNotFound)
+		return
+	}
+
+	levels := 0
+	if raw := r.URL.Query().Get("levels"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 0 {
+			http.Error(w, "invalid levels", http.StatusBadRequest)
+			return
+		}
+		levels = parsed
+	}
+
+	ob := marketManager.GetOrderBook(marketID)
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]interface{}{
+		"market_id": marketID,
+		"quote":     ob.Quote(),
+		"depth":     ob.Depth(levels),
+	}); err != nil {
+		http.Error(w, "failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
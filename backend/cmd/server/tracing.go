@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"cs2-prediction-engine/internal/config"
+	"cs2-prediction-engine/internal/engine"
+	"cs2-prediction-engine/internal/telemetry"
+)
+
+// setupTelemetry installs the structured logger as the slog default and starts
+// the span exporter. Tracing is off when no endpoint is configured; the log
+// level follows logLevel so config reloads can change it.
+func setupTelemetry(c config.Config) (*slog.Logger, *telemetry.Tracer) {
+	logger, err := telemetry.NewLogger(os.Stdout, c.Logging.Format, logLevel)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid logging configuration: %v\n", err)
+		os.Exit(1)
+	}
+	slog.SetDefault(logger)
+
+	cfg := telemetry.DefaultTracerConfig
+	cfg.Endpoint = c.Tracing.Endpoint
+	if c.Tracing.ServiceName != "" {
+		cfg.ServiceName = c.Tracing.ServiceName
+	}
+	if cfg.Endpoint != "" {
+		logger.Info("exporting trace spans", "endpoint", cfg.Endpoint)
+	}
+	return logger, telemetry.NewTracer(cfg, logger)
+}
+
+// orderLogger carries the order's correlation ID on every record, and the
+// caller's own ID when it sent one.
+func orderLogger(order engine.Order) *slog.Logger {
+	logger := slog.With(
+		"correlation_id", order.CorrelationID,
+		"order_id", order.ID,
+		"market_id", order.MarketID,
+	)
+	if order.ClientCorrelationID != "" {
+		logger = logger.With("client_correlation_id", order.ClientCorrelationID)
+	}
+	return logger
+}
+
+// traceOrderStage records one stage of an order as a child of its root span.
+func traceOrderStage(order engine.Order, name string, start, end time.Time, attrs map[string]string) {
+	span := tracer.Start(order.CorrelationID, telemetry.RootSpanID(order.CorrelationID), name, start)
+	for k, v := range attrs {
+		span.SetAttr(k, v)
+	}
+	span.End(end)
+}
+
+// endOrderTrace records the root span from receipt to the order's first
+// outcome: rejected, cancelled while buffered, or processed by the book.
+func endOrderTrace(order engine.Order, outcome, reason string, end time.Time) {
+	span := tracer.StartRoot(order.CorrelationID, "order", order.Timestamp)
+	if span == nil {
+		return
+	}
+	span.Kind = telemetry.SpanKindServer
+	span.SetAttr("order.id", fmt.Sprint(order.ID))
+	span.SetAttr("market.id", order.MarketID)
+	span.SetAttr("order.outcome", outcome)
+	if order.ClientCorrelationID != "" {
+		span.SetAttr("order.client_correlation_id", order.ClientCorrelationID)
+	}
+	if reason != "" {
+		span.SetAttr("order.reason", reason)
+	}
+	span.Error = outcome == "rejected"
+	span.End(end)
+}
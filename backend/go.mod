@@ -3,3 +3,5 @@ module cs2-prediction-engine
 go 1.24
 
 require github.com/gorilla/websocket v1.5.3
+
+require gopkg.in/yaml.v3 v3.0.1
@@ -1,10 +1,13 @@
 package audit
 
 import (
+	"context"
 	"crypto/sha256"
 	"cs2-prediction-engine/internal/engine"
 	"encoding/hex"
 	"fmt"
+	"log/slog"
+	"strings"
 	"sync"
 )
 
@@ -28,18 +31,38 @@ func NewVeritasChain() *VeritasChain {
 
 // LogEvent hashes an event and adds it to the chain
 func (vc *VeritasChain) LogEvent(eventData string) (string, error) {
-	vc.mu.Lock()
-	defer vc.mu.Unlock()
+	return vc.logEvent(eventData)
+}
 
+// logEvent appends to the chain and logs the hash with any correlation IDs
+// the event belongs to.
+func (vc *VeritasChain) logEvent(eventData string, correlationIDs ...string) (string, error) {
+	vc.mu.Lock()
 	hash := sha256.Sum256([]byte(eventData))
 	hashStr := hex.EncodeToString(hash[:])
-
 	vc.hashes = append(vc.hashes, hashStr)
-	fmt.Printf("📝 Audit Log (VCP): %s\n", hashStr)
+	index := len(vc.hashes) - 1
+	vc.mu.Unlock()
 
+	attrs := []slog.Attr{slog.String("hash", hashStr), slog.Int("index", index)}
+	switch len(correlationIDs) {
+	case 0:
+	case 1:
+		attrs = append(attrs, slog.String("correlation_id", correlationIDs[0]))
+	default:
+		attrs = append(attrs, slog.Any("correlation_ids", correlationIDs))
+	}
+	slog.LogAttrs(context.Background(), slog.LevelDebug, "audit event", attrs...)
 	return hashStr, nil
 }
 
+// Len returns the number of events in the chain
+func (vc *VeritasChain) Len() int {
+	vc.mu.Lock()
+	defer vc.mu.Unlock()
+	return len(vc.hashes)
+}
+
 // GetMerkleRoot calculates the root of the current event hashes
 func (vc *VeritasChain) GetMerkleRoot() string {
 	vc.mu.Lock()
@@ -70,8 +93,49 @@ func (vc *VeritasChain) GetMerkleRoot() string {
 	return tempHashes[0]
 }
 
+// LogFeedState records which source reported a game state
+func (vc *VeritasChain) LogFeedState(marketID, source string, gs engine.MarketGameState) (string, error) {
+	data := fmt.Sprintf("FEED: Market=%s Source=%s Map=%s Round=%d Score=%d-%d Phase=%s",
+		marketID, source, gs.Map, gs.Round, gs.TerroristScore, gs.CTScore, gs.Phase)
+	return vc.LogEvent(data)
+}
+
+// LogSettlement records a settlement and the sources whose agreement allowed it
+func (vc *VeritasChain) LogSettlement(marketID, winner string, sources []string) (string, error) {
+	data := fmt.Sprintf("SETTLE: Market=%s Winner=%s Sources=%s", marketID, winner, strings.Join(sources, ","))
+	return vc.LogEvent(data)
+}
+
+// LogOperatorAction records an admin action and the operator who took it
+func (vc *VeritasChain) LogOperatorAction(operatorID, marketID, action, detail string) (string, error) {
+	data := fmt.Sprintf("OPERATOR: Operator=%s Market=%s Action=%s Detail=%s", operatorID, marketID, action, detail)
+	return vc.LogEvent(data)
+}
+
+// LogVoid records a market voided outside the operator API
+func (vc *VeritasChain) LogVoid(marketID, reason string) (string, error) {
+	data := fmt.Sprintf("VOID: Market=%s Reason=%s", marketID, reason)
+	return vc.LogEvent(data)
+}
+
+// LogOrder records an accepted order
+func (vc *VeritasChain) LogOrder(o engine.Order) (string, error) {
+	data := fmt.Sprintf("ORDER: ID=%d User=%s Market=%s %s %s@%d Qty=%d Corr=%s",
+		o.ID, o.UserID, o.MarketID, o.Side, o.Outcome, o.Price, o.Quantity, o.CorrelationID)
+	return vc.logEvent(data, o.CorrelationID)
+}
+
+// LogCancel records an order leaving the book unfilled
+func (vc *VeritasChain) LogCancel(o engine.Order, remaining int64, reason string) (string, error) {
+	data := fmt.Sprintf("CANCEL: ID=%d User=%s Market=%s Remaining=%d Reason=%s Corr=%s",
+		o.ID, o.UserID, o.MarketID, remaining, reason, o.CorrelationID)
+	return vc.logEvent(data, o.CorrelationID)
+}
+
 // LogMatch is a convenience helper for logging trade executions
-func (vc *VeritasChain) LogMatch(m engine.Match) {
-	data := fmt.Sprintf("MATCH: Maker=%d Taker=%d Price=%d Qty=%d", m.MakerOrderID, m.TakerOrderID, m.Price, m.Quantity)
-	vc.LogEvent(data)
+func (vc *VeritasChain) LogMatch(m engine.Match) (string, error) {
+	data := fmt.Sprintf("MATCH: Maker=%d@%d Taker=%d@%d Price=%d Qty=%d Complementary=%t MakerCorr=%s TakerCorr=%s",
+		m.MakerOrderID, m.Maker.Price, m.TakerOrderID, m.Taker.Price, m.Price, m.Quantity, m.Complementary,
+		m.Maker.CorrelationID, m.Taker.CorrelationID)
+	return vc.logEvent(data, m.Maker.CorrelationID, m.Taker.CorrelationID)
 }
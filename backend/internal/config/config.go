@@ -0,0 +1,519 @@
+// Package config loads the engine configuration from a YAML file and
+// environment overrides, validates it, and reports what changed on reload.
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"cs2-prediction-engine/internal/engine"
+	"cs2-prediction-engine/internal/feedhealth"
+	"cs2-prediction-engine/internal/gateway"
+
+	"gopkg.in/yaml.v3"
+)
+
+type Config struct {
+	Server      Server       `yaml:"server"`
+	Accounts    Accounts     `yaml:"accounts"`
+	Fairness    Fairness     `yaml:"fairness"`
+	FeedHealth  FeedHealth   `yaml:"feed_health"`
+	Risk        Risk         `yaml:"risk"`
+	Exposure    Exposure     `yaml:"exposure"`
+	MarketTypes []MarketType `yaml:"market_types"`
+	Snapshot    Snapshot     `yaml:"snapshot"`
+	Logging     Logging      `yaml:"logging"`
+	Tracing     Tracing      `yaml:"tracing"`
+}
+
+type Server struct {
+	Addr              string        `yaml:"addr"`
+	ReadHeaderTimeout time.Duration `yaml:"read_header_timeout"`
+	ReadTimeout       time.Duration `yaml:"read_timeout"`
+	WriteTimeout      time.Duration `yaml:"write_timeout"`
+	IdleTimeout       time.Duration `yaml:"idle_timeout"`
+	ShutdownTimeout   time.Duration `yaml:"shutdown_timeout"`
+	// Environment is "production" or "development" and picks the origin
+	// allowlist.
+	Environment string  `yaml:"environment"`
+	Origins     Origins `yaml:"origins"`
+}
+
+// Origins lists the browser origins allowed to open WebSockets and call the
+// REST API, per environment. Production entries must be exact origins such as
+// "https://app.example.com"; development entries may also be path.Match
+// patterns such as "http://localhost:*", or "*" for any origin.
+type Origins struct {
+	Production  []string `yaml:"production"`
+	Development []string `yaml:"development"`
+}
+
+const (
+	EnvProduction  = "production"
+	EnvDevelopment = "development"
+)
+
+// AllowedOrigins is the allowlist for the configured environment.
+func (s Server) AllowedOrigins() []string {
+	if s.Environment == EnvProduction {
+		return s.Origins.Production
+	}
+	return s.Origins.Development
+}
+
+type Accounts struct {
+	DefaultUserID  string `yaml:"default_user_id"`
+	DefaultBalance int64  `yaml:"default_balance"` // Cents
+	// OrderHistoryPerUser caps the closed orders retained per user. Their
+	// client_order_ids stay reserved for as long as they are retained.
+	OrderHistoryPerUser int `yaml:"order_history_per_user"`
+}
+
+type Fairness struct {
+	BaseDelay    time.Duration `yaml:"base_delay"`
+	MaxDelay     time.Duration `yaml:"max_delay"`
+	LagMargin    time.Duration `yaml:"lag_margin"`
+	ImpactExtra  time.Duration `yaml:"impact_extra"`
+	ImpactWindow time.Duration `yaml:"impact_window"`
+	LagSmoothing float64       `yaml:"lag_smoothing"`
+	// PreMatchDelay applies before a market goes in play.
+	PreMatchDelay time.Duration `yaml:"pre_match_delay"`
+}
+
+type FeedHealth struct {
+	StaleTimeout   time.Duration `yaml:"stale_timeout"`
+	MaxRoundJump   int           `yaml:"max_round_jump"`
+	HealthyUpdates int           `yaml:"healthy_updates"`
+	// Sources lists the accepted feed sources in priority order; Quorum of
+	// them must report the same result before a market settles.
+	Sources       []string      `yaml:"sources"`
+	Quorum        int           `yaml:"quorum"`
+	LeaderTimeout time.Duration `yaml:"leader_timeout"`
+}
+
+type Limits struct {
+	MaxOrderQuantity   int64 `yaml:"max_order_quantity,omitempty"`
+	MaxOpenNotional    int64 `yaml:"max_open_notional,omitempty"`
+	MaxMarketLoss      int64 `yaml:"max_market_loss,omitempty"`
+	MaxOrdersPerSecond int   `yaml:"max_orders_per_second,omitempty"`
+	PriceBand          int64 `yaml:"price_band,omitempty"`
+}
+
+type Risk struct {
+	DefaultTier string            `yaml:"default_tier"`
+	Tiers       map[string]Limits `yaml:"tiers"`
+}
+
+type Exposure struct {
+	MaxLiability               int64   `yaml:"max_liability"`
+	MaxHouseLoss               int64   `yaml:"max_house_loss"`
+	MaxTopConcentration        float64 `yaml:"max_top_concentration"`
+	MinHoldersForConcentration int     `yaml:"min_holders_for_concentration"`
+}
+
+// MarketType overrides settings for markets whose ID matches Match, a
+// path.Match pattern such as "round_*_winner". The first matching type wins.
+type MarketType struct {
+	Name          string        `yaml:"name"`
+	Match         string        `yaml:"match"`
+	BaseDelay     time.Duration `yaml:"base_delay,omitempty"`
+	MaxDelay      time.Duration `yaml:"max_delay,omitempty"`
+	PreMatchDelay time.Duration `yaml:"pre_match_delay,omitempty"`
+	Risk          Limits        `yaml:"risk,omitempty"`
+}
+
+type Snapshot struct {
+	Path     string        `yaml:"path"`
+	Interval time.Duration `yaml:"interval"`
+	// Keep is how many snapshots from earlier runs are kept beside Path.
+	Keep int `yaml:"keep"`
+}
+
+type Logging struct {
+	Level  string `yaml:"level"`
+	Format string `yaml:"format"`
+}
+
+type Tracing struct {
+	Endpoint    string `yaml:"endpoint"`
+	ServiceName string `yaml:"service_name"`
+}
+
+func limitsFrom(l engine.RiskLimits) Limits {
+	return Limits{
+		MaxOrderQuantity:   l.MaxOrderQuantity,
+		MaxOpenNotional:    l.MaxOpenNotional,
+		MaxMarketLoss:      l.MaxMarketLoss,
+		MaxOrdersPerSecond: l.MaxOrdersPerSecond,
+		PriceBand:          l.PriceBand,
+	}
+}
+
+func (l Limits) engine() engine.RiskLimits {
+	return engine.RiskLimits{
+		MaxOrderQuantity:   l.MaxOrderQuantity,
+		MaxOpenNotional:    l.MaxOpenNotional,
+		MaxMarketLoss:      l.MaxMarketLoss,
+		MaxOrdersPerSecond: l.MaxOrdersPerSecond,
+		PriceBand:          l.PriceBand,
+	}
+}
+
+// Default is the configuration the engine runs with when nothing is set.
+func Default() Config {
+	tiers := make(map[string]Limits, len(engine.DefaultRiskConfig.Tiers))
+	for tier, limits := range engine.DefaultRiskConfig.Tiers {
+		tiers[string(tier)] = limitsFrom(limits)
+	}
+	delay := engine.DefaultDelayPolicy
+	exposure := engine.DefaultExposureThresholds
+	feed := feedhealth.DefaultRuleSettings
+	return Config{
+		Server: Server{
+			Addr:              ":8080",
+			ReadHeaderTimeout: 5 * time.Second,
+			ReadTimeout:       15 * time.Second,
+			WriteTimeout:      15 * time.Second,
+			IdleTimeout:       60 * time.Second,
+			ShutdownTimeout:   20 * time.Second,
+			Environment:       EnvDevelopment,
+			Origins: Origins{
+				Development: []string{"http://localhost:*", "http://127.0.0.1:*"},
+			},
+		},
+		Accounts: Accounts{
+			DefaultUserID:       "demo_user_1",
+			DefaultBalance:      1000000, // 10,000 IFC with 2 implied decimals
+			OrderHistoryPerUser: 1000,
+		},
+		Fairness: Fairness{
+			BaseDelay:     delay.Base,
+			MaxDelay:      delay.Max,
+			LagMargin:     delay.LagMargin,
+			ImpactExtra:   delay.ImpactExtra,
+			ImpactWindow:  delay.ImpactWindow,
+			LagSmoothing:  delay.LagSmoothing,
+			PreMatchDelay: engine.DefaultSchedulePolicy.PreMatchDelay,
+		},
+		FeedHealth: FeedHealth{
+			StaleTimeout:   feed.StaleTimeout,
+			MaxRoundJump:   feed.MaxRoundJump,
+			HealthyUpdates: feed.HealthyUpdates,
+			Sources:        append([]string(nil), feedhealth.DefaultReconcileConfig.Sources...),
+			Quorum:         feedhealth.DefaultReconcileConfig.Quorum,
+			LeaderTimeout:  feedhealth.DefaultReconcileConfig.LeaderTimeout,
+		},
+		Risk: Risk{
+			DefaultTier: string(engine.DefaultRiskConfig.DefaultTier),
+			Tiers:       tiers,
+		},
+		Exposure: Exposure{
+			MaxLiability:               exposure.MaxLiability,
+			MaxHouseLoss:               exposure.MaxHouseLoss,
+			MaxTopConcentration:        exposure.MaxTopConcentration,
+			MinHoldersForConcentration: exposure.MinHoldersForConcentration,
+		},
+		Snapshot: Snapshot{
+			Path:     "data/engine-snapshot.json",
+			Interval: 30 * time.Second,
+			Keep:     10,
+		},
+		Logging: Logging{Level: "info", Format: "json"},
+		Tracing: Tracing{ServiceName: "cs2-prediction-engine"},
+	}
+}
+
+// Load reads the defaults, then the YAML file at path if one is given, then
+// environment overrides, and validates the result. Unknown keys in the file
+// are errors so typos do not silently fall back to defaults.
+func Load(path string) (Config, error) {
+	cfg := Default()
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return Config{}, fmt.Errorf("read config: %w", err)
+		}
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		if err := dec.Decode(&cfg); err != nil && !errors.Is(err, io.EOF) {
+			return Config{}, fmt.Errorf("parse %s: %w", path, err)
+		}
+	}
+	if err := applyEnv(&cfg, os.LookupEnv); err != nil {
+		return Config{}, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return Config{}, err
+	}
+	return cfg, nil
+}
+
+// envOverrides maps environment variables onto config fields.
+var envOverrides = []struct {
+	name string
+	set  func(c *Config, v string) error
+}{
+	{"ENGINE_ADDR", func(c *Config, v string) error { c.Server.Addr = v; return nil }},
+	{"ENGINE_ENV", func(c *Config, v string) error { c.Server.Environment = v; return nil }},
+	// Replaces the allowlist of whichever environment is active.
+	{"ENGINE_ALLOWED_ORIGINS", func(c *Config, v string) error {
+		if c.Server.Environment == EnvProduction {
+			c.Server.Origins.Production = splitList(v)
+		} else {
+			c.Server.Origins.Development = splitList(v)
+		}
+		return nil
+	}},
+	{"ENGINE_DEFAULT_USER_ID", func(c *Config, v string) error { c.Accounts.DefaultUserID = v; return nil }},
+	{"ENGINE_DEFAULT_BALANCE", func(c *Config, v string) error { return setInt(&c.Accounts.DefaultBalance, v) }},
+	{"ENGINE_FAIRNESS_DELAY", func(c *Config, v string) error { return setDuration(&c.Fairness.BaseDelay, v) }},
+	{"ENGINE_MAX_FAIRNESS_DELAY", func(c *Config, v string) error { return setDuration(&c.Fairness.MaxDelay, v) }},
+	{"ENGINE_FEED_HEALTHY_UPDATES", func(c *Config, v string) error {
+		n, err := strconv.Atoi(v)
+		c.FeedHealth.HealthyUpdates = n
+		return err
+	}},
+	{"ENGINE_FEED_SOURCES", func(c *Config, v string) error { c.FeedHealth.Sources = splitList(v); return nil }},
+	{"ENGINE_FEED_QUORUM", func(c *Config, v string) error {
+		n, err := strconv.Atoi(v)
+		c.FeedHealth.Quorum = n
+		return err
+	}},
+	{"SNAPSHOT_PATH", func(c *Config, v string) error { c.Snapshot.Path = v; return nil }},
+	{"LOG_LEVEL", func(c *Config, v string) error { c.Logging.Level = v; return nil }},
+	{"LOG_FORMAT", func(c *Config, v string) error { c.Logging.Format = v; return nil }},
+	{"OTEL_EXPORTER_OTLP_ENDPOINT", func(c *Config, v string) error { c.Tracing.Endpoint = v; return nil }},
+	{"OTEL_SERVICE_NAME", func(c *Config, v string) error { c.Tracing.ServiceName = v; return nil }},
+}
+
+func applyEnv(cfg *Config, lookup func(string) (string, bool)) error {
+	for _, o := range envOverrides {
+		v, ok := lookup(o.name)
+		if !ok || v == "" {
+			continue
+		}
+		if err := o.set(cfg, v); err != nil {
+			return fmt.Errorf("%s: %w", o.name, err)
+		}
+	}
+	return nil
+}
+
+func setInt(dst *int64, v string) error {
+	n, err := strconv.ParseInt(v, 10, 64)
+	if err == nil {
+		*dst = n
+	}
+	return err
+}
+
+func setDuration(dst *time.Duration, v string) error {
+	d, err := time.ParseDuration(v)
+	if err == nil {
+		*dst = d
+	}
+	return err
+}
+
+func splitList(v string) []string {
+	var out []string
+	for _, s := range strings.Split(v, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// Validate reports every problem at once rather than the first.
+func (c Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...interface{}) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	check(c.Server.Addr != "", "server.addr is required")
+	for name, d := range map[string]time.Duration{
+		"server.read_header_timeout": c.Server.ReadHeaderTimeout,
+		"server.read_timeout":        c.Server.ReadTimeout,
+		"server.write_timeout":       c.Server.WriteTimeout,
+		"server.idle_timeout":        c.Server.IdleTimeout,
+		"server.shutdown_timeout":    c.Server.ShutdownTimeout,
+		"snapshot.interval":          c.Snapshot.Interval,
+	} {
+		check(d > 0, "%s must be positive", name)
+	}
+	check(c.Server.Environment == EnvProduction || c.Server.Environment == EnvDevelopment,
+		"server.environment must be production or development")
+	if c.Server.Environment == EnvProduction {
+		check(len(c.Server.Origins.Production) > 0, "server.origins.production must list the frontend origins")
+	}
+	for _, o := range c.Server.Origins.Production {
+		err := gateway.ExactOrigin(o)
+		check(err == nil, "server.origins.production: %v", err)
+	}
+	for _, o := range append(append([]string{}, c.Server.Origins.Production...), c.Server.Origins.Development...) {
+		_, err := path.Match(o, "")
+		check(err == nil, "server.origins: %q is not a valid pattern", o)
+	}
+
+	check(c.Accounts.DefaultUserID != "", "accounts.default_user_id is required")
+	check(c.Accounts.DefaultBalance >= 0, "accounts.default_balance must not be negative")
+	check(c.Accounts.OrderHistoryPerUser > 0, "accounts.order_history_per_user must be positive")
+
+	check(c.Fairness.BaseDelay >= 0, "fairness.base_delay must not be negative")
+	check(c.Fairness.MaxDelay == 0 || c.Fairness.MaxDelay >= c.Fairness.BaseDelay,
+		"fairness.max_delay %s is below base_delay %s", c.Fairness.MaxDelay, c.Fairness.BaseDelay)
+	check(c.Fairness.LagSmoothing > 0 && c.Fairness.LagSmoothing <= 1, "fairness.lag_smoothing must be in (0, 1]")
+	check(c.Fairness.PreMatchDelay >= 0, "fairness.pre_match_delay must not be negative")
+
+	check(c.FeedHealth.StaleTimeout > 0, "feed_health.stale_timeout must be positive")
+	check(c.FeedHealth.MaxRoundJump > 0, "feed_health.max_round_jump must be positive")
+	check(c.FeedHealth.HealthyUpdates > 0, "feed_health.healthy_updates must be positive")
+	check(len(c.FeedHealth.Sources) > 0, "feed_health.sources must not be empty")
+	seenSources := make(map[string]bool, len(c.FeedHealth.Sources))
+	for _, source := range c.FeedHealth.Sources {
+		check(source != "" && !seenSources[source], "feed_health.sources: %q is empty or listed twice", source)
+		seenSources[source] = true
+	}
+	check(c.FeedHealth.Quorum >= 1 && c.FeedHealth.Quorum <= len(c.FeedHealth.Sources),
+		"feed_health.quorum %d must be between 1 and the number of sources", c.FeedHealth.Quorum)
+	check(c.FeedHealth.LeaderTimeout > 0, "feed_health.leader_timeout must be positive")
+
+	for _, tier := range []engine.RiskTier{engine.TierRetail, engine.TierPro, engine.TierMarketMaker} {
+		_, ok := c.Risk.Tiers[string(tier)]
+		check(ok, "risk.tiers.%s is required", tier)
+	}
+	_, ok := c.Risk.Tiers[c.Risk.DefaultTier]
+	check(ok, "risk.default_tier %q is not a configured tier", c.Risk.DefaultTier)
+	for tier, l := range c.Risk.Tiers {
+		check(l.valid(), "risk.tiers.%s: limits must not be negative", tier)
+	}
+
+	check(c.Exposure.MaxTopConcentration >= 0 && c.Exposure.MaxTopConcentration <= 1,
+		"exposure.max_top_concentration must be in [0, 1]")
+
+	names := map[string]bool{}
+	for i, t := range c.MarketTypes {
+		check(t.Name != "", "market_types[%d].name is required", i)
+		check(!names[t.Name], "market_types[%d]: duplicate name %q", i, t.Name)
+		names[t.Name] = true
+		_, err := path.Match(t.Match, "")
+		check(t.Match != "" && err == nil, "market_types.%s.match %q is not a valid pattern", t.Name, t.Match)
+		check(t.BaseDelay >= 0 && t.MaxDelay >= 0 && t.PreMatchDelay >= 0, "market_types.%s: delays must not be negative", t.Name)
+		check(t.Risk.valid(), "market_types.%s.risk: limits must not be negative", t.Name)
+	}
+
+	check(c.Snapshot.Path != "", "snapshot.path is required")
+	check(c.Snapshot.Keep > 0, "snapshot.keep must be positive")
+	var level slog.Level
+	check(level.UnmarshalText([]byte(c.Logging.Level)) == nil, "logging.level %q is not debug, info, warn or error", c.Logging.Level)
+	format := strings.ToLower(c.Logging.Format)
+	check(format == "json" || format == "text", "logging.format must be json or text")
+	return errors.Join(errs...)
+}
+
+func (l Limits) valid() bool {
+	return l.MaxOrderQuantity >= 0 && l.MaxOpenNotional >= 0 && l.MaxMarketLoss >= 0 &&
+		l.MaxOrdersPerSecond >= 0 && l.PriceBand >= 0
+}
+
+// DelayPolicy is the default fairness policy.
+func (c Config) DelayPolicy() engine.DelayPolicy {
+	return engine.DelayPolicy{
+		Base:         c.Fairness.BaseDelay,
+		Max:          c.Fairness.MaxDelay,
+		LagMargin:    c.Fairness.LagMargin,
+		ImpactExtra:  c.Fairness.ImpactExtra,
+		ImpactWindow: c.Fairness.ImpactWindow,
+		LagSmoothing: c.Fairness.LagSmoothing,
+	}
+}
+
+// DelayOverrides are the fairness policies of market types that change a delay.
+func (c Config) DelayOverrides() []engine.DelayOverride {
+	var out []engine.DelayOverride
+	for _, t := range c.MarketTypes {
+		if t.BaseDelay == 0 && t.MaxDelay == 0 {
+			continue
+		}
+		policy := c.DelayPolicy()
+		if t.BaseDelay > 0 {
+			policy.Base = t.BaseDelay
+		}
+		if t.MaxDelay > 0 {
+			policy.Max = t.MaxDelay
+		}
+		out = append(out, engine.DelayOverride{Pattern: t.Match, Policy: policy})
+	}
+	return out
+}
+
+// PreMatchDelay is the fixed pre-match delay for marketID.
+func (c Config) PreMatchDelay(marketID string) time.Duration {
+	if t, ok := c.MarketType(marketID); ok && t.PreMatchDelay > 0 {
+		return t.PreMatchDelay
+	}
+	return c.Fairness.PreMatchDelay
+}
+
+// MarketType returns the first market type matching marketID.
+func (c Config) MarketType(marketID string) (MarketType, bool) {
+	for _, t := range c.MarketTypes {
+		if engine.MatchesMarket(t.Match, marketID) {
+			return t, true
+		}
+	}
+	return MarketType{}, false
+}
+
+func (c Config) RiskConfig() engine.RiskConfig {
+	cfg := engine.RiskConfig{
+		DefaultTier: engine.RiskTier(c.Risk.DefaultTier),
+		Tiers:       make(map[engine.RiskTier]engine.RiskLimits, len(c.Risk.Tiers)),
+	}
+	for tier, limits := range c.Risk.Tiers {
+		cfg.Tiers[engine.RiskTier(tier)] = limits.engine()
+	}
+	for _, t := range c.MarketTypes {
+		if t.Risk != (Limits{}) {
+			cfg.MarketTypes = append(cfg.MarketTypes, engine.MarketTypeLimits{Pattern: t.Match, Limits: t.Risk.engine()})
+		}
+	}
+	return cfg
+}
+
+func (c Config) ExposureThresholds() engine.ExposureThresholds {
+	return engine.ExposureThresholds{
+		MaxLiability:               c.Exposure.MaxLiability,
+		MaxHouseLoss:               c.Exposure.MaxHouseLoss,
+		MaxTopConcentration:        c.Exposure.MaxTopConcentration,
+		MinHoldersForConcentration: c.Exposure.MinHoldersForConcentration,
+	}
+}
+
+func (c Config) FeedRuleSettings() feedhealth.RuleSettings {
+	return feedhealth.RuleSettings{
+		StaleTimeout:   c.FeedHealth.StaleTimeout,
+		MaxRoundJump:   c.FeedHealth.MaxRoundJump,
+		HealthyUpdates: c.FeedHealth.HealthyUpdates,
+	}
+}
+
+func (c Config) ReconcileConfig() feedhealth.ReconcileConfig {
+	return feedhealth.ReconcileConfig{
+		Sources:       append([]string(nil), c.FeedHealth.Sources...),
+		Quorum:        c.FeedHealth.Quorum,
+		LeaderTimeout: c.FeedHealth.LeaderTimeout,
+	}
+}
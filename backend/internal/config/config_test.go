@@ -0,0 +1,253 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"cs2-prediction-engine/internal/config"
+)
+
+func TestValidateProductionOrigins(t *testing.T) {
+	tests := []struct {
+		origins []string
+		ok      bool
+	}{
+		{[]string{"https://app.example.com", "https://staging.example.com"}, true},
+		{nil, false},
+		{[]string{"*"}, false},
+		{[]string{"https://app.example.com", "https://*.vercel.app"}, false},
+		{[]string{"https://app.example.com:*"}, false},
+	}
+	for _, tt := range tests {
+		cfg := config.Default()
+		cfg.Server.Environment = config.EnvProduction
+		cfg.Server.Origins.Production = tt.origins
+		err := cfg.Validate()
+		if (err == nil) != tt.ok {
+			t.Errorf("origins %v: Validate() = %v, want ok %t", tt.origins, err, tt.ok)
+		}
+		if err != nil && !strings.Contains(err.Error(), "server.origins.production") {
+			t.Errorf("origins %v: error %q does not name the setting", tt.origins, err)
+		}
+	}
+}
+
+func writeConfig(t *testing.T, body string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "engine.yaml")
+	if err := os.WriteFile(path, []byte(body), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadWithoutFileUsesDefaults(t *testing.T) {
+	cfg, err := config.Load("")
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if changes := config.Diff(config.Default(), cfg); len(changes) != 0 {
+		t.Errorf("Load(\"\") differs from Default(): %v", changes)
+	}
+}
+
+func TestLoadFile(t *testing.T) {
+	path := writeConfig(t, `
+server:
+  addr: ":9090"
+fairness:
+  base_delay: 5s
+market_types:
+  - name: maps
+    match: "map_*"
+    risk:
+      max_order_quantity: 100
+`)
+	cfg, err := config.Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.Server.Addr != ":9090" {
+		t.Errorf("server.addr = %q, want :9090", cfg.Server.Addr)
+	}
+	if cfg.Fairness.BaseDelay != 5*time.Second {
+		t.Errorf("fairness.base_delay = %v, want 5s", cfg.Fairness.BaseDelay)
+	}
+	if cfg.Fairness.MaxDelay != config.Default().Fairness.MaxDelay {
+		t.Errorf("fairness.max_delay = %v, want the default", cfg.Fairness.MaxDelay)
+	}
+	if len(cfg.MarketTypes) != 1 || cfg.MarketTypes[0].Risk.MaxOrderQuantity != 100 {
+		t.Errorf("market_types = %+v", cfg.MarketTypes)
+	}
+}
+
+func TestLoadRejects(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{"unknown key", "fairness:\n  base_dealy: 5s\n", "base_dealy"},
+		{"malformed value", "fairness:\n  base_delay: soon\n", "parse"},
+		{"invalid setting", "fairness:\n  base_delay: -1s\n", "fairness"},
+		{"bad log level", "logging:\n  level: loud\n", "logging.level"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := config.Load(writeConfig(t, tt.body))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Load() error = %v, want one mentioning %q", err, tt.want)
+			}
+		})
+	}
+
+	if _, err := config.Load(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Error("Load of a missing file succeeded")
+	}
+}
+
+func TestEnvOverridesFile(t *testing.T) {
+	path := writeConfig(t, `
+server:
+  addr: ":9090"
+fairness:
+  base_delay: 5s
+logging:
+  level: warn
+`)
+	t.Setenv("ENGINE_ADDR", ":7070")
+	t.Setenv("ENGINE_FAIRNESS_DELAY", "7s")
+	t.Setenv("LOG_LEVEL", "")
+
+	cfg, err := config.Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.Server.Addr != ":7070" {
+		t.Errorf("server.addr = %q, want the ENGINE_ADDR value", cfg.Server.Addr)
+	}
+	if cfg.Fairness.BaseDelay != 7*time.Second {
+		t.Errorf("fairness.base_delay = %v, want the ENGINE_FAIRNESS_DELAY value", cfg.Fairness.BaseDelay)
+	}
+	if cfg.Logging.Level != "warn" {
+		t.Errorf("logging.level = %q, want the file value when LOG_LEVEL is empty", cfg.Logging.Level)
+	}
+}
+
+func TestEnvOriginsFollowEnvironment(t *testing.T) {
+	t.Setenv("ENGINE_ENV", config.EnvProduction)
+	t.Setenv("ENGINE_ALLOWED_ORIGINS", "https://app.example.com, https://staging.example.com")
+
+	cfg, err := config.Load("")
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	want := []string{"https://app.example.com", "https://staging.example.com"}
+	if !slices.Equal(cfg.Server.Origins.Production, want) {
+		t.Errorf("production origins = %v, want %v", cfg.Server.Origins.Production, want)
+	}
+	if !slices.Equal(cfg.Server.Origins.Development, config.Default().Server.Origins.Development) {
+		t.Errorf("development origins changed to %v", cfg.Server.Origins.Development)
+	}
+}
+
+func TestEnvRejectsInvalidValue(t *testing.T) {
+	t.Setenv("ENGINE_DEFAULT_BALANCE", "lots")
+	_, err := config.Load("")
+	if err == nil || !strings.Contains(err.Error(), "ENGINE_DEFAULT_BALANCE") {
+		t.Errorf("Load() error = %v, want one naming ENGINE_DEFAULT_BALANCE", err)
+	}
+}
+
+func TestValidateReportsEveryProblem(t *testing.T) {
+	cfg := config.Default()
+	cfg.Server.Addr = ""
+	cfg.Snapshot.Keep = 0
+	cfg.MarketTypes = []config.MarketType{{Name: "maps", Match: "["}}
+
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatal("Validate() accepted an invalid config")
+	}
+	for _, want := range []string{"server.addr", "snapshot.keep", "market_types.maps.match"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Validate() error %q does not mention %s", err, want)
+		}
+	}
+	if err := config.Default().Validate(); err != nil {
+		t.Errorf("Default().Validate() = %v", err)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	old := config.Default()
+	next := config.Default()
+	next.Server.Addr = ":9090"
+	next.Fairness.BaseDelay = 5 * time.Second
+	next.Logging.Level = "debug"
+
+	changes := config.Diff(old, next)
+	want := map[string]bool{
+		"fairness.base_delay": true,
+		"logging.level":       true,
+		"server.addr":         false,
+	}
+	if len(changes) != len(want) {
+		t.Fatalf("Diff() = %v, want changes to %d settings", changes, len(want))
+	}
+	for i, c := range changes {
+		reloadable, ok := want[c.Path]
+		if !ok {
+			t.Errorf("unexpected change %+v", c)
+			continue
+		}
+		if c.Reloadable() != reloadable {
+			t.Errorf("%s: Reloadable() = %t, want %t", c.Path, c.Reloadable(), reloadable)
+		}
+		if i > 0 && changes[i-1].Path >= c.Path {
+			t.Errorf("changes not sorted by path: %v", changes)
+		}
+	}
+	if changes[0].Old != "3s" || changes[0].New != "5s" {
+		t.Errorf("fairness.base_delay change = %+v, want 3s -> 5s", changes[0])
+	}
+}
+
+func TestDiffMarketTypeOrder(t *testing.T) {
+	a := config.MarketType{Name: "maps", Match: "map_*"}
+	b := config.MarketType{Name: "rounds", Match: "round_*"}
+	old := config.Default()
+	old.MarketTypes = []config.MarketType{a, b}
+	next := config.Default()
+	next.MarketTypes = []config.MarketType{b, a}
+
+	changes := config.Diff(old, next)
+	if len(changes) != 1 || changes[0].Path != "market_types.order" || !changes[0].Reloadable() {
+		t.Errorf("Diff() = %v, want only a reloadable market_types.order change", changes)
+	}
+}
+
+func TestWithReloadable(t *testing.T) {
+	current := config.Default()
+	next := config.Default()
+	next.Server.Addr = ":9090"
+	next.Snapshot.Path = "elsewhere.json"
+	next.Fairness.BaseDelay = 5 * time.Second
+	next.Logging.Level = "debug"
+	next.Logging.Format = "text"
+
+	got := current.WithReloadable(next)
+	if got.Server.Addr != current.Server.Addr || got.Snapshot.Path != current.Snapshot.Path {
+		t.Errorf("restart-only settings changed: addr %q, snapshot %q", got.Server.Addr, got.Snapshot.Path)
+	}
+	if got.Logging.Format != current.Logging.Format {
+		t.Errorf("logging.format = %q, want it kept until restart", got.Logging.Format)
+	}
+	if got.Fairness.BaseDelay != 5*time.Second || got.Logging.Level != "debug" {
+		t.Errorf("reloadable settings not taken: base_delay %v, level %q", got.Fairness.BaseDelay, got.Logging.Level)
+	}
+}
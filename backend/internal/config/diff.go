@@ -0,0 +1,130 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Change is one setting that differs between two configurations. Path is the
+// dotted YAML key, with market types addressed by name.
+type Change struct {
+	Path string `json:"path"`
+	Old  string `json:"old,omitempty"`
+	New  string `json:"new,omitempty"`
+}
+
+// reloadable lists the settings a running engine can pick up without a
+// restart: limits, delays and the log level. Everything else is read once.
+var reloadable = []string{
+	"fairness.",
+	"risk.",
+	"exposure.",
+	"market_types",
+	"logging.level",
+}
+
+// Reloadable reports whether the change can be applied to a running engine.
+func (c Change) Reloadable() bool {
+	for _, prefix := range reloadable {
+		if strings.HasPrefix(c.Path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Diff lists the settings that differ between old and new, sorted by path.
+func Diff(old, new Config) []Change {
+	before, after := flatten(old), flatten(new)
+	var changes []Change
+	for path, v := range after {
+		if prev, ok := before[path]; !ok || prev != v {
+			changes = append(changes, Change{Path: path, Old: before[path], New: v})
+		}
+	}
+	for path, v := range before {
+		if _, ok := after[path]; !ok {
+			changes = append(changes, Change{Path: path, Old: v})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+	return changes
+}
+
+// WithReloadable returns c with the reloadable settings taken from next.
+func (c Config) WithReloadable(next Config) Config {
+	c.Fairness = next.Fairness
+	c.Risk = next.Risk
+	c.Exposure = next.Exposure
+	c.MarketTypes = next.MarketTypes
+	c.Logging.Level = next.Logging.Level
+	return c
+}
+
+// flatten renders cfg as YAML scalars keyed by dotted path, so durations and
+// limits compare in the same form operators write them.
+func flatten(cfg Config) map[string]string {
+	out := map[string]string{}
+	var node yaml.Node
+	if err := node.Encode(cfg); err != nil {
+		return out
+	}
+	flattenNode(&node, "", out)
+	// First match wins, so the order of market types is a setting too.
+	names := make([]string, len(cfg.MarketTypes))
+	for i, t := range cfg.MarketTypes {
+		names[i] = t.Name
+	}
+	out["market_types.order"] = "[" + strings.Join(names, ", ") + "]"
+	return out
+}
+
+func flattenNode(n *yaml.Node, prefix string, out map[string]string) {
+	switch n.Kind {
+	case yaml.DocumentNode:
+		for _, c := range n.Content {
+			flattenNode(c, prefix, out)
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			flattenNode(n.Content[i+1], join(prefix, n.Content[i].Value), out)
+		}
+	case yaml.SequenceNode:
+		scalars := make([]string, 0, len(n.Content))
+		for i, c := range n.Content {
+			if c.Kind == yaml.ScalarNode {
+				scalars = append(scalars, c.Value)
+				continue
+			}
+			flattenNode(c, join(prefix, elementKey(c, i)), out)
+		}
+		if len(scalars) > 0 || len(n.Content) == 0 {
+			out[prefix] = "[" + strings.Join(scalars, ", ") + "]"
+		}
+	case yaml.ScalarNode:
+		out[prefix] = n.Value
+	}
+}
+
+// elementKey names a list element by its "name" field when it has one, so
+// reordering or inserting market types does not show up as edits to others.
+func elementKey(n *yaml.Node, index int) string {
+	if n.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			if n.Content[i].Value == "name" && n.Content[i+1].Value != "" {
+				return n.Content[i+1].Value
+			}
+		}
+	}
+	return fmt.Sprint(index)
+}
+
+func join(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
@@ -0,0 +1,304 @@
+package engine
+
+import (
+	"errors"
+	"sort"
+	"time"
+)
+
+type MatchingMode string
+
+const (
+	MatchingContinuous MatchingMode = "continuous"
+	MatchingBatch      MatchingMode = "batch"
+)
+
+var ErrInvalidMatchingMode = errors.New("invalid matching mode")
+
+func ParseMatchingMode(s string) (MatchingMode, error) {
+	switch MatchingMode(s) {
+	case MatchingContinuous, MatchingBatch:
+		return MatchingMode(s), nil
+	case "":
+		return MatchingContinuous, nil
+	}
+	return "", ErrInvalidMatchingMode
+}
+
+// MatchingModeChange is the payload of a CommandSetMatchingMode.
+type MatchingModeChange struct {
+	Mode          MatchingMode
+	BatchInterval time.Duration
+}
+
+// auctionOrder is a resting order restated as a YES bid or YES ask. A NO sell
+// at P is a YES bid at 100-P and a NO buy at P is a YES ask at 100-P, which
+// folds the complementary books into a single YES auction.
+type auctionOrder struct {
+	entry    *bookEntry
+	yesPrice int64
+	fill     int64
+}
+
+func (ob *OrderBook) SetMatchingMode(mode MatchingMode) {
+	ob.mu.Lock()
+	defer ob.mu.Unlock()
+	ob.mode = mode
+}
+
+func (ob *OrderBook) MatchingMode() MatchingMode {
+	ob.mu.Lock()
+	defer ob.mu.Unlock()
+	if ob.mode == "" {
+		return MatchingContinuous
+	}
+	return ob.mode
+}
+
+// RunAuction clears every resting order at one uniform YES price: the price
+// that maximizes executed volume, then minimizes the bid/ask imbalance, then
+// sits closest to the last trade. Orders priced better than the clearing price
+// fill in full; the level where one side runs out is allocated pro rata, with
+// rounding remainders going to the earliest sequence numbers.
+//
+// A user's own bids and asks never trade with each other: crossing pairs are
+// resolved by self-trade prevention before the auction is priced, and those
+// preventions are returned alongside the matches.
+func (ob *OrderBook) RunAuction() ([]Match, []SelfTradePrevention) {
+	ob.mu.Lock()
+	defer ob.mu.Unlock()
+
+	if ob.TradingSuspended {
+		return nil, nil
+	}
+
+	var bids, asks []*auctionOrder
+	collect := func(side *BookSide, bid bool, invert bool) {
+		for p := int64(minPrice); p <= maxPrice; p++ {
+			for e := side.levels[p].head; e != nil; e = e.next {
+				yes := p
+				if invert {
+					yes = 100 - p
+				}
+				o := &auctionOrder{entry: e, yesPrice: yes}
+				if bid {
+					bids = append(bids, o)
+				} else {
+					asks = append(asks, o)
+				}
+			}
+		}
+	}
+	collectAll := func() {
+		bids, asks = nil, nil
+		collect(ob.YesBids, true, false)
+		collect(ob.NoAsks, true, true)
+		collect(ob.YesAsks, false, false)
+		collect(ob.NoBids, false, true)
+	}
+	collectAll()
+	prevented := ob.preventSelfTrades(bids, asks)
+	if len(prevented) > 0 {
+		collectAll()
+	}
+	if len(bids) == 0 || len(asks) == 0 {
+		return nil, prevented
+	}
+
+	var demandAt, supplyAt [maxPrice + 2]int64
+	for _, o := range bids {
+		demandAt[o.yesPrice] += o.entry.order.Quantity
+	}
+	for _, o := range asks {
+		supplyAt[o.yesPrice] += o.entry.order.Quantity
+	}
+	// Cumulative: demand(P) = bids at P or higher, supply(P) = asks at P or lower.
+	var demand, supply [maxPrice + 2]int64
+	for p := maxPrice; p >= minPrice; p-- {
+		demand[p] = demand[p+1] + demandAt[p]
+	}
+	for p := minPrice; p <= maxPrice; p++ {
+		supply[p] = supply[p-1] + supplyAt[p]
+	}
+
+	reference := ob.lastYesPrice
+	if reference == 0 {
+		reference = 50
+	}
+	var clearing, volume int64
+	var imbalance int64 = -1
+	for p := int64(minPrice); p <= maxPrice; p++ {
+		v := min(demand[p], supply[p])
+		if v == 0 {
+			continue
+		}
+		imb := demand[p] - supply[p]
+		if imb < 0 {
+			imb = -imb
+		}
+		better := v > volume ||
+			(v == volume && imb < imbalance) ||
+			(v == volume && imb == imbalance && abs64(p-reference) < abs64(clearing-reference))
+		if better {
+			clearing, volume, imbalance = p, v, imb
+		}
+	}
+	if volume == 0 {
+		return nil, prevented
+	}
+
+	sortByPriority(bids, true)
+	sortByPriority(asks, false)
+	allocateProRata(bids, volume, func(o *auctionOrder) bool { return o.yesPrice >= clearing })
+	allocateProRata(asks, volume, func(o *auctionOrder) bool { return o.yesPrice <= clearing })
+
+	// Pair the filled bids and asks to produce per-leg matches. Each leg is
+	// priced in its own outcome, so every pair sums to 100.
+	legPrice := func(o *Order) int64 {
+		if o.Outcome == Yes {
+			return clearing
+		}
+		return 100 - clearing
+	}
+	now := time.Now()
+	var matches []Match
+	bi, ai := 0, 0
+	for bi < len(bids) && ai < len(asks) {
+		bid, ask := bids[bi], asks[ai]
+		if bid.fill == 0 {
+			bi++
+			continue
+		}
+		if ask.fill == 0 {
+			ai++
+			continue
+		}
+		qty := min(bid.fill, ask.fill)
+		bidOrder, askOrder := bid.entry.order, ask.entry.order
+		m := newMatch(askOrder, bidOrder, legPrice(askOrder), legPrice(bidOrder), qty, bidOrder.Side == askOrder.Side)
+		m.Auction = true
+		m.Timestamp = now
+		matches = append(matches, m)
+		bid.fill -= qty
+		ask.fill -= qty
+		ob.fillResting(bid.entry, qty)
+		ob.fillResting(ask.entry, qty)
+	}
+
+	ob.lastYesPrice = clearing
+	return matches, prevented
+}
+
+// sortByPriority orders auction orders best price first, then by time.
+func sortByPriority(orders []*auctionOrder, bids bool) {
+	sort.Slice(orders, func(i, j int) bool {
+		if orders[i].yesPrice != orders[j].yesPrice {
+			if bids {
+				return orders[i].yesPrice > orders[j].yesPrice
+			}
+			return orders[i].yesPrice < orders[j].yesPrice
+		}
+		return orders[i].entry.order.Sequence < orders[j].entry.order.Sequence
+	})
+}
+
+// preventSelfTrades resolves every pair of a user's bids and asks that cross.
+// Once a user's bids all sit below their asks, no clearing price fills both
+// sides, so the auction can never pair an account with itself.
+func (ob *OrderBook) preventSelfTrades(bids, asks []*auctionOrder) []SelfTradePrevention {
+	userAsks := make(map[string][]*auctionOrder)
+	for _, o := range asks {
+		userAsks[o.entry.order.UserID] = append(userAsks[o.entry.order.UserID], o)
+	}
+	userBids := make(map[string][]*auctionOrder)
+	var users []string
+	for _, o := range bids {
+		user := o.entry.order.UserID
+		if _, ok := userAsks[user]; !ok {
+			continue
+		}
+		if _, seen := userBids[user]; !seen {
+			users = append(users, user)
+		}
+		userBids[user] = append(userBids[user], o)
+	}
+
+	gone := func(o *auctionOrder) bool {
+		_, live := ob.index[o.entry.order.ID]
+		return !live
+	}
+	var prevented []SelfTradePrevention
+	for _, user := range users {
+		ub, ua := userBids[user], userAsks[user]
+		sortByPriority(ub, true)
+		sortByPriority(ua, false)
+		for i, j := 0, 0; i < len(ub) && j < len(ua); {
+			bid, ask := ub[i], ua[j]
+			if bid.yesPrice < ask.yesPrice {
+				break
+			}
+			newer, older := bid, ask
+			if ask.entry.order.Sequence > bid.entry.order.Sequence {
+				newer, older = ask, bid
+			}
+			complementary := bid.entry.order.Side == ask.entry.order.Side
+			prevented = append(prevented, ob.preventAuctionSelfTrade(newer.entry, older.entry, complementary))
+			if gone(bid) {
+				i++
+			}
+			if gone(ask) {
+				j++
+			}
+		}
+	}
+	return prevented
+}
+
+// allocateProRata fills eligible orders (already in priority order) until
+// volume is exhausted. The level that cannot be filled in full shares what is
+// left in proportion to size.
+func allocateProRata(orders []*auctionOrder, volume int64, eligible func(*auctionOrder) bool) {
+	remaining := volume
+	for i := 0; i < len(orders) && remaining > 0; {
+		if !eligible(orders[i]) {
+			break
+		}
+		j := i
+		var levelQty int64
+		for j < len(orders) && orders[j].yesPrice == orders[i].yesPrice {
+			levelQty += orders[j].entry.order.Quantity
+			j++
+		}
+		level := orders[i:j]
+		if levelQty <= remaining {
+			for _, o := range level {
+				o.fill = o.entry.order.Quantity
+			}
+			remaining -= levelQty
+		} else {
+			allocated := int64(0)
+			for _, o := range level {
+				o.fill = remaining * o.entry.order.Quantity / levelQty
+				allocated += o.fill
+			}
+			for _, o := range level {
+				if allocated == remaining {
+					break
+				}
+				if o.fill < o.entry.order.Quantity {
+					o.fill++
+					allocated++
+				}
+			}
+			remaining = 0
+		}
+		i = j
+	}
+}
+
+func abs64(v int64) int64 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
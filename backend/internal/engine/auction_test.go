@@ -0,0 +1,151 @@
+package engine_test
+
+import (
+	"testing"
+	"time"
+
+	"cs2-prediction-engine/internal/engine"
+)
+
+func newOrder(id uint64, user string, side engine.Side, outcome engine.Outcome, price, quantity int64) *engine.Order {
+	return &engine.Order{
+		ID:        id,
+		UserID:    user,
+		MarketID:  "m1",
+		Side:      side,
+		Outcome:   outcome,
+		Price:     price,
+		Quantity:  quantity,
+		Timestamp: time.Now(),
+	}
+}
+
+// batchBook returns a batch-mode book holding orders in the given sequence.
+func batchBook(t *testing.T, orders ...*engine.Order) *engine.OrderBook {
+	t.Helper()
+	ob := engine.NewOrderBook()
+	ob.SetMatchingMode(engine.MatchingBatch)
+	for _, o := range orders {
+		if _, _, err := ob.ProcessOrder(o); err != nil {
+			t.Fatalf("order %d: %v", o.ID, err)
+		}
+	}
+	return ob
+}
+
+func matchedQuantity(matches []engine.Match) int64 {
+	var total int64
+	for _, m := range matches {
+		total += m.Quantity
+	}
+	return total
+}
+
+func TestAuctionSelfTradePrevention(t *testing.T) {
+	tests := []struct {
+		mode           engine.SelfTradeMode
+		matched        int64
+		makerReduced   int64
+		takerReduced   int64
+		makerCancelled bool
+		takerCancelled bool
+	}{
+		{mode: engine.STPCancelNewest, matched: 5, takerReduced: 3, takerCancelled: true},
+		{mode: engine.STPCancelOldest, matched: 0, makerReduced: 5, makerCancelled: true},
+		{mode: engine.STPCancelBoth, matched: 0, makerReduced: 5, takerReduced: 3, makerCancelled: true, takerCancelled: true},
+		{mode: engine.STPDecrement, matched: 2, makerReduced: 3, takerReduced: 3, takerCancelled: true},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.mode), func(t *testing.T) {
+			// alice's newer ask at 55 crosses her own bid at 60; bob's ask
+			// at 50 is the only counterparty she may trade with.
+			ask := newOrder(3, "alice", engine.Sell, engine.Yes, 55, 3)
+			ask.SelfTradePrevention = tt.mode
+			ob := batchBook(t,
+				newOrder(1, "alice", engine.Buy, engine.Yes, 60, 5),
+				newOrder(2, "bob", engine.Sell, engine.Yes, 50, 5),
+				ask,
+			)
+
+			matches, prevented := ob.RunAuction()
+			for _, m := range matches {
+				if m.Maker.UserID == m.Taker.UserID {
+					t.Errorf("self-trade: %+v", m)
+				}
+			}
+			if got := matchedQuantity(matches); got != tt.matched {
+				t.Errorf("matched %d, want %d", got, tt.matched)
+			}
+			if len(prevented) != 1 {
+				t.Fatalf("prevented = %+v, want one prevention", prevented)
+			}
+			p := prevented[0]
+			if p.MakerOrderID != 1 || p.TakerOrderID != 3 || p.Mode != tt.mode {
+				t.Errorf("prevention = %+v, want maker 1, taker 3, mode %s", p, tt.mode)
+			}
+			if p.MakerReduced != tt.makerReduced || p.TakerReduced != tt.takerReduced ||
+				p.MakerCancelled != tt.makerCancelled || p.TakerCancelled != tt.takerCancelled {
+				t.Errorf("prevention = %+v, want reduced %d/%d cancelled %t/%t", p,
+					tt.makerReduced, tt.takerReduced, tt.makerCancelled, tt.takerCancelled)
+			}
+		})
+	}
+}
+
+// A NO sell is a YES bid, so it must not clear against the same user's YES
+// ask even though the two orders sit on different books.
+func TestAuctionSelfTradePreventionAcrossOutcomes(t *testing.T) {
+	ob := batchBook(t,
+		newOrder(1, "alice", engine.Sell, engine.No, 40, 4), // YES bid at 60
+		newOrder(2, "alice", engine.Sell, engine.Yes, 55, 4),
+	)
+	matches, prevented := ob.RunAuction()
+	if len(matches) != 0 {
+		t.Errorf("matches = %+v, want none", matches)
+	}
+	if len(prevented) != 1 || !prevented[0].Complementary || !prevented[0].TakerCancelled {
+		t.Errorf("prevented = %+v, want one complementary cancel of the newer order", prevented)
+	}
+}
+
+// Bids better than the clearing price fill in full; the marginal level shares
+// what is left in proportion to size, with rounding going to the earliest.
+func TestAuctionProRataAtMarginalLevel(t *testing.T) {
+	ob := batchBook(t,
+		newOrder(1, "a", engine.Buy, engine.Yes, 60, 4),
+		newOrder(2, "b", engine.Buy, engine.Yes, 55, 6),
+		newOrder(3, "c", engine.Buy, engine.Yes, 55, 3),
+		newOrder(4, "d", engine.Buy, engine.Yes, 55, 1),
+		newOrder(5, "e", engine.Sell, engine.Yes, 50, 9),
+	)
+	matches, prevented := ob.RunAuction()
+	if len(prevented) != 0 {
+		t.Errorf("prevented = %+v, want none", prevented)
+	}
+
+	filled := map[uint64]int64{}
+	for _, m := range matches {
+		if m.Price != 50 {
+			t.Errorf("match at %d, want uniform clearing price 50", m.Price)
+		}
+		filled[m.Taker.OrderID] += m.Quantity
+		filled[m.Maker.OrderID] += m.Quantity
+	}
+	// 4 goes to the bid above the clearing level; the 5 left split 6:3:1 is
+	// 3, 1.5 and 0.5, floored to 3/1/0 with the remainder to order 2.
+	want := map[uint64]int64{1: 4, 2: 4, 3: 1, 5: 9}
+	for id, qty := range want {
+		if filled[id] != qty {
+			t.Errorf("order %d filled %d, want %d", id, filled[id], qty)
+		}
+	}
+	if filled[4] != 0 {
+		t.Errorf("order 4 filled %d, want 0", filled[4])
+	}
+
+	// Unfilled marginal quantity stays on the book for the next auction.
+	want55 := []engine.DepthLevel{{Price: 55, Quantity: 5}}
+	if got := ob.Depth(0).YesBids; !equalDepth(got, want55) {
+		t.Errorf("YES bids = %v, want %v", got, want55)
+	}
+}
@@ -2,53 +2,141 @@ package engine
 
 import (
 	"container/heap"
+	"math/rand/v2"
+	"sync"
 	"time"
 )
 
 type BufferedOrder struct {
 	Order         *Order
 	ExecutionTime time.Time
+	index         int
 }
 
-type BufferHeap []BufferedOrder
+type BufferHeap []*BufferedOrder
 
 func (h BufferHeap) Len() int           { return len(h) }
 func (h BufferHeap) Less(i, j int) bool { return h[i].ExecutionTime.Before(h[j].ExecutionTime) }
-func (h BufferHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h BufferHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
 
 func (h *BufferHeap) Push(x interface{}) {
-	*h = append(*h, x.(BufferedOrder))
+	item := x.(*BufferedOrder)
+	item.index = len(*h)
+	*h = append(*h, item)
 }
 
 func (h *BufferHeap) Pop() interface{} {
 	old := *h
 	n := len(old)
 	x := old[n-1]
+	old[n-1] = nil
 	*h = old[0 : n-1]
 	return x
 }
 
+// FairnessBuffer holds orders for a delay before they may match. Release times
+// are rounded up to a batch window so orders arriving within the same window
+// share one release instant, and each released batch is shuffled: arriving a
+// few milliseconds earlier than a competitor buys no priority.
 type FairnessBuffer struct {
+	mu     sync.Mutex
 	orders BufferHeap
+	byID   map[uint64]*BufferedOrder
 	delay  time.Duration
+	window time.Duration
+	wake   chan struct{}
+	stop   chan struct{}
+	done   chan struct{}
+	// stopped is set by Stop under mu; no order is held after it.
+	stopped bool
 }
 
+// DefaultBatchWindow is the release granularity used by NewFairnessBuffer.
+const DefaultBatchWindow = 50 * time.Millisecond
+
 func NewFairnessBuffer(delay time.Duration) *FairnessBuffer {
 	fb := &FairnessBuffer{
-		delay: delay,
+		byID:   make(map[uint64]*BufferedOrder),
+		delay:  delay,
+		window: DefaultBatchWindow,
+		wake:   make(chan struct{}, 1),
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
 	}
 	heap.Init(&fb.orders)
 	return fb
 }
 
-func (fb *FairnessBuffer) Add(order *Order) {
-	heap.Push(&fb.orders, BufferedOrder{
+func (fb *FairnessBuffer) releaseTime(now time.Time, delay time.Duration) time.Time {
+	at := now.Add(delay)
+	if fb.window <= 0 {
+		return at
+	}
+	if rounded := at.Truncate(fb.window); rounded.Before(at) {
+		return rounded.Add(fb.window)
+	}
+	return at
+}
+
+// Add holds order for the buffer's default delay. It reports false, holding
+// nothing, once the buffer has been stopped.
+func (fb *FairnessBuffer) Add(order *Order) bool {
+	return fb.AddWithDelay(order, fb.delay)
+}
+
+// AddWithDelay holds order for delay instead of the buffer default, so each
+// market can wait out its own feed lag. Like Add, it refuses orders after
+// Stop so none can slip in behind Drain.
+func (fb *FairnessBuffer) AddWithDelay(order *Order, delay time.Duration) bool {
+	fb.mu.Lock()
+	if fb.stopped {
+		fb.mu.Unlock()
+		return false
+	}
+	item := &BufferedOrder{
 		Order:         order,
-		ExecutionTime: time.Now().Add(fb.delay),
-	})
+		ExecutionTime: fb.releaseTime(time.Now(), delay),
+	}
+	heap.Push(&fb.orders, item)
+	fb.byID[order.ID] = item
+	isHead := fb.orders[0] == item
+	fb.mu.Unlock()
+
+	if isHead {
+		fb.signal()
+	}
+	return true
+}
+
+// Cancel withdraws an order that has not been released yet.
+func (fb *FairnessBuffer) Cancel(orderID uint64) (*Order, bool) {
+	fb.mu.Lock()
+	defer fb.mu.Unlock()
+	item, ok := fb.byID[orderID]
+	if !ok {
+		return nil, false
+	}
+	heap.Remove(&fb.orders, item.index)
+	delete(fb.byID, orderID)
+	return item.Order, true
+}
+
+func (fb *FairnessBuffer) Len() int {
+	fb.mu.Lock()
+	defer fb.mu.Unlock()
+	return fb.orders.Len()
 }
 
+// GetReadyOrders pops every order whose release time has passed, in shuffled
+// order.
 func (fb *FairnessBuffer) GetReadyOrders() []*Order {
+	fb.mu.Lock()
+	defer fb.mu.Unlock()
+
 	var ready []*Order
 	now := time.Now()
 
@@ -56,9 +144,105 @@ func (fb *FairnessBuffer) GetReadyOrders() []*Order {
 		if fb.orders[0].ExecutionTime.After(now) {
 			break
 		}
-		item := heap.Pop(&fb.orders).(BufferedOrder)
+		item := heap.Pop(&fb.orders).(*BufferedOrder)
+		delete(fb.byID, item.Order.ID)
 		ready = append(ready, item.Order)
 	}
 
+	rand.Shuffle(len(ready), func(i, j int) { ready[i], ready[j] = ready[j], ready[i] })
 	return ready
 }
+
+// Overdue reports how long the earliest held order is past its release time.
+// Anything beyond a batch window or two means Run is not keeping up.
+func (fb *FairnessBuffer) Overdue(now time.Time) time.Duration {
+	next, ok := fb.nextRelease()
+	if !ok || next.After(now) {
+		return 0
+	}
+	return now.Sub(next)
+}
+
+func (fb *FairnessBuffer) nextRelease() (time.Time, bool) {
+	fb.mu.Lock()
+	defer fb.mu.Unlock()
+	if fb.orders.Len() == 0 {
+		return time.Time{}, false
+	}
+	return fb.orders[0].ExecutionTime, true
+}
+
+func (fb *FairnessBuffer) signal() {
+	select {
+	case fb.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Run sleeps until the earliest release time, hands each ready batch to
+// release, and returns after Stop.
+func (fb *FairnessBuffer) Run(release func([]*Order)) {
+	defer close(fb.done)
+	timer := time.NewTimer(time.Hour)
+	timer.Stop()
+
+	for {
+		if next, ok := fb.nextRelease(); ok {
+			timer.Reset(time.Until(next))
+		}
+
+		select {
+		case <-timer.C:
+			if batch := fb.GetReadyOrders(); len(batch) > 0 {
+				release(batch)
+			}
+		case <-fb.wake:
+			// A new earliest order arrived; re-arm against it.
+			timer.Stop()
+		case <-fb.stop:
+			timer.Stop()
+			return
+		}
+	}
+}
+
+// Stop ends Run and refuses further orders. Orders still held remain in the
+// buffer for Drain.
+func (fb *FairnessBuffer) Stop() {
+	fb.mu.Lock()
+	fb.stopped = true
+	fb.mu.Unlock()
+	close(fb.stop)
+	<-fb.done
+}
+
+// Drain removes and returns every order still held.
+func (fb *FairnessBuffer) Drain() []*Order {
+	fb.mu.Lock()
+	defer fb.mu.Unlock()
+	out := make([]*Order, 0, fb.orders.Len())
+	for _, item := range fb.orders {
+		out = append(out, item.Order)
+	}
+	fb.orders = fb.orders[:0]
+	fb.byID = make(map[uint64]*BufferedOrder)
+	return out
+}
+
+// CancelWhere removes every held order for which cancel returns true and
+// returns them.
+func (fb *FairnessBuffer) CancelWhere(cancel func(*Order) bool) []*Order {
+	fb.mu.Lock()
+	defer fb.mu.Unlock()
+	var out []*Order
+	for _, item := range fb.byID {
+		if cancel(item.Order) {
+			out = append(out, item.Order)
+		}
+	}
+	for _, o := range out {
+		heap.Remove(&fb.orders, fb.byID[o.ID].index)
+		delete(fb.byID, o.ID)
+	}
+	return out
+}
@@ -0,0 +1,29 @@
+package engine_test
+
+import (
+	"testing"
+	"time"
+
+	"cs2-prediction-engine/internal/engine"
+)
+
+func TestFairnessBufferRefusesAddsAfterStop(t *testing.T) {
+	fb := engine.NewFairnessBuffer(time.Hour)
+	go fb.Run(func([]*engine.Order) {})
+
+	if !fb.Add(newOrder(1, "alice", engine.Buy, engine.Yes, 50, 1)) {
+		t.Fatal("Add refused before Stop")
+	}
+	fb.Stop()
+	if fb.Add(newOrder(2, "alice", engine.Buy, engine.Yes, 50, 1)) {
+		t.Error("Add accepted after Stop")
+	}
+
+	held := fb.Drain()
+	if len(held) != 1 || held[0].ID != 1 {
+		t.Errorf("Drain() = %v, want only order 1", held)
+	}
+	if fb.Len() != 0 {
+		t.Errorf("Len() = %d after Drain, want 0", fb.Len())
+	}
+}
@@ -0,0 +1,197 @@
+package engine
+
+import (
+	"sync"
+	"time"
+)
+
+// DelayPolicy bounds the per-market fairness delay. The delay tracks measured
+// feed lag plus a margin, never drops below Base, and is stretched by
+// ImpactExtra for ImpactWindow after a high-impact game event.
+type DelayPolicy struct {
+	Base         time.Duration
+	Max          time.Duration
+	LagMargin    time.Duration
+	ImpactExtra  time.Duration
+	ImpactWindow time.Duration
+	// LagSmoothing is the EWMA weight given to each new lag sample.
+	LagSmoothing float64
+}
+
+var DefaultDelayPolicy = DelayPolicy{
+	Base:         3 * time.Second,
+	Max:          15 * time.Second,
+	LagMargin:    time.Second,
+	ImpactExtra:  3 * time.Second,
+	ImpactWindow: 5 * time.Second,
+	LagSmoothing: 0.3,
+}
+
+// DelayOverride replaces the policy for markets matching Pattern.
+type DelayOverride struct {
+	Pattern string
+	Policy  DelayPolicy
+}
+
+// DelayState is the delay in force for one market and what it was derived from.
+type DelayState struct {
+	MarketID       string     `json:"market_id"`
+	DelayMs        int64      `json:"delay_ms"`
+	FeedLagMs      int64      `json:"feed_lag_ms"`
+	LagSamples     int        `json:"lag_samples"`
+	Stretched      bool       `json:"stretched"`
+	StretchReason  string     `json:"stretch_reason,omitempty"`
+	StretchedUntil *time.Time `json:"stretched_until,omitempty"`
+}
+
+type marketDelay struct {
+	lag           time.Duration
+	samples       int
+	impactUntil   time.Time
+	impactReason  string
+	lastPublished time.Duration
+}
+
+// DelayController derives each market's fairness delay from how far the feed
+// runs behind the game. Spectators at the venue see events before the feed
+// does, so orders must wait out at least that lag before they can match.
+type DelayController struct {
+	mu        sync.Mutex
+	policy    DelayPolicy
+	overrides []DelayOverride // First match wins
+	markets   map[string]*marketDelay
+}
+
+func NewDelayController(policy DelayPolicy) *DelayController {
+	return &DelayController{
+		policy:  policy,
+		markets: make(map[string]*marketDelay),
+	}
+}
+
+// SetPolicy replaces the default policy and the per-market-type overrides.
+// Lag estimates carry over.
+func (dc *DelayController) SetPolicy(policy DelayPolicy, overrides []DelayOverride) {
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+	dc.policy = policy
+	dc.overrides = append([]DelayOverride(nil), overrides...)
+}
+
+// Policy returns the policy in force for marketID.
+func (dc *DelayController) Policy(marketID string) DelayPolicy {
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+	return dc.policyLocked(marketID)
+}
+
+func (dc *DelayController) policyLocked(marketID string) DelayPolicy {
+	for _, o := range dc.overrides {
+		if MatchesMarket(o.Pattern, marketID) {
+			return o.Policy
+		}
+	}
+	return dc.policy
+}
+
+func (dc *DelayController) market(marketID string) *marketDelay {
+	md, ok := dc.markets[marketID]
+	if !ok {
+		md = &marketDelay{}
+		dc.markets[marketID] = md
+	}
+	return md
+}
+
+// ObserveFeed folds one lag sample into the market's estimate. Samples with
+// the feed ahead of the server clock are treated as zero lag.
+func (dc *DelayController) ObserveFeed(marketID string, feedTime, arrival time.Time) {
+	lag := arrival.Sub(feedTime)
+	if lag < 0 {
+		lag = 0
+	}
+
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+	md := dc.market(marketID)
+	if md.samples == 0 {
+		md.lag = lag
+	} else {
+		w := dc.policyLocked(marketID).LagSmoothing
+		md.lag = time.Duration(w*float64(lag) + (1-w)*float64(md.lag))
+	}
+	md.samples++
+}
+
+// MarkImpact stretches the market's delay for the policy's impact window.
+func (dc *DelayController) MarkImpact(marketID, reason string, at time.Time) {
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+	md := dc.market(marketID)
+	md.impactUntil = at.Add(dc.policyLocked(marketID).ImpactWindow)
+	md.impactReason = reason
+}
+
+// Delay returns the fairness delay currently in force for marketID.
+func (dc *DelayController) Delay(marketID string) time.Duration {
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+	return dc.delayLocked(marketID, dc.market(marketID), time.Now())
+}
+
+func (dc *DelayController) delayLocked(marketID string, md *marketDelay, now time.Time) time.Duration {
+	policy := dc.policyLocked(marketID)
+	delay := policy.Base
+	if md.samples > 0 {
+		delay = max(delay, md.lag+policy.LagMargin)
+	}
+	if now.Before(md.impactUntil) {
+		delay += policy.ImpactExtra
+	}
+	if policy.Max > 0 && delay > policy.Max {
+		delay = policy.Max
+	}
+	return delay
+}
+
+func (dc *DelayController) State(marketID string) DelayState {
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+	return dc.stateLocked(marketID, dc.market(marketID), time.Now())
+}
+
+func (dc *DelayController) stateLocked(marketID string, md *marketDelay, now time.Time) DelayState {
+	state := DelayState{
+		MarketID:   marketID,
+		DelayMs:    dc.delayLocked(marketID, md, now).Milliseconds(),
+		FeedLagMs:  md.lag.Milliseconds(),
+		LagSamples: md.samples,
+	}
+	if now.Before(md.impactUntil) {
+		state.Stretched = true
+		state.StretchReason = md.impactReason
+		until := md.impactUntil
+		state.StretchedUntil = &until
+	}
+	return state
+}
+
+// Changed reports the market's state when its delay has moved by at least
+// threshold since the last state it reported, so callers publish only
+// meaningful changes.
+func (dc *DelayController) Changed(marketID string, threshold time.Duration) (DelayState, bool) {
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+	md := dc.market(marketID)
+	now := time.Now()
+	delay := dc.delayLocked(marketID, md, now)
+	diff := delay - md.lastPublished
+	if diff < 0 {
+		diff = -diff
+	}
+	if md.lastPublished != 0 && diff < threshold {
+		return DelayState{}, false
+	}
+	md.lastPublished = delay
+	return dc.stateLocked(marketID, md, now), true
+}
@@ -0,0 +1,101 @@
+package engine
+
+import "fmt"
+
+// GameEventTrigger is a material change between two consecutive game states.
+type GameEventTrigger string
+
+const (
+	TriggerMapChanged   GameEventTrigger = "map_changed"
+	TriggerBombPlanted  GameEventTrigger = "bomb_planted"
+	TriggerRoundEnded   GameEventTrigger = "round_ended"
+	TriggerScoreChanged GameEventTrigger = "score_changed"
+)
+
+// CancelScope says which resting orders an event rule pulls from the book.
+type CancelScope string
+
+const (
+	CancelNone    CancelScope = "none"
+	CancelFlagged CancelScope = "flagged" // Only orders placed with cancel_on_event
+	CancelAll     CancelScope = "all"
+)
+
+// EventRules maps each trigger to the orders it cancels. Triggers without an
+// entry cancel nothing.
+type EventRules map[GameEventTrigger]CancelScope
+
+// DefaultEventRules clears the whole book on the events that reprice a market
+// instantly, and only opted-in orders on a bare score correction.
+var DefaultEventRules = EventRules{
+	TriggerMapChanged:   CancelAll,
+	TriggerBombPlanted:  CancelAll,
+	TriggerRoundEnded:   CancelAll,
+	TriggerScoreChanged: CancelFlagged,
+}
+
+func (rules EventRules) Validate() error {
+	for trigger, scope := range rules {
+		switch trigger {
+		case TriggerMapChanged, TriggerBombPlanted, TriggerRoundEnded, TriggerScoreChanged:
+		default:
+			return fmt.Errorf("unknown event trigger %q", trigger)
+		}
+		switch scope {
+		case CancelNone, CancelFlagged, CancelAll:
+		default:
+			return fmt.Errorf("invalid cancel scope %q for %s", scope, trigger)
+		}
+	}
+	return nil
+}
+
+// Resolve returns the widest scope among the fired triggers and the trigger
+// that asked for it. ok is false when none of them cancels anything.
+func (rules EventRules) Resolve(fired []GameEventTrigger) (GameEventTrigger, CancelScope, bool) {
+	var trigger GameEventTrigger
+	scope := CancelNone
+	for _, t := range fired {
+		s := rules[t]
+		if s == CancelAll || (s == CancelFlagged && scope == CancelNone) {
+			trigger, scope = t, s
+		}
+		if scope == CancelAll {
+			break
+		}
+	}
+	return trigger, scope, scope != CancelNone
+}
+
+// Applies reports whether an order falls within scope.
+func (scope CancelScope) Applies(order *Order) bool {
+	switch scope {
+	case CancelAll:
+		return true
+	case CancelFlagged:
+		return order.CancelOnEvent
+	}
+	return false
+}
+
+// DetectGameEvents lists the triggers between two game states, most material
+// first. A nil previous state is the first update and fires nothing.
+func DetectGameEvents(previous *MarketGameState, next MarketGameState) []GameEventTrigger {
+	if previous == nil {
+		return nil
+	}
+	var fired []GameEventTrigger
+	if next.Map != previous.Map {
+		fired = append(fired, TriggerMapChanged)
+	}
+	if next.BombPlanted && !previous.BombPlanted {
+		fired = append(fired, TriggerBombPlanted)
+	}
+	if next.Round != previous.Round {
+		fired = append(fired, TriggerRoundEnded)
+	}
+	if next.TerroristScore != previous.TerroristScore || next.CTScore != previous.CTScore {
+		fired = append(fired, TriggerScoreChanged)
+	}
+	return fired
+}
@@ -0,0 +1,243 @@
+package engine
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// HolderShare is one user's share of a market's largest payout liability.
+type HolderShare struct {
+	UserID   string  `json:"user_id"`
+	Payout   int64   `json:"payout"` // What the user is owed if their larger side wins
+	Fraction float64 `json:"fraction"`
+}
+
+// MarketExposure is what a market owes under each outcome. Liabilities are
+// gross payouts; house figures are the net result for the platform's own
+// liquidity accounts.
+type MarketExposure struct {
+	MarketID       string        `json:"market_id"`
+	YesShares      int64         `json:"yes_shares"`
+	NoShares       int64         `json:"no_shares"`
+	LiabilityIfYes int64         `json:"liability_if_yes"`
+	LiabilityIfNo  int64         `json:"liability_if_no"`
+	Collected      int64         `json:"collected"` // Cost paid in by every holder
+	OpenReserves   int64         `json:"open_reserves"`
+	HouseNetIfYes  int64         `json:"house_net_if_yes"`
+	HouseNetIfNo   int64         `json:"house_net_if_no"`
+	Holders        int           `json:"holders"`
+	TopHolders     []HolderShare `json:"top_holders"`
+	// TopConcentration is the fraction of the larger liability owed to
+	// TopHolders combined.
+	TopConcentration float64 `json:"top_concentration"`
+}
+
+// MaxLiability is the larger of the two outcome payouts.
+func (e MarketExposure) MaxLiability() int64 {
+	return max(e.LiabilityIfYes, e.LiabilityIfNo)
+}
+
+// HouseWorstLoss is what the liquidity accounts lose under their worse outcome.
+func (e MarketExposure) HouseWorstLoss() int64 {
+	return max(-e.HouseNetIfYes, -e.HouseNetIfNo, 0)
+}
+
+// BuildExposure aggregates open positions per market. reserves holds each
+// market's open-order reserves and isHouse marks liquidity accounts.
+func BuildExposure(positions []HolderPosition, reserves map[string]int64, isHouse func(userID string) bool, topN int) []MarketExposure {
+	byMarket := make(map[string]*MarketExposure)
+	holders := make(map[string][]HolderPosition)
+	get := func(marketID string) *MarketExposure {
+		e, ok := byMarket[marketID]
+		if !ok {
+			e = &MarketExposure{MarketID: marketID, TopHolders: []HolderShare{}}
+			byMarket[marketID] = e
+		}
+		return e
+	}
+
+	for _, p := range positions {
+		if p.YesShares == 0 && p.NoShares == 0 {
+			continue
+		}
+		e := get(p.MarketID)
+		e.YesShares += p.YesShares
+		e.NoShares += p.NoShares
+		e.LiabilityIfYes += p.YesShares * 100
+		e.LiabilityIfNo += p.NoShares * 100
+		cost := p.YesCost + p.NoCost
+		e.Collected += cost
+		if isHouse(p.UserID) {
+			e.HouseNetIfYes += p.YesShares*100 - cost
+			e.HouseNetIfNo += p.NoShares*100 - cost
+		}
+		holders[p.MarketID] = append(holders[p.MarketID], p)
+	}
+	for marketID, reserved := range reserves {
+		if reserved > 0 {
+			get(marketID).OpenReserves += reserved
+		}
+	}
+
+	out := make([]MarketExposure, 0, len(byMarket))
+	for marketID, e := range byMarket {
+		list := holders[marketID]
+		e.Holders = len(list)
+		sort.Slice(list, func(i, j int) bool {
+			pi := max(list[i].YesShares, list[i].NoShares)
+			pj := max(list[j].YesShares, list[j].NoShares)
+			if pi != pj {
+				return pi > pj
+			}
+			return list[i].UserID < list[j].UserID
+		})
+		total := e.MaxLiability()
+		var top int64
+		for i := 0; i < len(list) && i < topN; i++ {
+			payout := max(list[i].YesShares, list[i].NoShares) * 100
+			share := HolderShare{UserID: list[i].UserID, Payout: payout}
+			if total > 0 {
+				share.Fraction = float64(payout) / float64(total)
+			}
+			e.TopHolders = append(e.TopHolders, share)
+			top += payout
+		}
+		if total > 0 {
+			// Holders on opposite sides can together exceed either liability.
+			e.TopConcentration = float64(top) / float64(total)
+			if e.TopConcentration > 1 {
+				e.TopConcentration = 1
+			}
+		}
+		out = append(out, *e)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].MarketID < out[j].MarketID })
+	return out
+}
+
+// ExposureThresholds raise alerts on a market's exposure. Zero disables a check.
+type ExposureThresholds struct {
+	MaxLiability        int64   `json:"max_liability,omitempty"`
+	MaxHouseLoss        int64   `json:"max_house_loss,omitempty"`
+	MaxTopConcentration float64 `json:"max_top_concentration,omitempty"`
+	// MinHoldersForConcentration skips the concentration check on thin markets.
+	MinHoldersForConcentration int `json:"min_holders_for_concentration,omitempty"`
+}
+
+var DefaultExposureThresholds = ExposureThresholds{
+	MaxLiability:               10000000,
+	MaxHouseLoss:               2500000,
+	MaxTopConcentration:        0.6,
+	MinHoldersForConcentration: 5,
+}
+
+// ExposureAlert is a threshold crossing, raised or cleared.
+type ExposureAlert struct {
+	MarketID  string    `json:"market_id"`
+	Kind      string    `json:"kind"`
+	Raised    bool      `json:"raised"`
+	Value     float64   `json:"value"`
+	Threshold float64   `json:"threshold"`
+	Detail    string    `json:"detail"`
+	At        time.Time `json:"at"`
+}
+
+// ExposureMonitor tracks which alerts are raised so each crossing is
+// reported once, when it is raised and when it clears.
+type ExposureMonitor struct {
+	mu         sync.Mutex
+	thresholds ExposureThresholds
+	active     map[string]ExposureAlert // keyed by market and kind
+}
+
+func NewExposureMonitor(thresholds ExposureThresholds) *ExposureMonitor {
+	return &ExposureMonitor{
+		thresholds: thresholds,
+		active:     make(map[string]ExposureAlert),
+	}
+}
+
+func (m *ExposureMonitor) Thresholds() ExposureThresholds {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.thresholds
+}
+
+func (m *ExposureMonitor) SetThresholds(thresholds ExposureThresholds) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.thresholds = thresholds
+}
+
+// Evaluate checks a fresh report and returns alerts that were raised or
+// cleared since the previous call.
+func (m *ExposureMonitor) Evaluate(report []MarketExposure, now time.Time) []ExposureAlert {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	t := m.thresholds
+	breached := make(map[string]ExposureAlert)
+	for _, e := range report {
+		if t.MaxLiability > 0 && e.MaxLiability() > t.MaxLiability {
+			breached[e.MarketID+"/liability"] = ExposureAlert{
+				MarketID: e.MarketID, Kind: "liability",
+				Value: float64(e.MaxLiability()), Threshold: float64(t.MaxLiability),
+				Detail: fmt.Sprintf("payout liability %d (YES %d, NO %d)", e.MaxLiability(), e.LiabilityIfYes, e.LiabilityIfNo),
+			}
+		}
+		if t.MaxHouseLoss > 0 && e.HouseWorstLoss() > t.MaxHouseLoss {
+			breached[e.MarketID+"/house_loss"] = ExposureAlert{
+				MarketID: e.MarketID, Kind: "house_loss",
+				Value: float64(e.HouseWorstLoss()), Threshold: float64(t.MaxHouseLoss),
+				Detail: fmt.Sprintf("house accounts net %d if YES, %d if NO", e.HouseNetIfYes, e.HouseNetIfNo),
+			}
+		}
+		if t.MaxTopConcentration > 0 && e.Holders >= t.MinHoldersForConcentration && e.TopConcentration > t.MaxTopConcentration {
+			breached[e.MarketID+"/concentration"] = ExposureAlert{
+				MarketID: e.MarketID, Kind: "concentration",
+				Value: e.TopConcentration, Threshold: t.MaxTopConcentration,
+				Detail: fmt.Sprintf("top %d holders own %.0f%% of the payout", len(e.TopHolders), e.TopConcentration*100),
+			}
+		}
+	}
+
+	var changed []ExposureAlert
+	for key, alert := range breached {
+		if _, ok := m.active[key]; ok {
+			continue
+		}
+		alert.Raised = true
+		alert.At = now
+		m.active[key] = alert
+		changed = append(changed, alert)
+	}
+	for key, alert := range m.active {
+		if _, ok := breached[key]; ok {
+			continue
+		}
+		delete(m.active, key)
+		alert.Raised = false
+		alert.At = now
+		changed = append(changed, alert)
+	}
+	return changed
+}
+
+// Active returns the alerts currently raised.
+func (m *ExposureMonitor) Active() []ExposureAlert {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	out := make([]ExposureAlert, 0, len(m.active))
+	for _, alert := range m.active {
+		out = append(out, alert)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].MarketID != out[j].MarketID {
+			return out[i].MarketID < out[j].MarketID
+		}
+		return out[i].Kind < out[j].Kind
+	})
+	return out
+}
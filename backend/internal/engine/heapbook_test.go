@@ -0,0 +1,140 @@
+package engine_test
+
+import (
+	"container/heap"
+
+	"cs2-prediction-engine/internal/engine"
+)
+
+// heapBook is the container/heap order book the price-level book replaced,
+// kept as the baseline for the order book benchmarks.
+type heapBook struct {
+	yesBids bidHeap
+	yesAsks askHeap
+	noBids  bidHeap
+	noAsks  askHeap
+}
+
+type orderHeap []*engine.Order
+
+func (h orderHeap) Len() int      { return len(h) }
+func (h orderHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+
+func (h *orderHeap) Push(x interface{}) {
+	*h = append(*h, x.(*engine.Order))
+}
+
+func (h *orderHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	x := old[n-1]
+	*h = old[0 : n-1]
+	return x
+}
+
+type bidHeap struct{ orderHeap }
+
+func (h bidHeap) Less(i, j int) bool {
+	if h.orderHeap[i].Price == h.orderHeap[j].Price {
+		return h.orderHeap[i].Timestamp.Before(h.orderHeap[j].Timestamp)
+	}
+	return h.orderHeap[i].Price > h.orderHeap[j].Price
+}
+
+type askHeap struct{ orderHeap }
+
+func (h askHeap) Less(i, j int) bool {
+	if h.orderHeap[i].Price == h.orderHeap[j].Price {
+		return h.orderHeap[i].Timestamp.Before(h.orderHeap[j].Timestamp)
+	}
+	return h.orderHeap[i].Price < h.orderHeap[j].Price
+}
+
+func (hb *heapBook) ProcessOrder(incoming *engine.Order) {
+	if incoming.Outcome == engine.Yes {
+		if incoming.Side == engine.Buy {
+			hb.match(incoming, &hb.yesAsks, &hb.noBids, true)
+		} else {
+			hb.match(incoming, &hb.yesBids, &hb.noAsks, false)
+		}
+	} else {
+		if incoming.Side == engine.Buy {
+			hb.match(incoming, &hb.noAsks, &hb.yesBids, true)
+		} else {
+			hb.match(incoming, &hb.noBids, &hb.yesAsks, false)
+		}
+	}
+	if incoming.Quantity > 0 {
+		heap.Push(hb.heapFor(incoming), incoming)
+	}
+}
+
+func (hb *heapBook) heapFor(order *engine.Order) heap.Interface {
+	if order.Outcome == engine.Yes {
+		if order.Side == engine.Buy {
+			return &hb.yesBids
+		}
+		return &hb.yesAsks
+	}
+	if order.Side == engine.Buy {
+		return &hb.noBids
+	}
+	return &hb.noAsks
+}
+
+func peek(h heap.Interface) *engine.Order {
+	switch v := h.(type) {
+	case *bidHeap:
+		return v.orderHeap[0]
+	case *askHeap:
+		return v.orderHeap[0]
+	}
+	return nil
+}
+
+func (hb *heapBook) match(incoming *engine.Order, traditional heap.Interface, complementary heap.Interface, isBuy bool) {
+	for traditional.Len() > 0 && incoming.Quantity > 0 {
+		best := peek(traditional)
+		if (isBuy && incoming.Price < best.Price) || (!isBuy && incoming.Price > best.Price) {
+			break
+		}
+		qty := min(incoming.Quantity, best.Quantity)
+		incoming.Quantity -= qty
+		best.Quantity -= qty
+		if best.Quantity == 0 {
+			heap.Pop(traditional)
+		}
+	}
+	for complementary.Len() > 0 && incoming.Quantity > 0 {
+		best := peek(complementary)
+		if (isBuy && incoming.Price+best.Price < 100) || (!isBuy && incoming.Price+best.Price > 100) {
+			break
+		}
+		qty := min(incoming.Quantity, best.Quantity)
+		incoming.Quantity -= qty
+		best.Quantity -= qty
+		if best.Quantity == 0 {
+			heap.Pop(complementary)
+		}
+	}
+}
+
+// CancelOrder is the linear scan the heap layout forces on a cancel by ID.
+func (hb *heapBook) CancelOrder(orderID uint64) bool {
+	for _, h := range []heap.Interface{&hb.yesBids, &hb.yesAsks, &hb.noBids, &hb.noAsks} {
+		var orders orderHeap
+		switch v := h.(type) {
+		case *bidHeap:
+			orders = v.orderHeap
+		case *askHeap:
+			orders = v.orderHeap
+		}
+		for i, o := range orders {
+			if o.ID == orderID {
+				heap.Remove(h, i)
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -1,6 +1,9 @@
 package engine
 
-import "sync"
+import (
+	"sort"
+	"sync"
+)
 
 type Account struct {
 	UserID      string `json:"user_id"`
@@ -155,6 +158,83 @@ func (l *Ledger) GetPositions(userID string) []MarketPosition {
 	return out
 }
 
+// LedgerTotals sums every account's balances.
+type LedgerTotals struct {
+	Accounts    int   `json:"accounts"`
+	Available   int64 `json:"available"`
+	Reserved    int64 `json:"reserved"`
+	Spent       int64 `json:"spent"`
+	RealizedPnL int64 `json:"realized_pnl"`
+}
+
+func (l *Ledger) Totals() LedgerTotals {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	t := LedgerTotals{Accounts: len(l.accounts)}
+	for _, acc := range l.accounts {
+		t.Available += acc.Available
+		t.Reserved += acc.Reserved
+		t.Spent += acc.Spent
+		t.RealizedPnL += acc.RealizedPnL
+	}
+	return t
+}
+
+// LedgerSnapshot is every account and position, settled or not.
+type LedgerSnapshot struct {
+	Accounts  []Account        `json:"accounts"`
+	Positions []HolderPosition `json:"positions"`
+}
+
+func (l *Ledger) Snapshot() LedgerSnapshot {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	out := LedgerSnapshot{
+		Accounts:  make([]Account, 0, len(l.accounts)),
+		Positions: make([]HolderPosition, 0),
+	}
+	for _, acc := range l.accounts {
+		out.Accounts = append(out.Accounts, *acc)
+	}
+	for userID, userPositions := range l.positionsByUser {
+		for _, p := range userPositions {
+			out.Positions = append(out.Positions, HolderPosition{UserID: userID, MarketPosition: *p})
+		}
+	}
+	sort.Slice(out.Accounts, func(i, j int) bool { return out.Accounts[i].UserID < out.Accounts[j].UserID })
+	sort.Slice(out.Positions, func(i, j int) bool {
+		if out.Positions[i].UserID != out.Positions[j].UserID {
+			return out.Positions[i].UserID < out.Positions[j].UserID
+		}
+		return out.Positions[i].MarketID < out.Positions[j].MarketID
+	})
+	return out
+}
+
+// HolderPosition is one user's position in one market.
+type HolderPosition struct {
+	UserID string `json:"user_id"`
+	MarketPosition
+}
+
+// OpenPositions returns every unsettled position across all users.
+func (l *Ledger) OpenPositions() []HolderPosition {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	out := make([]HolderPosition, 0)
+	for userID, userPositions := range l.positionsByUser {
+		for _, p := range userPositions {
+			if p.Settled {
+				continue
+			}
+			out = append(out, HolderPosition{UserID: userID, MarketPosition: *p})
+		}
+	}
+	return out
+}
+
 func (l *Ledger) SettleMarket(marketID string, winner Outcome) []SettlementResult {
 	l.mu.Lock()
 	defer l.mu.Unlock()
@@ -201,3 +281,37 @@ func (l *Ledger) SettleMarket(marketID string, winner Outcome) []SettlementResul
 
 	return out
 }
+
+// VoidMarket unwinds every position in a market, returning each user's cost.
+func (l *Ledger) VoidMarket(marketID string) []SettlementResult {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	out := make([]SettlementResult, 0)
+	for userID, userPositions := range l.positionsByUser {
+		position, ok := userPositions[marketID]
+		if !ok || position.Settled {
+			continue
+		}
+
+		totalCost := position.YesCost + position.NoCost
+		if acc, ok := l.accounts[userID]; ok {
+			if totalCost > acc.Spent {
+				totalCost = acc.Spent
+			}
+			acc.Available += totalCost
+			acc.Spent -= totalCost
+		}
+
+		position.Settled = true
+		out = append(out, SettlementResult{
+			UserID:    userID,
+			MarketID:  marketID,
+			Winner:    "VOID",
+			Payout:    totalCost,
+			TotalCost: totalCost,
+		})
+	}
+
+	return out
+}
@@ -0,0 +1,79 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// MarketStatus is a market's position in its lifecycle:
+//
+//	scheduled → open ⇄ suspended
+//	open/suspended → closed → resolving → settled
+//	any non-terminal status → voided
+type MarketStatus string
+
+const (
+	StatusScheduled MarketStatus = "scheduled"
+	StatusOpen      MarketStatus = "open"
+	StatusSuspended MarketStatus = "suspended"
+	StatusClosed    MarketStatus = "closed"
+	StatusResolving MarketStatus = "resolving"
+	StatusSettled   MarketStatus = "settled"
+	StatusVoided    MarketStatus = "voided"
+)
+
+var (
+	ErrUnknownMarket     = errors.New("unknown market")
+	ErrIllegalTransition = errors.New("illegal market status transition")
+)
+
+var legalTransitions = map[MarketStatus][]MarketStatus{
+	StatusScheduled: {StatusOpen, StatusClosed, StatusVoided},
+	StatusOpen:      {StatusSuspended, StatusClosed, StatusVoided},
+	StatusSuspended: {StatusOpen, StatusClosed, StatusVoided},
+	// A closed market may reopen before it resolves, e.g. after a delayed start.
+	StatusClosed:    {StatusOpen, StatusResolving, StatusVoided},
+	StatusResolving: {StatusSettled, StatusVoided},
+}
+
+// CanTransition reports whether the lifecycle allows moving from s to next.
+func (s MarketStatus) CanTransition(next MarketStatus) bool {
+	for _, allowed := range legalTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// Terminal reports a status the market can never leave.
+func (s MarketStatus) Terminal() bool {
+	return s == StatusSettled || s == StatusVoided
+}
+
+// AcceptsOrders reports whether new orders may be placed.
+func (s MarketStatus) AcceptsOrders() bool {
+	return s == StatusOpen
+}
+
+func ParseMarketStatus(s string) (MarketStatus, error) {
+	status := MarketStatus(s)
+	if _, ok := legalTransitions[status]; ok || status.Terminal() {
+		return status, nil
+	}
+	return "", fmt.Errorf("unknown market status %q", s)
+}
+
+// LifecycleTransition records one status change. From is empty for the
+// status a market was created in.
+type LifecycleTransition struct {
+	From   MarketStatus `json:"from,omitempty"`
+	To     MarketStatus `json:"to"`
+	Reason string       `json:"reason"`
+	At     time.Time    `json:"at"`
+}
+
+// LifecycleHook runs after a transition is recorded, outside the registry
+// lock, on the goroutine that requested the transition.
+type LifecycleHook func(marketID string, t LifecycleTransition)
@@ -1,15 +1,27 @@
 package engine
 
-import "sync"
+import (
+	"fmt"
+	"sync"
+	"time"
+)
 
 type MarketMetadata struct {
-	MarketID   string           `json:"market_id"`
-	SeriesID   string           `json:"series_id"`
-	Title      string           `json:"title"`
-	Tournament string           `json:"tournament"`
-	Teams      []string         `json:"teams"`
-	StartTime  string           `json:"start_time,omitempty"`
-	Status     string           `json:"status"`
+	MarketID   string   `json:"market_id"`
+	SeriesID   string   `json:"series_id"`
+	Title      string   `json:"title"`
+	Tournament string   `json:"tournament"`
+	Teams      []string `json:"teams"`
+	StartTime  string   `json:"start_time,omitempty"`
+
+	Status    MarketStatus          `json:"status"`
+	Phase     TradingPhase          `json:"phase,omitempty"`
+	Lifecycle []LifecycleTransition `json:"lifecycle"`
+
+	MatchingMode    MatchingMode `json:"matching_mode"`
+	BatchIntervalMs int64        `json:"batch_interval_ms,omitempty"`
+	CancelRules     EventRules   `json:"cancel_rules,omitempty"` // Nil means DefaultEventRules
+
 	GameState  *MarketGameState `json:"game_state,omitempty"`
 	Winner     string           `json:"winner,omitempty"`
 	SettledAt  string           `json:"settled_at,omitempty"`
@@ -30,28 +42,74 @@ type MarketGameState struct {
 type MarketRegistry struct {
 	mu      sync.RWMutex
 	markets map[string]MarketMetadata
+
+	hooksMu sync.RWMutex
+	onEnter map[MarketStatus][]LifecycleHook
+	onExit  map[MarketStatus][]LifecycleHook
 }
 
 func NewMarketRegistry() *MarketRegistry {
 	return &MarketRegistry{
 		markets: make(map[string]MarketMetadata),
+		onEnter: make(map[MarketStatus][]LifecycleHook),
+		onExit:  make(map[MarketStatus][]LifecycleHook),
+	}
+}
+
+// OnEnter registers a hook run whenever a market enters status, including the
+// status it is created in.
+func (mr *MarketRegistry) OnEnter(status MarketStatus, hook LifecycleHook) {
+	mr.hooksMu.Lock()
+	defer mr.hooksMu.Unlock()
+	mr.onEnter[status] = append(mr.onEnter[status], hook)
+}
+
+// OnExit registers a hook run whenever a market leaves status.
+func (mr *MarketRegistry) OnExit(status MarketStatus, hook LifecycleHook) {
+	mr.hooksMu.Lock()
+	defer mr.hooksMu.Unlock()
+	mr.onExit[status] = append(mr.onExit[status], hook)
+}
+
+func (mr *MarketRegistry) runHooks(marketID string, t LifecycleTransition) {
+	mr.hooksMu.RLock()
+	exit := mr.onExit[t.From]
+	enter := mr.onEnter[t.To]
+	mr.hooksMu.RUnlock()
+
+	if t.From != "" {
+		for _, hook := range exit {
+			hook(marketID, t)
+		}
+	}
+	for _, hook := range enter {
+		hook(marketID, t)
 	}
 }
 
+// UpsertMarket registers a market, or refreshes its descriptive fields if it
+// already exists. New markets start in meta.Status, defaulting to scheduled.
 func (mr *MarketRegistry) UpsertMarket(meta MarketMetadata) {
 	mr.mu.Lock()
-	defer mr.mu.Unlock()
 
 	existing, ok := mr.markets[meta.MarketID]
 	if !ok {
+		if meta.Status == "" {
+			meta.Status = StatusScheduled
+		}
+		created := LifecycleTransition{To: meta.Status, Reason: "market_created", At: time.Now()}
+		meta.Lifecycle = []LifecycleTransition{created}
 		mr.markets[meta.MarketID] = meta
+		mr.mu.Unlock()
+		mr.runHooks(meta.MarketID, created)
 		return
 	}
+	defer mr.mu.Unlock()
 
 	// Preserve lifecycle-critical fields when rediscovery sends market_created again.
-	if existing.Status != "" {
-		meta.Status = existing.Status
-	}
+	meta.Status = existing.Status
+	meta.Phase = existing.Phase
+	meta.Lifecycle = existing.Lifecycle
 	if existing.GameState != nil {
 		meta.GameState = existing.GameState
 	}
@@ -64,11 +122,74 @@ func (mr *MarketRegistry) UpsertMarket(meta MarketMetadata) {
 	if existing.FinalScore != "" {
 		meta.FinalScore = existing.FinalScore
 	}
+	if meta.CancelRules == nil {
+		meta.CancelRules = existing.CancelRules
+	}
+	if existing.MatchingMode != "" {
+		meta.MatchingMode = existing.MatchingMode
+		meta.BatchIntervalMs = existing.BatchIntervalMs
+	}
 
 	mr.markets[meta.MarketID] = meta
 }
 
-func (mr *MarketRegistry) UpdateMarketStatus(marketID string, status string) bool {
+// Transition moves a market to status if the lifecycle allows it, records
+// the change, and runs the exit and enter hooks.
+func (mr *MarketRegistry) Transition(marketID string, status MarketStatus, reason string) (LifecycleTransition, error) {
+	mr.mu.Lock()
+	meta, ok := mr.markets[marketID]
+	if !ok {
+		mr.mu.Unlock()
+		return LifecycleTransition{}, fmt.Errorf("%w: %s", ErrUnknownMarket, marketID)
+	}
+	if !meta.Status.CanTransition(status) {
+		mr.mu.Unlock()
+		return LifecycleTransition{}, fmt.Errorf("%w: %s -> %s on %s", ErrIllegalTransition, meta.Status, status, marketID)
+	}
+	t := LifecycleTransition{From: meta.Status, To: status, Reason: reason, At: time.Now()}
+	meta.Status = status
+	meta.Lifecycle = append(meta.Lifecycle, t)
+	mr.markets[marketID] = meta
+	mr.mu.Unlock()
+
+	mr.runHooks(marketID, t)
+	return t, nil
+}
+
+// MarketMetadataPatch carries operator edits to a market's descriptive
+// fields. Nil fields are left unchanged.
+type MarketMetadataPatch struct {
+	Title      *string   `json:"title,omitempty"`
+	Tournament *string   `json:"tournament,omitempty"`
+	Teams      *[]string `json:"teams,omitempty"`
+	StartTime  *string   `json:"start_time,omitempty"`
+}
+
+func (mr *MarketRegistry) UpdateMetadata(marketID string, patch MarketMetadataPatch) (MarketMetadata, bool) {
+	mr.mu.Lock()
+	defer mr.mu.Unlock()
+
+	meta, ok := mr.markets[marketID]
+	if !ok {
+		return MarketMetadata{}, false
+	}
+	if patch.Title != nil {
+		meta.Title = *patch.Title
+	}
+	if patch.Tournament != nil {
+		meta.Tournament = *patch.Tournament
+	}
+	if patch.Teams != nil {
+		meta.Teams = *patch.Teams
+	}
+	if patch.StartTime != nil {
+		meta.StartTime = *patch.StartTime
+	}
+	mr.markets[marketID] = meta
+	return meta, true
+}
+
+func (mr *MarketRegistry) UpdateTradingPhase(marketID string, phase TradingPhase) bool {
 	mr.mu.Lock()
 	defer mr.mu.Unlock()
 
@@ -76,7 +197,21 @@ func (mr *MarketRegistry) UpdateMarketStatus(marketID string, status string) boo
 	if !ok {
 		return false
 	}
-	meta.Status = status
+	meta.Phase = phase
+	mr.markets[marketID] = meta
+	return true
+}
+
+func (mr *MarketRegistry) UpdateMatchingMode(marketID string, mode MatchingMode, batchInterval time.Duration) bool {
+	mr.mu.Lock()
+	defer mr.mu.Unlock()
+
+	meta, ok := mr.markets[marketID]
+	if !ok {
+		return false
+	}
+	meta.MatchingMode = mode
+	meta.BatchIntervalMs = batchInterval.Milliseconds()
 	mr.markets[marketID] = meta
 	return true
 }
@@ -113,6 +248,7 @@ func (mr *MarketRegistry) GetMarket(marketID string) (MarketMetadata, bool) {
 	mr.mu.RLock()
 	defer mr.mu.RUnlock()
 	meta, ok := mr.markets[marketID]
+	meta.Lifecycle = append([]LifecycleTransition(nil), meta.Lifecycle...)
 	return meta, ok
 }
 
@@ -122,6 +258,7 @@ func (mr *MarketRegistry) ListMarkets() []MarketMetadata {
 
 	out := make([]MarketMetadata, 0, len(mr.markets))
 	for _, meta := range mr.markets {
+		meta.Lifecycle = append([]LifecycleTransition(nil), meta.Lifecycle...)
 		out = append(out, meta)
 	}
 	return out
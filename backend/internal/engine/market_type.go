@@ -0,0 +1,10 @@
+package engine
+
+import "path"
+
+// MatchesMarket reports whether a market-type pattern such as
+// "series_*_winner" covers marketID. Patterns use path.Match syntax.
+func MatchesMarket(pattern, marketID string) bool {
+	ok, err := path.Match(pattern, marketID)
+	return err == nil && ok
+}
@@ -0,0 +1,84 @@
+package engine
+
+import (
+	"sync"
+	"time"
+)
+
+type OrderStatus string
+
+const (
+	OrderOpen      OrderStatus = "open"
+	OrderFilled    OrderStatus = "filled"
+	OrderCancelled OrderStatus = "cancelled"
+	OrderRejected  OrderStatus = "rejected"
+)
+
+func ParseOrderStatus(s string) (OrderStatus, bool) {
+	switch OrderStatus(s) {
+	case OrderOpen, OrderFilled, OrderCancelled, OrderRejected:
+		return OrderStatus(s), true
+	}
+	return "", false
+}
+
+// OrderSummary is the externally visible lifecycle of a single order.
+type OrderSummary struct {
+	OrderID           uint64      `json:"order_id"`
+	ClientOrderID     string      `json:"client_order_id,omitempty"`
+	MarketID          string      `json:"market_id"`
+	UserID            string      `json:"user_id"`
+	Side              Side        `json:"side"`
+	Outcome           Outcome     `json:"outcome"`
+	Price             int64       `json:"price"`
+	Quantity          int64       `json:"quantity"`
+	FilledQuantity    int64       `json:"filled_quantity"`
+	AvgFillPrice      float64     `json:"avg_fill_price"`
+	ReservedRemaining int64       `json:"reserved_remaining"`
+	Status            OrderStatus `json:"status"`
+	Reason            string      `json:"reason,omitempty"`
+	SubmittedAt       time.Time   `json:"submitted_at"`
+	UpdatedAt         time.Time   `json:"updated_at"`
+}
+
+// OrderHistoryStore holds closed orders once they leave the live order set.
+type OrderHistoryStore interface {
+	Append(summary OrderSummary)
+	ListByUser(userID string) []OrderSummary
+}
+
+// MemoryOrderHistory keeps the most recent closed orders per user, dropping the
+// oldest once a user exceeds the retention limit.
+type MemoryOrderHistory struct {
+	mu      sync.RWMutex
+	perUser int
+	byUser  map[string][]OrderSummary
+}
+
+func NewMemoryOrderHistory(perUser int) *MemoryOrderHistory {
+	return &MemoryOrderHistory{
+		perUser: perUser,
+		byUser:  make(map[string][]OrderSummary),
+	}
+}
+
+func (h *MemoryOrderHistory) Append(summary OrderSummary) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	orders := append(h.byUser[summary.UserID], summary)
+	if h.perUser > 0 && len(orders) > h.perUser {
+		orders = append([]OrderSummary(nil), orders[len(orders)-h.perUser:]...)
+	}
+	h.byUser[summary.UserID] = orders
+}
+
+func (h *MemoryOrderHistory) ListByUser(userID string) []OrderSummary {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	orders := h.byUser[userID]
+	out := make([]OrderSummary, len(orders))
+	copy(out, orders)
+	return out
+}
@@ -1,54 +1,13 @@
 package engine
 
 import (
-	"container/heap"
+	"errors"
+	"sort"
 	"sync"
 	"time"
 )
 
-// OrderHeap implements heap.Interface and holds Orders.
-type OrderHeap []*Order
-
-func (h OrderHeap) Len() int { return len(h) }
-func (h OrderHeap) Less(i, j int) bool {
-	// Default is min-heap. For Price-Time priority:
-	// Max-Price for Bids, Min-Price for Asks.
-	// This will be wrapped in specific Min/Max heap logic.
-	return h[i].Price < h[j].Price
-}
-func (h OrderHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
-
-func (h *OrderHeap) Push(x interface{}) {
-	*h = append(*h, x.(*Order))
-}
-
-func (h *OrderHeap) Pop() interface{} {
-	old := *h
-	n := len(old)
-	x := old[n-1]
-	*h = old[0 : n-1]
-	return x
-}
-
-// Bids are Max-Heaps (highest price at root)
-type BidHeap struct{ OrderHeap }
-
-func (h BidHeap) Less(i, j int) bool {
-	if h.OrderHeap[i].Price == h.OrderHeap[j].Price {
-		return h.OrderHeap[i].Timestamp.Before(h.OrderHeap[j].Timestamp)
-	}
-	return h.OrderHeap[i].Price > h.OrderHeap[j].Price
-}
-
-// Asks are Min-Heaps (lowest price at root)
-type AskHeap struct{ OrderHeap }
-
-func (h AskHeap) Less(i, j int) bool {
-	if h.OrderHeap[i].Price == h.OrderHeap[j].Price {
-		return h.OrderHeap[i].Timestamp.Before(h.OrderHeap[j].Timestamp)
-	}
-	return h.OrderHeap[i].Price < h.OrderHeap[j].Price
-}
+var ErrTradingSuspended = errors.New("trading suspended")
 
 // OrderBook manages the bids and asks for YES and NO outcomes
 type OrderBook struct {
@@ -58,12 +17,25 @@ type OrderBook struct {
 	TradingSuspended bool
 
 	// YES Outcome book
-	YesBids BidHeap
-	YesAsks AskHeap
+	YesBids *BookSide
+	YesAsks *BookSide
 
 	// NO Outcome book
-	NoBids BidHeap
-	NoAsks AskHeap
+	NoBids *BookSide
+	NoAsks *BookSide
+
+	// index locates every resting order for constant-time cancels.
+	index map[uint64]*bookEntry
+	// nextSequence stamps orders on entry; it replaces timestamps for time
+	// priority because wall-clock values can collide.
+	nextSequence uint64
+
+	// lastYesPrice is the most recent execution expressed in YES terms (0 = no trades yet).
+	lastYesPrice int64
+
+	// mode selects continuous matching or frequent batch auctions. In batch
+	// mode ProcessOrder only rests orders and RunAuction clears the book.
+	mode MatchingMode
 }
 
 // MarketManager routes orders to their specific market liquidity pools
@@ -100,36 +72,57 @@ func (mm *MarketManager) GetOrderBook(marketID string) *OrderBook {
 
 // NewOrderBook initializes a new order book
 func NewOrderBook() *OrderBook {
-	ob := &OrderBook{}
-	heap.Init(&ob.YesBids)
-	heap.Init(&ob.YesAsks)
-	heap.Init(&ob.NoBids)
-	heap.Init(&ob.NoAsks)
-	return ob
+	return &OrderBook{
+		YesBids: newBookSide(true),
+		YesAsks: newBookSide(false),
+		NoBids:  newBookSide(true),
+		NoAsks:  newBookSide(false),
+		index:   make(map[uint64]*bookEntry),
+	}
 }
 
-func (ob *OrderBook) ProcessOrder(incoming *Order) []Match {
+// ProcessOrder matches an incoming order and rests any remainder. It also
+// returns the self-trades that were prevented along the way.
+func (ob *OrderBook) ProcessOrder(incoming *Order) ([]Match, []SelfTradePrevention, error) {
 	ob.mu.Lock()
 	defer ob.mu.Unlock()
 
 	if ob.TradingSuspended {
-		return nil
+		return nil, nil, ErrTradingSuspended
+	}
+
+	ob.nextSequence++
+	incoming.Sequence = ob.nextSequence
+
+	if ob.mode == MatchingBatch {
+		ob.addToBook(incoming)
+		return nil, nil, nil
 	}
 
 	var matches []Match
+	var prevented []SelfTradePrevention
 
 	if incoming.Outcome == Yes {
 		if incoming.Side == Buy {
-			matches = ob.match(incoming, &ob.YesAsks, &ob.NoBids, true)
+			matches, prevented = ob.match(incoming, ob.YesAsks, ob.NoBids, true)
 		} else {
-			matches = ob.match(incoming, &ob.YesBids, &ob.NoAsks, false)
+			matches, prevented = ob.match(incoming, ob.YesBids, ob.NoAsks, false)
 		}
 	} else {
 		if incoming.Side == Buy {
-			matches = ob.match(incoming, &ob.NoAsks, &ob.YesBids, true)
+			matches, prevented = ob.match(incoming, ob.NoAsks, ob.YesBids, true)
 		} else {
-			matches = ob.match(incoming, &ob.NoBids, &ob.YesAsks, false)
+			matches, prevented = ob.match(incoming, ob.NoBids, ob.YesAsks, false)
+		}
+	}
+
+	// Both matching paths price fills in the incoming order's outcome.
+	if len(matches) > 0 {
+		last := matches[len(matches)-1].Price
+		if incoming.Outcome == No {
+			last = 100 - last
 		}
+		ob.lastYesPrice = last
 	}
 
 	// If order is not fully filled, add to book
@@ -137,7 +130,84 @@ func (ob *OrderBook) ProcessOrder(incoming *Order) []Match {
 		ob.addToBook(incoming)
 	}
 
-	return matches
+	return matches, prevented, nil
+}
+
+// Quote returns the top of book in YES terms. NO orders contribute implied
+// YES liquidity: a NO bid at P is a YES ask at 100-P and vice-versa.
+func (ob *OrderBook) Quote() Quote {
+	ob.mu.Lock()
+	defer ob.mu.Unlock()
+
+	var q Quote
+	q.BestBid = ob.YesBids.BestPrice()
+	if best := ob.NoAsks.BestPrice(); best > 0 && 100-best > q.BestBid {
+		q.BestBid = 100 - best
+	}
+	q.BestAsk = ob.YesAsks.BestPrice()
+	if best := ob.NoBids.BestPrice(); best > 0 && (q.BestAsk == 0 || 100-best < q.BestAsk) {
+		q.BestAsk = 100 - best
+	}
+	q.LastPrice = ob.lastYesPrice
+	return q
+}
+
+// BookDepth is an aggregated view of every side of the book.
+type BookDepth struct {
+	YesBids []DepthLevel `json:"yes_bids"`
+	YesAsks []DepthLevel `json:"yes_asks"`
+	NoBids  []DepthLevel `json:"no_bids"`
+	NoAsks  []DepthLevel `json:"no_asks"`
+}
+
+// Depth returns up to levels price levels per side; levels <= 0 returns all.
+func (ob *OrderBook) Depth(levels int) BookDepth {
+	ob.mu.Lock()
+	defer ob.mu.Unlock()
+	return BookDepth{
+		YesBids: ob.YesBids.Depth(levels),
+		YesAsks: ob.YesAsks.Depth(levels),
+		NoBids:  ob.NoBids.Depth(levels),
+		NoAsks:  ob.NoAsks.Depth(levels),
+	}
+}
+
+// CancelOrder removes a resting order by ID in constant time and returns it
+// with its unfilled quantity.
+func (ob *OrderBook) CancelOrder(orderID uint64) (Order, bool) {
+	ob.mu.Lock()
+	defer ob.mu.Unlock()
+
+	e, ok := ob.index[orderID]
+	if !ok {
+		return Order{}, false
+	}
+	ob.unlink(e)
+	return *e.order, true
+}
+
+// CancelWhere removes every resting order for which cancel returns true and
+// returns them in time priority.
+func (ob *OrderBook) CancelWhere(cancel func(*Order) bool) []Order {
+	ob.mu.Lock()
+	defer ob.mu.Unlock()
+
+	var out []Order
+	for _, e := range ob.index {
+		if cancel(e.order) {
+			out = append(out, *e.order)
+		}
+	}
+	for _, o := range out {
+		ob.unlink(ob.index[o.ID])
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Sequence < out[j].Sequence })
+	return out
+}
+
+func (ob *OrderBook) unlink(e *bookEntry) {
+	e.side.remove(e)
+	delete(ob.index, e.order.ID)
 }
 
 func (ob *OrderBook) SuspendTrading() {
@@ -158,12 +228,14 @@ func (ob *OrderBook) IsTradingSuspended() bool {
 	return ob.TradingSuspended
 }
 
-func (ob *OrderBook) match(incoming *Order, traditional heap.Interface, complementary heap.Interface, isBuy bool) []Match {
+func (ob *OrderBook) match(incoming *Order, traditional *BookSide, complementary *BookSide, isBuy bool) ([]Match, []SelfTradePrevention) {
 	var matches []Match
+	var prevented []SelfTradePrevention
 
 	// 1. Match against Traditional side
 	for traditional.Len() > 0 && incoming.Quantity > 0 {
-		bestOther := traditional.(interface{ Peek() *Order }).Peek()
+		entry := traditional.peekEntry()
+		bestOther := entry.order
 
 		canMatch := false
 		if isBuy {
@@ -175,74 +247,111 @@ func (ob *OrderBook) match(incoming *Order, traditional heap.Interface, compleme
 		if !canMatch {
 			break
 		}
+		if bestOther.UserID == incoming.UserID {
+			p, stop := ob.preventSelfTrade(incoming, entry, false)
+			prevented = append(prevented, p)
+			if stop {
+				break
+			}
+			continue
+		}
 
 		matchQty := min(incoming.Quantity, bestOther.Quantity)
-		matches = append(matches, Match{
-			MakerOrderID: bestOther.ID,
-			TakerOrderID: incoming.ID,
-			Price:        bestOther.Price,
-			Quantity:     matchQty,
-			Timestamp:    time.Now(),
-		})
+		matches = append(matches, newMatch(bestOther, incoming, bestOther.Price, bestOther.Price, matchQty, false))
 
 		incoming.Quantity -= matchQty
-		bestOther.Quantity -= matchQty
-
-		if bestOther.Quantity == 0 {
-			heap.Pop(traditional)
-		}
+		ob.fillResting(entry, matchQty)
 	}
 
 	// 2. Match against Complementary side (Yes + No = 100)
 	for complementary.Len() > 0 && incoming.Quantity > 0 {
-		bestOther := complementary.(interface{ Peek() *Order }).Peek()
+		entry := complementary.peekEntry()
+		bestOther := entry.order
 
-		// For Complementary: if I buy YES at 60, I can match someone buying NO at 40 (or less)
-		// because 60 + 40 = 100. Actually, if they buy NO at 30, it's even better for me.
-		// Rule: incoming.Price + bestOther.Price >= 100
-		if incoming.Price+bestOther.Price < 100 {
+		// Two buys of opposite outcomes mint a YES+NO pair worth 100, so they
+		// cross when the bids sum to at least 100. Two sells are each long the
+		// opposite outcome at 100-P, so they cross when the asks sum to at most 100.
+		if isBuy && incoming.Price+bestOther.Price < 100 {
+			break
+		}
+		if !isBuy && incoming.Price+bestOther.Price > 100 {
 			break
 		}
+		if bestOther.UserID == incoming.UserID {
+			p, stop := ob.preventSelfTrade(incoming, entry, true)
+			prevented = append(prevented, p)
+			if stop {
+				break
+			}
+			continue
+		}
 
+		// Price improvement rule: the maker keeps its limit and the taker
+		// receives the whole surplus, mirroring traditional fills at the
+		// resting price. The legs therefore always sum to exactly 100.
 		matchQty := min(incoming.Quantity, bestOther.Quantity)
-		matches = append(matches, Match{
-			MakerOrderID: bestOther.ID,
-			TakerOrderID: incoming.ID,
-			Price:        incoming.Price, // Settlement happens at the incoming price in this simple model
-			Quantity:     matchQty,
-			Timestamp:    time.Now(),
-		})
+		matches = append(matches, newMatch(bestOther, incoming, bestOther.Price, 100-bestOther.Price, matchQty, true))
 
 		incoming.Quantity -= matchQty
-		bestOther.Quantity -= matchQty
+		ob.fillResting(entry, matchQty)
+	}
 
-		if bestOther.Quantity == 0 {
-			heap.Pop(complementary)
-		}
+	return matches, prevented
+}
+
+func newMatch(maker *Order, taker *Order, makerPrice int64, takerPrice int64, quantity int64, complementary bool) Match {
+	return Match{
+		MakerOrderID:  maker.ID,
+		TakerOrderID:  taker.ID,
+		Price:         takerPrice,
+		Quantity:      quantity,
+		Complementary: complementary,
+		Maker: MatchLeg{
+			OrderID: maker.ID,
+			UserID:  maker.UserID,
+			Side:    maker.Side,
+			Outcome: maker.Outcome,
+			Price:   makerPrice,
+
+			CorrelationID: maker.CorrelationID,
+		},
+		Taker: MatchLeg{
+			OrderID: taker.ID,
+			UserID:  taker.UserID,
+			Side:    taker.Side,
+			Outcome: taker.Outcome,
+			Price:   takerPrice,
+
+			CorrelationID: taker.CorrelationID,
+		},
+		Timestamp: time.Now(),
 	}
+}
 
-	return matches
+// fillResting reduces a resting order and unlinks it once fully filled.
+func (ob *OrderBook) fillResting(e *bookEntry, quantity int64) {
+	e.side.fill(e, quantity)
+	if e.order.Quantity == 0 {
+		ob.unlink(e)
+	}
 }
 
-func (ob *OrderBook) addToBook(order *Order) {
+func (ob *OrderBook) sideFor(order *Order) *BookSide {
 	if order.Outcome == Yes {
 		if order.Side == Buy {
-			heap.Push(&ob.YesBids, order)
-		} else {
-			heap.Push(&ob.YesAsks, order)
-		}
-	} else {
-		if order.Side == Buy {
-			heap.Push(&ob.NoBids, order)
-		} else {
-			heap.Push(&ob.NoAsks, order)
+			return ob.YesBids
 		}
+		return ob.YesAsks
+	}
+	if order.Side == Buy {
+		return ob.NoBids
 	}
+	return ob.NoAsks
 }
 
-// Helper: Peek gets the top element without removing it
-func (h BidHeap) Peek() *Order { return h.OrderHeap[0] }
-func (h AskHeap) Peek() *Order { return h.OrderHeap[0] }
+func (ob *OrderBook) addToBook(order *Order) {
+	ob.index[order.ID] = ob.sideFor(order).push(order)
+}
 
 func min(a, b int64) int64 {
 	if a < b {
@@ -0,0 +1,163 @@
+package engine_test
+
+import (
+	"testing"
+
+	"cs2-prediction-engine/internal/engine"
+)
+
+// continuousBook returns a book holding orders as resting liquidity; the
+// orders must not cross each other.
+func continuousBook(t *testing.T, orders ...*engine.Order) *engine.OrderBook {
+	t.Helper()
+	ob := engine.NewOrderBook()
+	for _, o := range orders {
+		matches, prevented, err := ob.ProcessOrder(o)
+		if err != nil || len(matches) > 0 || len(prevented) > 0 {
+			t.Fatalf("order %d did not rest: matches %v, prevented %v, err %v", o.ID, matches, prevented, err)
+		}
+	}
+	return ob
+}
+
+func TestSelfTradePrevention(t *testing.T) {
+	tests := []struct {
+		name           string
+		mode           engine.SelfTradeMode
+		want           engine.SelfTradeMode
+		matched        int64
+		makerReduced   int64
+		takerReduced   int64
+		makerCancelled bool
+		takerCancelled bool
+		restingAsks    []engine.DepthLevel
+	}{
+		{
+			name: "default", mode: "", want: engine.STPCancelNewest,
+			takerReduced: 5, takerCancelled: true,
+			restingAsks: []engine.DepthLevel{{Price: 50, Quantity: 3}, {Price: 55, Quantity: 10}},
+		},
+		{
+			name: "cancel_newest", mode: engine.STPCancelNewest, want: engine.STPCancelNewest,
+			takerReduced: 5, takerCancelled: true,
+			restingAsks: []engine.DepthLevel{{Price: 50, Quantity: 3}, {Price: 55, Quantity: 10}},
+		},
+		{
+			name: "cancel_oldest", mode: engine.STPCancelOldest, want: engine.STPCancelOldest,
+			matched: 5, makerReduced: 3, makerCancelled: true,
+			restingAsks: []engine.DepthLevel{{Price: 55, Quantity: 5}},
+		},
+		{
+			name: "cancel_both", mode: engine.STPCancelBoth, want: engine.STPCancelBoth,
+			makerReduced: 3, takerReduced: 5, makerCancelled: true, takerCancelled: true,
+			restingAsks: []engine.DepthLevel{{Price: 55, Quantity: 10}},
+		},
+		{
+			name: "decrement", mode: engine.STPDecrement, want: engine.STPDecrement,
+			matched: 2, makerReduced: 3, takerReduced: 3, makerCancelled: true,
+			restingAsks: []engine.DepthLevel{{Price: 55, Quantity: 8}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// alice's own ask at 50 is ahead of bob's at 55.
+			ob := continuousBook(t,
+				newOrder(1, "alice", engine.Sell, engine.Yes, 50, 3),
+				newOrder(2, "bob", engine.Sell, engine.Yes, 55, 10),
+			)
+			buy := newOrder(3, "alice", engine.Buy, engine.Yes, 60, 5)
+			buy.SelfTradePrevention = tt.mode
+
+			matches, prevented, err := ob.ProcessOrder(buy)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, m := range matches {
+				if m.Maker.UserID == m.Taker.UserID {
+					t.Errorf("self-trade: %+v", m)
+				}
+			}
+			if got := matchedQuantity(matches); got != tt.matched {
+				t.Errorf("matched %d, want %d", got, tt.matched)
+			}
+			if len(prevented) != 1 {
+				t.Fatalf("prevented = %+v, want one prevention", prevented)
+			}
+			p := prevented[0]
+			if p.Mode != tt.want || p.MakerOrderID != 1 || p.TakerOrderID != 3 || p.Quantity != 3 {
+				t.Errorf("prevention = %+v, want mode %s, maker 1, taker 3, quantity 3", p, tt.want)
+			}
+			if p.MakerReduced != tt.makerReduced || p.TakerReduced != tt.takerReduced ||
+				p.MakerCancelled != tt.makerCancelled || p.TakerCancelled != tt.takerCancelled {
+				t.Errorf("prevention = %+v, want reduced %d/%d cancelled %t/%t", p,
+					tt.makerReduced, tt.takerReduced, tt.makerCancelled, tt.takerCancelled)
+			}
+			if buy.Quantity != 0 {
+				t.Errorf("incoming rests %d, want 0", buy.Quantity)
+			}
+			if got := ob.Depth(0).YesAsks; !equalDepth(got, tt.restingAsks) {
+				t.Errorf("YES asks = %v, want %v", got, tt.restingAsks)
+			}
+		})
+	}
+}
+
+func equalDepth(got, want []engine.DepthLevel) bool {
+	if len(got) != len(want) {
+		return false
+	}
+	for i := range got {
+		if got[i].Price != want[i].Price || got[i].Quantity != want[i].Quantity {
+			return false
+		}
+	}
+	return true
+}
+
+// Opposite-outcome orders cross when buys sum to at least 100 or sells to at
+// most 100. The maker keeps its limit and the legs always sum to 100.
+func TestComplementaryCrossingBoundary(t *testing.T) {
+	tests := []struct {
+		name       string
+		side       engine.Side
+		makerPrice int64 // NO order resting
+		takerPrice int64 // incoming YES order
+		crosses    bool
+		takerLeg   int64
+	}{
+		{"buys sum to 99", engine.Buy, 40, 59, false, 0},
+		{"buys sum to 100", engine.Buy, 40, 60, true, 60},
+		{"buys sum to 101", engine.Buy, 40, 61, true, 60},
+		{"sells sum to 99", engine.Sell, 40, 59, true, 60},
+		{"sells sum to 100", engine.Sell, 40, 60, true, 60},
+		{"sells sum to 101", engine.Sell, 40, 61, false, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ob := continuousBook(t, newOrder(1, "bob", tt.side, engine.No, tt.makerPrice, 5))
+			matches, _, err := ob.ProcessOrder(newOrder(2, "alice", tt.side, engine.Yes, tt.takerPrice, 5))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !tt.crosses {
+				if len(matches) != 0 {
+					t.Errorf("matches = %+v, want none", matches)
+				}
+				return
+			}
+			if len(matches) != 1 {
+				t.Fatalf("matches = %+v, want one", matches)
+			}
+			m := matches[0]
+			if !m.Complementary || m.Quantity != 5 {
+				t.Errorf("match = %+v, want complementary fill of 5", m)
+			}
+			if m.Maker.Price != tt.makerPrice || m.Taker.Price != tt.takerLeg {
+				t.Errorf("legs = %d/%d, want %d/%d", m.Maker.Price, m.Taker.Price, tt.makerPrice, tt.takerLeg)
+			}
+			if m.Maker.Price+m.Taker.Price != 100 {
+				t.Errorf("legs sum to %d, want 100", m.Maker.Price+m.Taker.Price)
+			}
+		})
+	}
+}
@@ -0,0 +1,165 @@
+package engine
+
+const (
+	minPrice = 1
+	maxPrice = 99
+)
+
+// bookEntry is an order's node in its price level's FIFO queue.
+type bookEntry struct {
+	order *Order
+	side  *BookSide
+	prev  *bookEntry
+	next  *bookEntry
+}
+
+// priceLevel is a doubly linked FIFO of orders resting at one price.
+type priceLevel struct {
+	head     *bookEntry
+	tail     *bookEntry
+	quantity int64
+	orders   int
+}
+
+// BookSide holds one side (bids or asks) of one outcome as an array of price
+// levels indexed by price, so the best level is found without sorting and any
+// order can be unlinked in constant time.
+type BookSide struct {
+	bid    bool
+	levels [maxPrice + 1]priceLevel
+	best   int64 // 0 when the side is empty
+	orders int
+}
+
+func newBookSide(bid bool) *BookSide {
+	return &BookSide{bid: bid}
+}
+
+// DepthLevel aggregates the resting quantity at one price.
+type DepthLevel struct {
+	Price    int64 `json:"price"`
+	Quantity int64 `json:"quantity"`
+	Orders   int   `json:"orders"`
+}
+
+func (bs *BookSide) Len() int { return bs.orders }
+
+// Peek returns the oldest order at the best price, or nil when empty.
+func (bs *BookSide) Peek() *Order {
+	if bs.best == 0 {
+		return nil
+	}
+	return bs.levels[bs.best].head.order
+}
+
+func (bs *BookSide) peekEntry() *bookEntry {
+	if bs.best == 0 {
+		return nil
+	}
+	return bs.levels[bs.best].head
+}
+
+// BestPrice returns the best resting price, or 0 when empty.
+func (bs *BookSide) BestPrice() int64 { return bs.best }
+
+func (bs *BookSide) better(a, b int64) bool {
+	if bs.bid {
+		return a > b
+	}
+	return a < b
+}
+
+func (bs *BookSide) push(order *Order) *bookEntry {
+	e := &bookEntry{order: order, side: bs}
+	level := &bs.levels[order.Price]
+	if level.tail == nil {
+		level.head = e
+	} else {
+		level.tail.next = e
+		e.prev = level.tail
+	}
+	level.tail = e
+	level.quantity += order.Quantity
+	level.orders++
+	bs.orders++
+
+	if bs.best == 0 || bs.better(order.Price, bs.best) {
+		bs.best = order.Price
+	}
+	return e
+}
+
+// fill reduces a resting order's quantity and keeps the level total in step.
+func (bs *BookSide) fill(e *bookEntry, quantity int64) {
+	e.order.Quantity -= quantity
+	bs.levels[e.order.Price].quantity -= quantity
+}
+
+func (bs *BookSide) remove(e *bookEntry) {
+	price := e.order.Price
+	level := &bs.levels[price]
+	if e.prev == nil {
+		level.head = e.next
+	} else {
+		e.prev.next = e.next
+	}
+	if e.next == nil {
+		level.tail = e.prev
+	} else {
+		e.next.prev = e.prev
+	}
+	e.prev, e.next = nil, nil
+	level.quantity -= e.order.Quantity
+	level.orders--
+	bs.orders--
+
+	if level.orders == 0 && price == bs.best {
+		bs.best = bs.nextBest(price)
+	}
+}
+
+// nextBest walks away from price toward worse prices for the next non-empty
+// level. The walk is bounded by the 99 tradable prices.
+func (bs *BookSide) nextBest(from int64) int64 {
+	if bs.orders == 0 {
+		return 0
+	}
+	if bs.bid {
+		for p := from - 1; p >= minPrice; p-- {
+			if bs.levels[p].orders > 0 {
+				return p
+			}
+		}
+	} else {
+		for p := from + 1; p <= maxPrice; p++ {
+			if bs.levels[p].orders > 0 {
+				return p
+			}
+		}
+	}
+	return 0
+}
+
+// Depth returns up to n non-empty levels from the best price outward; n <= 0
+// returns every level.
+func (bs *BookSide) Depth(n int) []DepthLevel {
+	out := make([]DepthLevel, 0)
+	if bs.best == 0 {
+		return out
+	}
+	step := int64(1)
+	if bs.bid {
+		step = -1
+	}
+	for p := bs.best; p >= minPrice && p <= maxPrice; p += step {
+		level := bs.levels[p]
+		if level.orders == 0 {
+			continue
+		}
+		out = append(out, DepthLevel{Price: p, Quantity: level.quantity, Orders: level.orders})
+		if n > 0 && len(out) == n {
+			break
+		}
+	}
+	return out
+}
@@ -0,0 +1,82 @@
+package engine
+
+import "sync/atomic"
+
+// commandRing is a bounded lock-free queue (Vyukov's array queue). Any number
+// of goroutines may offer concurrently; exactly one goroutine may poll.
+type commandRing struct {
+	mask  uint64
+	slots []ringSlot
+
+	_       [56]byte // keep the producer and consumer cursors on separate cache lines
+	enqueue atomic.Uint64
+	_       [56]byte
+	dequeue atomic.Uint64
+}
+
+type ringSlot struct {
+	turn atomic.Uint64
+	cmd  Command
+}
+
+// newCommandRing rounds capacity up to a power of two.
+func newCommandRing(capacity int) *commandRing {
+	size := uint64(2)
+	for size < uint64(capacity) {
+		size <<= 1
+	}
+	r := &commandRing{
+		mask:  size - 1,
+		slots: make([]ringSlot, size),
+	}
+	for i := range r.slots {
+		r.slots[i].turn.Store(uint64(i))
+	}
+	return r
+}
+
+// offer enqueues cmd, returning false when the ring is full.
+func (r *commandRing) offer(cmd Command) bool {
+	pos := r.enqueue.Load()
+	for {
+		slot := &r.slots[pos&r.mask]
+		turn := slot.turn.Load()
+		switch diff := int64(turn) - int64(pos); {
+		case diff == 0:
+			if r.enqueue.CompareAndSwap(pos, pos+1) {
+				slot.cmd = cmd
+				slot.turn.Store(pos + 1)
+				return true
+			}
+			pos = r.enqueue.Load()
+		case diff < 0:
+			return false
+		default:
+			pos = r.enqueue.Load()
+		}
+	}
+}
+
+// poll dequeues the next command. It must only be called by the consumer.
+func (r *commandRing) poll() (Command, bool) {
+	pos := r.dequeue.Load()
+	slot := &r.slots[pos&r.mask]
+	if slot.turn.Load() != pos+1 {
+		return Command{}, false
+	}
+	cmd := slot.cmd
+	slot.cmd = Command{}
+	slot.turn.Store(pos + r.mask + 1)
+	r.dequeue.Store(pos + 1)
+	return cmd, true
+}
+
+func (r *commandRing) len() int {
+	n := int64(r.enqueue.Load()) - int64(r.dequeue.Load())
+	if n < 0 {
+		return 0
+	}
+	return int(n)
+}
+
+func (r *commandRing) capacity() int { return len(r.slots) }
@@ -0,0 +1,304 @@
+package engine
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+type RiskTier string
+
+const (
+	TierRetail      RiskTier = "retail"
+	TierPro         RiskTier = "pro"
+	TierMarketMaker RiskTier = "market_maker"
+)
+
+// RiskLimit names the check an order failed.
+type RiskLimit string
+
+const (
+	LimitOrderSize    RiskLimit = "order_size"
+	LimitOpenNotional RiskLimit = "open_notional"
+	LimitMarketLoss   RiskLimit = "market_loss"
+	LimitOrderRate    RiskLimit = "order_rate"
+	LimitPriceBand    RiskLimit = "price_band"
+)
+
+// RiskLimits caps one user's trading. Zero leaves a limit unenforced.
+type RiskLimits struct {
+	MaxOrderQuantity   int64 `json:"max_order_quantity,omitempty"`
+	MaxOpenNotional    int64 `json:"max_open_notional,omitempty"` // Reserved across all open orders
+	MaxMarketLoss      int64 `json:"max_market_loss,omitempty"`   // Worst-case loss in one market
+	MaxOrdersPerSecond int   `json:"max_orders_per_second,omitempty"`
+	PriceBand          int64 `json:"price_band,omitempty"` // Max distance in YES cents from the reference price
+}
+
+// Tighten combines two limit sets, keeping the stricter of each enforced limit.
+func (l RiskLimits) Tighten(o RiskLimits) RiskLimits {
+	return RiskLimits{
+		MaxOrderQuantity:   tighter(l.MaxOrderQuantity, o.MaxOrderQuantity),
+		MaxOpenNotional:    tighter(l.MaxOpenNotional, o.MaxOpenNotional),
+		MaxMarketLoss:      tighter(l.MaxMarketLoss, o.MaxMarketLoss),
+		MaxOrdersPerSecond: int(tighter(int64(l.MaxOrdersPerSecond), int64(o.MaxOrdersPerSecond))),
+		PriceBand:          tighter(l.PriceBand, o.PriceBand),
+	}
+}
+
+func tighter(a, b int64) int64 {
+	if a == 0 || (b != 0 && b < a) {
+		return b
+	}
+	return a
+}
+
+// RiskConfig holds limits per user tier, per market type and per market.
+// Market-type and market limits apply on top of the user's tier limits.
+type RiskConfig struct {
+	DefaultTier RiskTier                `json:"default_tier"`
+	Tiers       map[RiskTier]RiskLimits `json:"tiers"`
+	MarketTypes []MarketTypeLimits      `json:"market_types,omitempty"`
+	Markets     map[string]RiskLimits   `json:"markets,omitempty"`
+}
+
+// MarketTypeLimits applies to every market matching Pattern.
+type MarketTypeLimits struct {
+	Pattern string     `json:"pattern"`
+	Limits  RiskLimits `json:"limits"`
+}
+
+var DefaultRiskConfig = RiskConfig{
+	DefaultTier: TierRetail,
+	Tiers: map[RiskTier]RiskLimits{
+		TierRetail: {
+			MaxOrderQuantity:   5000,
+			MaxOpenNotional:    500000,
+			MaxMarketLoss:      250000,
+			MaxOrdersPerSecond: 5,
+			PriceBand:          25,
+		},
+		TierPro: {
+			MaxOrderQuantity:   50000,
+			MaxOpenNotional:    5000000,
+			MaxMarketLoss:      2500000,
+			MaxOrdersPerSecond: 20,
+			PriceBand:          40,
+		},
+		TierMarketMaker: {
+			MaxOpenNotional:    50000000,
+			MaxMarketLoss:      10000000,
+			MaxOrdersPerSecond: 200,
+		},
+	},
+}
+
+// RiskRejection reports which limit an order would breach.
+type RiskRejection struct {
+	Limit  RiskLimit `json:"limit"`
+	Value  int64     `json:"value"`
+	Max    int64     `json:"max"`
+	Detail string    `json:"detail"`
+}
+
+func (r *RiskRejection) Error() string {
+	return fmt.Sprintf("risk limit %s: %s", r.Limit, r.Detail)
+}
+
+// RiskExposure is what the user already has at stake when an order arrives.
+type RiskExposure struct {
+	Position     MarketPosition
+	OpenOrders   []Order // Resting or buffered orders in the market, remaining quantity only
+	OpenNotional int64   // Reserved across all markets
+	Reference    int64   // YES reference price; zero skips the price band
+}
+
+// rateWindow is the period MaxOrdersPerSecond is measured over.
+const rateWindow = time.Second
+
+// RiskEngine runs pre-trade checks on orders before any funds are reserved.
+type RiskEngine struct {
+	mu        sync.Mutex
+	cfg       RiskConfig
+	userTiers map[string]RiskTier
+	recent    map[string][]time.Time
+}
+
+func NewRiskEngine(cfg RiskConfig) *RiskEngine {
+	if cfg.Markets == nil {
+		cfg.Markets = make(map[string]RiskLimits)
+	}
+	return &RiskEngine{
+		cfg:       cfg,
+		userTiers: make(map[string]RiskTier),
+		recent:    make(map[string][]time.Time),
+	}
+}
+
+// SetConfig replaces the tier and market-type limits. Per-market limits set
+// by operators and user tier assignments are kept.
+func (re *RiskEngine) SetConfig(cfg RiskConfig) {
+	re.mu.Lock()
+	defer re.mu.Unlock()
+	re.cfg.DefaultTier = cfg.DefaultTier
+	re.cfg.Tiers = cfg.Tiers
+	re.cfg.MarketTypes = cfg.MarketTypes
+}
+
+func (re *RiskEngine) SetUserTier(userID string, tier RiskTier) error {
+	re.mu.Lock()
+	defer re.mu.Unlock()
+	if _, ok := re.cfg.Tiers[tier]; !ok {
+		return fmt.Errorf("unknown risk tier %q", tier)
+	}
+	re.userTiers[userID] = tier
+	return nil
+}
+
+func (re *RiskEngine) UserTier(userID string) RiskTier {
+	re.mu.Lock()
+	defer re.mu.Unlock()
+	return re.tierLocked(userID)
+}
+
+func (re *RiskEngine) tierLocked(userID string) RiskTier {
+	if tier, ok := re.userTiers[userID]; ok {
+		return tier
+	}
+	return re.cfg.DefaultTier
+}
+
+func (re *RiskEngine) SetMarketLimits(marketID string, limits RiskLimits) {
+	re.mu.Lock()
+	defer re.mu.Unlock()
+	re.cfg.Markets[marketID] = limits
+}
+
+// Limits returns the limits in force for a user trading a market.
+func (re *RiskEngine) Limits(userID, marketID string) RiskLimits {
+	re.mu.Lock()
+	defer re.mu.Unlock()
+	return re.limitsLocked(userID, marketID)
+}
+
+func (re *RiskEngine) limitsLocked(userID, marketID string) RiskLimits {
+	limits := re.cfg.Tiers[re.tierLocked(userID)]
+	for _, t := range re.cfg.MarketTypes {
+		if MatchesMarket(t.Pattern, marketID) {
+			limits = limits.Tighten(t.Limits)
+			break
+		}
+	}
+	return limits.Tighten(re.cfg.Markets[marketID])
+}
+
+// Check vets an order that would reserve the given amount. It does not count
+// the order towards the user's rate; call RecordOrder once it is accepted, so
+// orders rejected later for other reasons do not use up the limit.
+func (re *RiskEngine) Check(order Order, reserve int64, exposure RiskExposure, now time.Time) *RiskRejection {
+	re.mu.Lock()
+	defer re.mu.Unlock()
+
+	limits := re.limitsLocked(order.UserID, order.MarketID)
+
+	if limits.MaxOrderQuantity > 0 && order.Quantity > limits.MaxOrderQuantity {
+		return &RiskRejection{
+			Limit: LimitOrderSize, Value: order.Quantity, Max: limits.MaxOrderQuantity,
+			Detail: fmt.Sprintf("quantity %d exceeds %d", order.Quantity, limits.MaxOrderQuantity),
+		}
+	}
+
+	if limits.PriceBand > 0 && exposure.Reference > 0 {
+		yesPrice := order.Price
+		if order.Outcome == No {
+			yesPrice = 100 - order.Price
+		}
+		if distance := abs64(yesPrice - exposure.Reference); distance > limits.PriceBand {
+			return &RiskRejection{
+				Limit: LimitPriceBand, Value: distance, Max: limits.PriceBand,
+				Detail: fmt.Sprintf("YES price %d is %d from reference %d", yesPrice, distance, exposure.Reference),
+			}
+		}
+	}
+
+	if limits.MaxOpenNotional > 0 && exposure.OpenNotional+reserve > limits.MaxOpenNotional {
+		return &RiskRejection{
+			Limit: LimitOpenNotional, Value: exposure.OpenNotional + reserve, Max: limits.MaxOpenNotional,
+			Detail: fmt.Sprintf("open notional would reach %d", exposure.OpenNotional+reserve),
+		}
+	}
+
+	if limits.MaxMarketLoss > 0 {
+		loss := WorstCaseLoss(exposure.Position, append(exposure.OpenOrders, order))
+		if loss > limits.MaxMarketLoss {
+			return &RiskRejection{
+				Limit: LimitMarketLoss, Value: loss, Max: limits.MaxMarketLoss,
+				Detail: fmt.Sprintf("worst-case loss in %s would reach %d", order.MarketID, loss),
+			}
+		}
+	}
+
+	if recent := re.recentLocked(order.UserID, now); limits.MaxOrdersPerSecond > 0 && len(recent) >= limits.MaxOrdersPerSecond {
+		return &RiskRejection{
+			Limit: LimitOrderRate, Value: int64(len(recent) + 1), Max: int64(limits.MaxOrdersPerSecond),
+			Detail: fmt.Sprintf("more than %d orders per second", limits.MaxOrdersPerSecond),
+		}
+	}
+	return nil
+}
+
+// RecordOrder counts an accepted order towards the user's order rate.
+func (re *RiskEngine) RecordOrder(userID string, now time.Time) {
+	re.mu.Lock()
+	defer re.mu.Unlock()
+	re.recent[userID] = append(re.recentLocked(userID, now), now)
+}
+
+// recentLocked drops the user's orders older than the rate window and returns
+// the rest.
+func (re *RiskEngine) recentLocked(userID string, now time.Time) []time.Time {
+	recent := re.recent[userID]
+	cutoff := now.Add(-rateWindow)
+	kept := recent[:0]
+	for _, at := range recent {
+		if at.After(cutoff) {
+			kept = append(kept, at)
+		}
+	}
+	if len(kept) == 0 {
+		delete(re.recent, userID)
+		return nil
+	}
+	re.recent[userID] = kept
+	return kept
+}
+
+// WorstCaseLoss bounds what a user can lose in one market if every open order
+// fills, under whichever outcome is worse for them. Orders that would hedge
+// the position are not credited, since they may never fill.
+func WorstCaseLoss(position MarketPosition, open []Order) int64 {
+	cost := position.YesCost + position.NoCost
+	lossIfYes := cost - position.YesShares*100
+	lossIfNo := cost - position.NoShares*100
+
+	for _, o := range open {
+		outcome, price := o.Outcome, o.Price
+		if o.Side == Sell {
+			// Selling an outcome at P is buying the other at 100-P.
+			outcome, price = opposite(o.Outcome), 100-o.Price
+		}
+		orderCost := price * o.Quantity
+		if outcome == Yes {
+			lossIfNo += orderCost
+		} else {
+			lossIfYes += orderCost
+		}
+	}
+	return max(lossIfYes, lossIfNo, 0)
+}
+
+func opposite(o Outcome) Outcome {
+	if o == Yes {
+		return No
+	}
+	return Yes
+}
@@ -0,0 +1,129 @@
+package engine_test
+
+import (
+	"testing"
+	"time"
+
+	"cs2-prediction-engine/internal/engine"
+)
+
+func TestRiskLimitsTighten(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b engine.RiskLimits
+		want engine.RiskLimits
+	}{
+		{"both unset", engine.RiskLimits{}, engine.RiskLimits{}, engine.RiskLimits{}},
+		{"unset takes the other", engine.RiskLimits{}, engine.RiskLimits{MaxOrderQuantity: 10}, engine.RiskLimits{MaxOrderQuantity: 10}},
+		{"unset never loosens", engine.RiskLimits{PriceBand: 25}, engine.RiskLimits{}, engine.RiskLimits{PriceBand: 25}},
+		{"stricter wins", engine.RiskLimits{MaxOpenNotional: 500, MaxOrdersPerSecond: 5}, engine.RiskLimits{MaxOpenNotional: 900, MaxOrdersPerSecond: 2},
+			engine.RiskLimits{MaxOpenNotional: 500, MaxOrdersPerSecond: 2}},
+		{"per field", engine.RiskLimits{MaxMarketLoss: 100, PriceBand: 40}, engine.RiskLimits{MaxMarketLoss: 300, PriceBand: 10},
+			engine.RiskLimits{MaxMarketLoss: 100, PriceBand: 10}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.a.Tighten(tt.b); got != tt.want {
+				t.Errorf("Tighten = %+v, want %+v", got, tt.want)
+			}
+			if got := tt.b.Tighten(tt.a); got != tt.want {
+				t.Errorf("Tighten reversed = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWorstCaseLoss(t *testing.T) {
+	longYes := engine.MarketPosition{YesShares: 10, YesCost: 400}
+	tests := []struct {
+		name     string
+		position engine.MarketPosition
+		open     []engine.Order
+		want     int64
+	}{
+		{"nothing at stake", engine.MarketPosition{}, nil, 0},
+		{"long YES loses its cost on NO", longYes, nil, 400},
+		{"hedged position", engine.MarketPosition{YesShares: 10, YesCost: 400, NoShares: 10, NoCost: 500}, nil, 0},
+		{"YES bid", engine.MarketPosition{}, []engine.Order{*newOrder(1, "a", engine.Buy, engine.Yes, 40, 10)}, 400},
+		{"YES ask buys NO at 100-P", engine.MarketPosition{}, []engine.Order{*newOrder(1, "a", engine.Sell, engine.Yes, 60, 10)}, 400},
+		{"worse outcome of both sides", engine.MarketPosition{}, []engine.Order{
+			*newOrder(1, "a", engine.Buy, engine.Yes, 40, 10),
+			*newOrder(2, "a", engine.Buy, engine.No, 50, 10),
+		}, 500},
+		{"hedging order not credited", longYes, []engine.Order{*newOrder(1, "a", engine.Sell, engine.Yes, 70, 10)}, 400},
+		{"adding to the position", longYes, []engine.Order{*newOrder(1, "a", engine.Buy, engine.Yes, 50, 4)}, 600},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := engine.WorstCaseLoss(tt.position, tt.open); got != tt.want {
+				t.Errorf("WorstCaseLoss = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRiskLimitsResolution(t *testing.T) {
+	re := engine.NewRiskEngine(engine.RiskConfig{
+		DefaultTier: engine.TierRetail,
+		Tiers: map[engine.RiskTier]engine.RiskLimits{
+			engine.TierRetail: {MaxOrderQuantity: 100, PriceBand: 25},
+			engine.TierPro:    {MaxOrderQuantity: 1000, PriceBand: 40},
+		},
+		MarketTypes: []engine.MarketTypeLimits{
+			{Pattern: "series_*_winner", Limits: engine.RiskLimits{MaxOrderQuantity: 500, MaxMarketLoss: 2000}},
+			{Pattern: "series_*", Limits: engine.RiskLimits{MaxOrderQuantity: 1}},
+			{Pattern: "map_*", Limits: engine.RiskLimits{PriceBand: 10}},
+		},
+	})
+	if err := re.SetUserTier("pro_user", engine.TierPro); err != nil {
+		t.Fatal(err)
+	}
+	if err := re.SetUserTier("pro_user", "vip"); err == nil {
+		t.Error("unknown tier accepted")
+	}
+	re.SetMarketLimits("map_s1_1", engine.RiskLimits{MaxOrderQuantity: 50})
+
+	tests := []struct {
+		name   string
+		user   string
+		market string
+		want   engine.RiskLimits
+	}{
+		{"default tier", "retail_user", "other", engine.RiskLimits{MaxOrderQuantity: 100, PriceBand: 25}},
+		{"assigned tier", "pro_user", "other", engine.RiskLimits{MaxOrderQuantity: 1000, PriceBand: 40}},
+		{"first matching type only", "pro_user", "series_s1_winner", engine.RiskLimits{MaxOrderQuantity: 500, MaxMarketLoss: 2000, PriceBand: 40}},
+		{"type never loosens the tier", "retail_user", "series_s1_winner", engine.RiskLimits{MaxOrderQuantity: 100, MaxMarketLoss: 2000, PriceBand: 25}},
+		{"market on top of type", "pro_user", "map_s1_1", engine.RiskLimits{MaxOrderQuantity: 50, PriceBand: 10}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := re.Limits(tt.user, tt.market); got != tt.want {
+				t.Errorf("Limits(%s, %s) = %+v, want %+v", tt.user, tt.market, got, tt.want)
+			}
+		})
+	}
+}
+
+// Only orders recorded as accepted use up the rate limit.
+func TestRiskOrderRateCountsAcceptedOrders(t *testing.T) {
+	re := engine.NewRiskEngine(engine.RiskConfig{
+		DefaultTier: engine.TierRetail,
+		Tiers:       map[engine.RiskTier]engine.RiskLimits{engine.TierRetail: {MaxOrdersPerSecond: 2}},
+	})
+	order := *newOrder(1, "alice", engine.Buy, engine.Yes, 40, 1)
+	now := time.Now()
+
+	for i := 0; i < 5; i++ {
+		if rejection := re.Check(order, 40, engine.RiskExposure{}, now); rejection != nil {
+			t.Fatalf("check %d without accepting: %v", i, rejection)
+		}
+	}
+	re.RecordOrder("alice", now)
+	re.RecordOrder("alice", now)
+	if rejection := re.Check(order, 40, engine.RiskExposure{}, now); rejection == nil || rejection.Limit != engine.LimitOrderRate {
+		t.Errorf("third accepted order in a second: %v, want order_rate", rejection)
+	}
+	if rejection := re.Check(order, 40, engine.RiskExposure{}, now.Add(time.Second)); rejection != nil {
+		t.Errorf("after the window: %v", rejection)
+	}
+}
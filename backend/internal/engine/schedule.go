@@ -0,0 +1,93 @@
+package engine
+
+import "time"
+
+// TradingPhase separates pre-match trading from in-play trading, which runs
+// under feed-driven rules: adaptive fairness delay, event cancellation and
+// feed integrity checks.
+type TradingPhase string
+
+const (
+	PhasePreMatch TradingPhase = "pre_match"
+	PhaseInPlay   TradingPhase = "in_play"
+)
+
+// ScheduleAction is a lifecycle step that falls due from a market's StartTime.
+type ScheduleAction string
+
+const (
+	ScheduleOpenPreMatch ScheduleAction = "open_pre_match"
+	ScheduleGoInPlay     ScheduleAction = "go_in_play"
+	ScheduleNoLiveFeed   ScheduleAction = "no_live_feed"
+)
+
+// SchedulePolicy drives markets from their StartTime. Markets open
+// PreMatchLead before the start, go in-play at the start, and are halted with
+// GraceAction (StatusSuspended or StatusClosed) if no series_state arrives
+// within LiveGrace of it. Resting orders carry over into in-play trading
+// unless CancelOnInPlay is set, in which case each owner gets order_cancelled
+// with reason market_in_play at the switch.
+type SchedulePolicy struct {
+	PreMatchLead   time.Duration
+	LiveGrace      time.Duration
+	GraceAction    MarketStatus
+	PreMatchDelay  time.Duration // Fairness delay while no feed is running
+	CancelOnInPlay bool
+}
+
+var DefaultSchedulePolicy = SchedulePolicy{
+	PreMatchLead:  24 * time.Hour,
+	LiveGrace:     10 * time.Minute,
+	GraceAction:   StatusSuspended,
+	PreMatchDelay: 500 * time.Millisecond,
+}
+
+// ScheduledStart parses StartTime; ok is false when it is unset or invalid.
+func (m MarketMetadata) ScheduledStart() (time.Time, bool) {
+	if m.StartTime == "" {
+		return time.Time{}, false
+	}
+	start, err := time.Parse(time.RFC3339, m.StartTime)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return start, true
+}
+
+// Initial returns the status and phase a new market starts in. Markets
+// without a start time are treated as already live.
+func (p SchedulePolicy) Initial(start time.Time, hasStart bool, now time.Time) (MarketStatus, TradingPhase) {
+	switch {
+	case !hasStart || !now.Before(start):
+		return StatusOpen, PhaseInPlay
+	case now.Before(start.Add(-p.PreMatchLead)):
+		return StatusScheduled, PhasePreMatch
+	default:
+		return StatusOpen, PhasePreMatch
+	}
+}
+
+// Due returns the next scheduled step for a market, if one has fallen due.
+func (p SchedulePolicy) Due(meta MarketMetadata, now time.Time) (ScheduleAction, bool) {
+	start, ok := meta.ScheduledStart()
+	if !ok {
+		return "", false
+	}
+
+	switch {
+	case meta.Status == StatusScheduled:
+		if !now.Before(start.Add(-p.PreMatchLead)) {
+			return ScheduleOpenPreMatch, true
+		}
+	case meta.Status != StatusOpen && meta.Status != StatusSuspended:
+	case meta.Phase == PhasePreMatch:
+		if !now.Before(start) {
+			return ScheduleGoInPlay, true
+		}
+	case meta.Status == StatusOpen && meta.GameState == nil:
+		if !now.Before(start.Add(p.LiveGrace)) {
+			return ScheduleNoLiveFeed, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,85 @@
+package engine_test
+
+import (
+	"testing"
+	"time"
+
+	"cs2-prediction-engine/internal/engine"
+)
+
+func TestScheduleInitial(t *testing.T) {
+	policy := engine.DefaultSchedulePolicy
+	start := time.Date(2026, 5, 1, 18, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		hasStart bool
+		now      time.Time
+		status   engine.MarketStatus
+		phase    engine.TradingPhase
+	}{
+		{"no start time", false, start, engine.StatusOpen, engine.PhaseInPlay},
+		{"before lead", true, start.Add(-policy.PreMatchLead - time.Minute), engine.StatusScheduled, engine.PhasePreMatch},
+		{"at lead", true, start.Add(-policy.PreMatchLead), engine.StatusOpen, engine.PhasePreMatch},
+		{"within lead", true, start.Add(-time.Hour), engine.StatusOpen, engine.PhasePreMatch},
+		{"at start", true, start, engine.StatusOpen, engine.PhaseInPlay},
+		{"after start", true, start.Add(time.Hour), engine.StatusOpen, engine.PhaseInPlay},
+	}
+	for _, tt := range tests {
+		status, phase := policy.Initial(start, tt.hasStart, tt.now)
+		if status != tt.status || phase != tt.phase {
+			t.Errorf("%s: Initial() = %s, %s; want %s, %s", tt.name, status, phase, tt.status, tt.phase)
+		}
+	}
+}
+
+func TestScheduleDue(t *testing.T) {
+	policy := engine.DefaultSchedulePolicy
+	start := time.Date(2026, 5, 1, 18, 0, 0, 0, time.UTC)
+	startTime := start.Format(time.RFC3339)
+	live := &engine.MarketGameState{}
+	tests := []struct {
+		name   string
+		meta   engine.MarketMetadata
+		now    time.Time
+		action engine.ScheduleAction
+	}{
+		{"no start time", engine.MarketMetadata{Status: engine.StatusScheduled}, start, ""},
+		{"invalid start time", engine.MarketMetadata{Status: engine.StatusScheduled, StartTime: "tomorrow"}, start, ""},
+		{"scheduled before lead",
+			engine.MarketMetadata{Status: engine.StatusScheduled, Phase: engine.PhasePreMatch, StartTime: startTime},
+			start.Add(-policy.PreMatchLead - time.Second), ""},
+		{"scheduled at lead",
+			engine.MarketMetadata{Status: engine.StatusScheduled, Phase: engine.PhasePreMatch, StartTime: startTime},
+			start.Add(-policy.PreMatchLead), engine.ScheduleOpenPreMatch},
+		{"pre-match before start",
+			engine.MarketMetadata{Status: engine.StatusOpen, Phase: engine.PhasePreMatch, StartTime: startTime},
+			start.Add(-time.Second), ""},
+		{"pre-match at start",
+			engine.MarketMetadata{Status: engine.StatusOpen, Phase: engine.PhasePreMatch, StartTime: startTime},
+			start, engine.ScheduleGoInPlay},
+		{"suspended pre-match at start",
+			engine.MarketMetadata{Status: engine.StatusSuspended, Phase: engine.PhasePreMatch, StartTime: startTime},
+			start, engine.ScheduleGoInPlay},
+		{"closed pre-match at start",
+			engine.MarketMetadata{Status: engine.StatusClosed, Phase: engine.PhasePreMatch, StartTime: startTime},
+			start, ""},
+		{"in play within grace",
+			engine.MarketMetadata{Status: engine.StatusOpen, Phase: engine.PhaseInPlay, StartTime: startTime},
+			start.Add(policy.LiveGrace - time.Second), ""},
+		{"in play past grace without feed",
+			engine.MarketMetadata{Status: engine.StatusOpen, Phase: engine.PhaseInPlay, StartTime: startTime},
+			start.Add(policy.LiveGrace), engine.ScheduleNoLiveFeed},
+		{"in play past grace with feed",
+			engine.MarketMetadata{Status: engine.StatusOpen, Phase: engine.PhaseInPlay, StartTime: startTime, GameState: live},
+			start.Add(policy.LiveGrace), ""},
+		{"suspended in play past grace",
+			engine.MarketMetadata{Status: engine.StatusSuspended, Phase: engine.PhaseInPlay, StartTime: startTime},
+			start.Add(policy.LiveGrace), ""},
+	}
+	for _, tt := range tests {
+		action, ok := policy.Due(tt.meta, tt.now)
+		if action != tt.action || ok != (tt.action != "") {
+			t.Errorf("%s: Due() = %q, %t; want %q", tt.name, action, ok, tt.action)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package engine
+
+import "fmt"
+
+// SelfTradeMode decides what happens when an incoming order would match a
+// resting order from the same user. The incoming order's mode applies.
+type SelfTradeMode string
+
+const (
+	// STPCancelNewest cancels the rest of the incoming order.
+	STPCancelNewest SelfTradeMode = "cancel_newest"
+	// STPCancelOldest cancels the resting order and keeps matching.
+	STPCancelOldest SelfTradeMode = "cancel_oldest"
+	// STPCancelBoth cancels the resting order and the rest of the incoming one.
+	STPCancelBoth SelfTradeMode = "cancel_both"
+	// STPDecrement shrinks both orders by the quantity that would have
+	// traded, cancelling whichever reaches zero.
+	STPDecrement SelfTradeMode = "decrement"
+)
+
+func ParseSelfTradeMode(s string) (SelfTradeMode, error) {
+	switch SelfTradeMode(s) {
+	case STPCancelNewest, STPCancelOldest, STPCancelBoth, STPDecrement:
+		return SelfTradeMode(s), nil
+	case "":
+		return STPCancelNewest, nil
+	}
+	return "", fmt.Errorf("unknown self-trade prevention mode %q", s)
+}
+
+// SelfTradePrevention reports a match that was not allowed to happen and how
+// much each order lost to it.
+type SelfTradePrevention struct {
+	Mode           SelfTradeMode `json:"mode"`
+	UserID         string        `json:"user_id"`
+	MakerOrderID   uint64        `json:"maker_order_id"`
+	TakerOrderID   uint64        `json:"taker_order_id"`
+	Quantity       int64         `json:"quantity"` // Would have traded
+	MakerReduced   int64         `json:"maker_reduced"`
+	TakerReduced   int64         `json:"taker_reduced"`
+	MakerCancelled bool          `json:"maker_cancelled"`
+	TakerCancelled bool          `json:"taker_cancelled"`
+	Complementary  bool          `json:"complementary"`
+}
+
+// preventSelfTrade applies the incoming order's mode against a crossing
+// resting order from the same user. It reports whether matching must stop.
+func (ob *OrderBook) preventSelfTrade(incoming *Order, e *bookEntry, complementary bool) (SelfTradePrevention, bool) {
+	resting := e.order
+	mode, err := ParseSelfTradeMode(string(incoming.SelfTradePrevention))
+	if err != nil {
+		mode = STPCancelNewest
+	}
+	p := SelfTradePrevention{
+		Mode:          mode,
+		UserID:        incoming.UserID,
+		MakerOrderID:  resting.ID,
+		TakerOrderID:  incoming.ID,
+		Quantity:      min(incoming.Quantity, resting.Quantity),
+		Complementary: complementary,
+	}
+
+	cancelMaker := func() {
+		p.MakerReduced = resting.Quantity
+		p.MakerCancelled = true
+		ob.unlink(e)
+	}
+	cancelTaker := func() {
+		p.TakerReduced = incoming.Quantity
+		p.TakerCancelled = true
+		incoming.Quantity = 0
+	}
+
+	switch mode {
+	case STPCancelOldest:
+		cancelMaker()
+	case STPCancelBoth:
+		cancelMaker()
+		cancelTaker()
+	case STPDecrement:
+		p.MakerReduced, p.TakerReduced = p.Quantity, p.Quantity
+		incoming.Quantity -= p.Quantity
+		p.TakerCancelled = incoming.Quantity == 0
+		p.MakerCancelled = resting.Quantity == p.Quantity
+		ob.fillResting(e, p.Quantity)
+	default:
+		cancelTaker()
+	}
+	return p, incoming.Quantity == 0
+}
+
+// preventAuctionSelfTrade is preventSelfTrade for two resting orders that
+// would cross in an auction. The newer order stands in for the incoming one:
+// its mode applies and it is reported as the taker.
+func (ob *OrderBook) preventAuctionSelfTrade(newer, older *bookEntry, complementary bool) SelfTradePrevention {
+	mode, err := ParseSelfTradeMode(string(newer.order.SelfTradePrevention))
+	if err != nil {
+		mode = STPCancelNewest
+	}
+	p := SelfTradePrevention{
+		Mode:          mode,
+		UserID:        newer.order.UserID,
+		MakerOrderID:  older.order.ID,
+		TakerOrderID:  newer.order.ID,
+		Quantity:      min(newer.order.Quantity, older.order.Quantity),
+		Complementary: complementary,
+	}
+
+	cancelMaker := func() {
+		p.MakerReduced = older.order.Quantity
+		p.MakerCancelled = true
+		ob.unlink(older)
+	}
+	cancelTaker := func() {
+		p.TakerReduced = newer.order.Quantity
+		p.TakerCancelled = true
+		ob.unlink(newer)
+	}
+
+	switch mode {
+	case STPCancelOldest:
+		cancelMaker()
+	case STPCancelBoth:
+		cancelMaker()
+		cancelTaker()
+	case STPDecrement:
+		p.MakerReduced, p.TakerReduced = p.Quantity, p.Quantity
+		p.MakerCancelled = older.order.Quantity == p.Quantity
+		p.TakerCancelled = newer.order.Quantity == p.Quantity
+		ob.fillResting(older, p.Quantity)
+		ob.fillResting(newer, p.Quantity)
+	default:
+		cancelTaker()
+	}
+	return p
+}
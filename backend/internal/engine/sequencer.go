@@ -0,0 +1,277 @@
+package engine
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type CommandKind string
+
+const (
+	CommandPlaceOrder  CommandKind = "place_order"
+	CommandCancelOrder CommandKind = "cancel_order"
+	CommandFeedEvent   CommandKind = "feed_event"
+	CommandRunAuction  CommandKind = "run_auction"
+	// CommandSetMatchingMode carries a MatchingModeChange payload.
+	CommandSetMatchingMode CommandKind = "set_matching_mode"
+	// CommandOperatorAction carries a caller-defined operator request.
+	CommandOperatorAction CommandKind = "operator_action"
+	// CommandScheduled carries a ScheduleAction that fell due.
+	CommandScheduled CommandKind = "scheduled_action"
+)
+
+// Command is one unit of work for a market's sequencer. Payload carries
+// caller-defined context such as a decoded feed message.
+type Command struct {
+	Kind       CommandKind
+	MarketID   string
+	Sequence   uint64
+	Order      *Order
+	OrderID    uint64
+	Payload    interface{}
+	EnqueuedAt time.Time
+}
+
+// CommandHandler applies a command against the market's book. It always runs
+// on the market's sequencer goroutine, so handlers for one market never
+// overlap and observe commands in sequence order.
+type CommandHandler func(book *OrderBook, cmd Command)
+
+// Sequencer owns one market and applies its orders, cancels and feed events
+// in a single total order on a dedicated goroutine.
+type Sequencer struct {
+	marketID string
+	book     *OrderBook
+	ring     *commandRing
+	wake     chan struct{}
+	stop     chan struct{}
+	done     chan struct{}
+	handler  CommandHandler
+
+	auctionMu   sync.Mutex
+	auctionStop chan struct{}
+
+	// stopMu orders Submit against Stop: once stopped is set, no command
+	// is enqueued that the goroutine would never run.
+	stopMu  sync.RWMutex
+	stopped bool
+
+	sequence  atomic.Uint64
+	processed atomic.Uint64
+	rejected  atomic.Uint64
+	waitNanos atomic.Int64
+	busyNanos atomic.Int64
+	startedAt time.Time
+}
+
+// SequencerStats reports a sequencer's progress and throughput.
+type SequencerStats struct {
+	MarketID       string  `json:"market_id"`
+	Sequence       uint64  `json:"sequence"`
+	Processed      uint64  `json:"processed"`
+	RejectedFull   uint64  `json:"rejected_full"`
+	QueueDepth     int     `json:"queue_depth"`
+	QueueCapacity  int     `json:"queue_capacity"`
+	CommandsPerSec float64 `json:"commands_per_sec"`
+	AvgQueueWaitUs float64 `json:"avg_queue_wait_us"`
+	AvgApplyUs     float64 `json:"avg_apply_us"`
+	UptimeSec      float64 `json:"uptime_sec"`
+}
+
+func newSequencer(marketID string, book *OrderBook, capacity int, handler CommandHandler) *Sequencer {
+	return &Sequencer{
+		marketID:  marketID,
+		book:      book,
+		ring:      newCommandRing(capacity),
+		wake:      make(chan struct{}, 1),
+		stop:      make(chan struct{}),
+		done:      make(chan struct{}),
+		handler:   handler,
+		startedAt: time.Now(),
+	}
+}
+
+// Submit enqueues a command without blocking. It returns false when the
+// market's queue is full, so callers can shed load instead of stalling, and
+// once the sequencer has been stopped.
+func (s *Sequencer) Submit(cmd Command) bool {
+	s.stopMu.RLock()
+	defer s.stopMu.RUnlock()
+	if s.stopped {
+		return false
+	}
+	cmd.MarketID = s.marketID
+	cmd.EnqueuedAt = time.Now()
+	if !s.ring.offer(cmd) {
+		s.rejected.Add(1)
+		return false
+	}
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+	return true
+}
+
+func (s *Sequencer) run() {
+	defer close(s.done)
+	for {
+		cmd, ok := s.ring.poll()
+		if !ok {
+			select {
+			case <-s.wake:
+				continue
+			case <-s.stop:
+				return
+			}
+		}
+
+		cmd.Sequence = s.sequence.Add(1)
+		start := time.Now()
+		s.handler(s.book, cmd)
+		s.waitNanos.Add(int64(start.Sub(cmd.EnqueuedAt)))
+		s.busyNanos.Add(int64(time.Since(start)))
+		s.processed.Add(1)
+	}
+}
+
+// StartAuctions submits a CommandRunAuction every interval until
+// StopAuctions, replacing any schedule already running.
+func (s *Sequencer) StartAuctions(interval time.Duration) {
+	s.StopAuctions()
+
+	s.auctionMu.Lock()
+	defer s.auctionMu.Unlock()
+	stop := make(chan struct{})
+	s.auctionStop = stop
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				s.Submit(Command{Kind: CommandRunAuction})
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
+
+func (s *Sequencer) StopAuctions() {
+	s.auctionMu.Lock()
+	defer s.auctionMu.Unlock()
+	if s.auctionStop != nil {
+		close(s.auctionStop)
+		s.auctionStop = nil
+	}
+}
+
+// Stop refuses further commands and halts the goroutine once the commands
+// already queued have drained.
+func (s *Sequencer) Stop() {
+	s.stopMu.Lock()
+	s.stopped = true
+	s.stopMu.Unlock()
+	s.StopAuctions()
+	for s.ring.len() > 0 {
+		select {
+		case <-s.done:
+			return
+		default:
+			time.Sleep(time.Millisecond)
+		}
+	}
+	close(s.stop)
+	<-s.done
+}
+
+func (s *Sequencer) Stats() SequencerStats {
+	processed := s.processed.Load()
+	uptime := time.Since(s.startedAt).Seconds()
+	stats := SequencerStats{
+		MarketID:      s.marketID,
+		Sequence:      s.sequence.Load(),
+		Processed:     processed,
+		RejectedFull:  s.rejected.Load(),
+		QueueDepth:    s.ring.len(),
+		QueueCapacity: s.ring.capacity(),
+		UptimeSec:     uptime,
+	}
+	if uptime > 0 {
+		stats.CommandsPerSec = float64(processed) / uptime
+	}
+	if processed > 0 {
+		stats.AvgQueueWaitUs = float64(s.waitNanos.Load()) / float64(processed) / 1e3
+		stats.AvgApplyUs = float64(s.busyNanos.Load()) / float64(processed) / 1e3
+	}
+	return stats
+}
+
+// SequencerPool lazily starts one sequencer per market.
+type SequencerPool struct {
+	mu         sync.RWMutex
+	markets    *MarketManager
+	sequencers map[string]*Sequencer
+	capacity   int
+	handler    CommandHandler
+	stopped    bool
+}
+
+func NewSequencerPool(markets *MarketManager, capacity int, handler CommandHandler) *SequencerPool {
+	return &SequencerPool{
+		markets:    markets,
+		sequencers: make(map[string]*Sequencer),
+		capacity:   capacity,
+		handler:    handler,
+	}
+}
+
+func (sp *SequencerPool) Get(marketID string) *Sequencer {
+	sp.mu.RLock()
+	s, ok := sp.sequencers[marketID]
+	sp.mu.RUnlock()
+	if ok {
+		return s
+	}
+
+	sp.mu.Lock()
+	defer sp.mu.Unlock()
+	if s, ok := sp.sequencers[marketID]; ok {
+		return s
+	}
+	s = newSequencer(marketID, sp.markets.GetOrderBook(marketID), sp.capacity, sp.handler)
+	sp.sequencers[marketID] = s
+	if sp.stopped {
+		// After StopAll, new markets get a sequencer that refuses commands.
+		s.stopped = true
+		close(s.done)
+		return s
+	}
+	go s.run()
+	return s
+}
+
+// Lookup returns the sequencer for marketID without starting one.
+func (sp *SequencerPool) Lookup(marketID string) (*Sequencer, bool) {
+	sp.mu.RLock()
+	defer sp.mu.RUnlock()
+	s, ok := sp.sequencers[marketID]
+	return s, ok
+}
+
+// StopAll drains and stops every sequencer. Sequencers requested afterwards
+// refuse every command.
+func (sp *SequencerPool) StopAll() {
+	sp.mu.Lock()
+	sp.stopped = true
+	all := make([]*Sequencer, 0, len(sp.sequencers))
+	for _, s := range sp.sequencers {
+		all = append(all, s)
+	}
+	sp.mu.Unlock()
+	for _, s := range all {
+		s.Stop()
+	}
+}
@@ -0,0 +1,33 @@
+package engine_test
+
+import (
+	"sync/atomic"
+	"testing"
+
+	"cs2-prediction-engine/internal/engine"
+)
+
+func TestSequencerRefusesCommandsAfterStop(t *testing.T) {
+	var ran atomic.Int64
+	pool := engine.NewSequencerPool(engine.NewMarketManager(), 16, func(*engine.OrderBook, engine.Command) {
+		ran.Add(1)
+	})
+
+	s := pool.Get("m1")
+	for i := 0; i < 3; i++ {
+		if !s.Submit(engine.Command{Kind: engine.CommandRunAuction}) {
+			t.Fatal("Submit refused before StopAll")
+		}
+	}
+	pool.StopAll()
+
+	if got := ran.Load(); got != 3 {
+		t.Errorf("ran %d commands before stopping, want the 3 queued", got)
+	}
+	if s.Submit(engine.Command{Kind: engine.CommandRunAuction}) {
+		t.Error("Submit accepted after StopAll")
+	}
+	if pool.Get("m2").Submit(engine.Command{Kind: engine.CommandRunAuction}) {
+		t.Error("sequencer started after StopAll accepted a command")
+	}
+}
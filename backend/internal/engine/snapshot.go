@@ -0,0 +1,137 @@
+package engine
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Snapshot is the engine state written to disk: market metadata, balances
+// and positions, and the orders still resting on the books.
+type Snapshot struct {
+	TakenAt     time.Time        `json:"taken_at"`
+	Markets     []MarketMetadata `json:"markets"`
+	Ledger      LedgerSnapshot   `json:"ledger"`
+	OpenOrders  []Order          `json:"open_orders"`
+	AuditRoot   string           `json:"audit_root,omitempty"`
+	AuditEvents int              `json:"audit_events,omitempty"`
+}
+
+// PersistenceStatus reports the outcome of the latest snapshot write.
+type PersistenceStatus struct {
+	Path        string    `json:"path"`
+	LastSavedAt time.Time `json:"last_saved_at,omitempty"`
+	LastError   string    `json:"last_error,omitempty"`
+	Saves       int       `json:"saves"`
+	// Archived is where the previous run's snapshot was moved on startup.
+	Archived string `json:"archived,omitempty"`
+}
+
+// SnapshotStore writes snapshots to one file, replacing it atomically so a
+// crash mid-write never leaves a torn snapshot behind. Archive moves the
+// previous run's snapshot aside before the first write of a new run.
+type SnapshotStore struct {
+	mu     sync.Mutex
+	path   string
+	status PersistenceStatus
+}
+
+func NewSnapshotStore(path string) *SnapshotStore {
+	return &SnapshotStore{path: path, status: PersistenceStatus{Path: path}}
+}
+
+func (s *SnapshotStore) Save(snap Snapshot) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	err := s.write(snap)
+	if err != nil {
+		s.status.LastError = err.Error()
+		return err
+	}
+	s.status.LastError = ""
+	s.status.LastSavedAt = snap.TakenAt
+	s.status.Saves++
+	return nil
+}
+
+func (s *SnapshotStore) write(snap Snapshot) error {
+	data, err := json.MarshalIndent(snap, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode snapshot: %w", err)
+	}
+	dir := filepath.Dir(s.path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("create snapshot directory: %w", err)
+	}
+	tmp, err := os.CreateTemp(dir, filepath.Base(s.path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("create snapshot file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("write snapshot: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("sync snapshot: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("close snapshot: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("replace snapshot: %w", err)
+	}
+	return nil
+}
+
+// Archive renames the snapshot left by a previous run to a name stamped with
+// its modification time, so this run's first save cannot overwrite it, and
+// removes all but the newest keep archives. It returns the archive's path, or
+// "" when there was no snapshot to move.
+func (s *SnapshotStore) Archive(keep int) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	info, err := os.Stat(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("stat snapshot: %w", err)
+	}
+	ext := filepath.Ext(s.path)
+	stem := strings.TrimSuffix(s.path, ext)
+	archived := stem + "." + info.ModTime().UTC().Format("20060102T150405.000Z") + ext
+	if err := os.Rename(s.path, archived); err != nil {
+		return "", fmt.Errorf("archive snapshot: %w", err)
+	}
+	s.status.Archived = archived
+
+	// The timestamps sort lexically, oldest first.
+	archives, err := filepath.Glob(stem + ".*" + ext)
+	if err != nil {
+		return archived, fmt.Errorf("list snapshot archives: %w", err)
+	}
+	sort.Strings(archives)
+	for len(archives) > keep {
+		if err := os.Remove(archives[0]); err != nil {
+			return archived, fmt.Errorf("prune snapshot archive: %w", err)
+		}
+		archives = archives[1:]
+	}
+	return archived, nil
+}
+
+func (s *SnapshotStore) Status() PersistenceStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.status
+}
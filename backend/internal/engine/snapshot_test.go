@@ -0,0 +1,88 @@
+package engine_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"cs2-prediction-engine/internal/engine"
+)
+
+func TestSnapshotArchiveKeepsPreviousRun(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "engine-snapshot.json")
+
+	previous := engine.NewSnapshotStore(path)
+	final := engine.Snapshot{TakenAt: time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC), AuditEvents: 42}
+	if err := previous.Save(final); err != nil {
+		t.Fatal(err)
+	}
+
+	// A restart archives the final snapshot before writing its own.
+	store := engine.NewSnapshotStore(path)
+	archived, err := store.Archive(5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if archived == "" {
+		t.Fatal("nothing archived")
+	}
+	if err := store.Save(engine.Snapshot{TakenAt: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(archived)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got engine.Snapshot
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.AuditEvents != 42 {
+		t.Errorf("archived snapshot has %d audit events, want the previous run's 42", got.AuditEvents)
+	}
+}
+
+func TestSnapshotArchiveWithoutPreviousRun(t *testing.T) {
+	store := engine.NewSnapshotStore(filepath.Join(t.TempDir(), "engine-snapshot.json"))
+	archived, err := store.Archive(5)
+	if err != nil || archived != "" {
+		t.Errorf("Archive() = %q, %v; want nothing to archive", archived, err)
+	}
+}
+
+func TestSnapshotArchivePrunesOldest(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "engine-snapshot.json")
+	store := engine.NewSnapshotStore(path)
+
+	var archives []string
+	for i := 0; i < 4; i++ {
+		if err := store.Save(engine.Snapshot{TakenAt: time.Now()}); err != nil {
+			t.Fatal(err)
+		}
+		// Give each run's snapshot a distinct modification time.
+		modTime := time.Date(2026, 10, 1, i, 0, 0, 0, time.UTC)
+		if err := os.Chtimes(path, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+		archived, err := store.Archive(2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		archives = append(archives, archived)
+	}
+
+	for i, archived := range archives {
+		_, err := os.Stat(archived)
+		if kept := i >= 2; kept != (err == nil) {
+			t.Errorf("archive %d (%s): kept = %t, want %t", i, filepath.Base(archived), err == nil, kept)
+		}
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("current snapshot still present after archive: %v", err)
+	}
+}
@@ -0,0 +1,206 @@
+package engine
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Trade is one execution on the tape. Price is quoted in the taker's outcome;
+// YesPrice normalizes it so a market's tape forms a single price series.
+type Trade struct {
+	ID            uint64    `json:"id"`
+	MarketID      string    `json:"market_id"`
+	Price         int64     `json:"price"`
+	YesPrice      int64     `json:"yes_price"`
+	Quantity      int64     `json:"quantity"`
+	AggressorSide Side      `json:"aggressor_side"`
+	Outcome       Outcome   `json:"outcome"`
+	MakerOrderID  uint64    `json:"maker_order_id"`
+	TakerOrderID  uint64    `json:"taker_order_id"`
+	Round         int       `json:"round,omitempty"`
+	Timestamp     time.Time `json:"timestamp"`
+}
+
+// RoundMarker records when the feed moved a market to a new round so charts can
+// overlay game progress on the price series.
+type RoundMarker struct {
+	Round          int       `json:"round"`
+	Map            string    `json:"map"`
+	TerroristScore int       `json:"terrorist_score"`
+	CTScore        int       `json:"ct_score"`
+	LastAction     string    `json:"last_action"`
+	FeedTimestamp  string    `json:"feed_timestamp,omitempty"`
+	Timestamp      time.Time `json:"timestamp"`
+}
+
+type Candle struct {
+	Start  time.Time `json:"start"`
+	Open   int64     `json:"open"`
+	High   int64     `json:"high"`
+	Low    int64     `json:"low"`
+	Close  int64     `json:"close"`
+	Volume int64     `json:"volume"`
+	Trades int       `json:"trades"`
+}
+
+type marketTape struct {
+	trades  []Trade
+	markers []RoundMarker
+	nextID  uint64
+}
+
+// TradeStore keeps the per-market trade tape and round markers.
+type TradeStore struct {
+	mu      sync.RWMutex
+	markets map[string]*marketTape
+}
+
+func NewTradeStore() *TradeStore {
+	return &TradeStore{
+		markets: make(map[string]*marketTape),
+	}
+}
+
+func (ts *TradeStore) tape(marketID string) *marketTape {
+	tape, ok := ts.markets[marketID]
+	if !ok {
+		tape = &marketTape{}
+		ts.markets[marketID] = tape
+	}
+	return tape
+}
+
+// Record appends a match to the tape from the perspective of the taker leg.
+func (ts *TradeStore) Record(marketID string, match Match, round int) Trade {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	taker := match.Taker
+	tape := ts.tape(marketID)
+	tape.nextID++
+	yesPrice := match.Price
+	if taker.Outcome == No {
+		yesPrice = 100 - match.Price
+	}
+	trade := Trade{
+		ID:            tape.nextID,
+		MarketID:      marketID,
+		Price:         match.Price,
+		YesPrice:      yesPrice,
+		Quantity:      match.Quantity,
+		AggressorSide: taker.Side,
+		Outcome:       taker.Outcome,
+		MakerOrderID:  match.MakerOrderID,
+		TakerOrderID:  match.TakerOrderID,
+		Round:         round,
+		Timestamp:     match.Timestamp,
+	}
+	tape.trades = append(tape.trades, trade)
+	return trade
+}
+
+// RecordRound adds a marker when the round differs from the last one seen.
+func (ts *TradeStore) RecordRound(marketID string, gs MarketGameState, at time.Time) {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	tape := ts.tape(marketID)
+	if n := len(tape.markers); n > 0 && tape.markers[n-1].Round == gs.Round && tape.markers[n-1].Map == gs.Map {
+		return
+	}
+	tape.markers = append(tape.markers, RoundMarker{
+		Round:          gs.Round,
+		Map:            gs.Map,
+		TerroristScore: gs.TerroristScore,
+		CTScore:        gs.CTScore,
+		LastAction:     gs.LastAction,
+		FeedTimestamp:  gs.Timestamp,
+		Timestamp:      at,
+	})
+}
+
+// ListTrades pages newest-first. A zero cursor starts at the latest trade;
+// otherwise only trades with an ID below the cursor are returned. The returned
+// cursor is zero when there are no older trades.
+func (ts *TradeStore) ListTrades(marketID string, cursor uint64, limit int) ([]Trade, uint64) {
+	ts.mu.RLock()
+	defer ts.mu.RUnlock()
+
+	tape, ok := ts.markets[marketID]
+	if !ok || limit <= 0 {
+		return []Trade{}, 0
+	}
+
+	// IDs are dense and start at 1, so trade N lives at index N-1.
+	end := len(tape.trades)
+	if cursor > 0 && int(cursor-1) < end {
+		end = int(cursor - 1)
+	}
+	start := end - limit
+	if start < 0 {
+		start = 0
+	}
+
+	out := make([]Trade, 0, end-start)
+	for i := end - 1; i >= start; i-- {
+		out = append(out, tape.trades[i])
+	}
+	var next uint64
+	if start > 0 {
+		next = tape.trades[start].ID
+	}
+	return out, next
+}
+
+// Candles aggregates the tape into OHLCV buckets of the given interval using
+// YES prices. Buckets without trades are omitted.
+func (ts *TradeStore) Candles(marketID string, interval time.Duration, since time.Time) []Candle {
+	ts.mu.RLock()
+	defer ts.mu.RUnlock()
+
+	out := make([]Candle, 0)
+	tape, ok := ts.markets[marketID]
+	if !ok || interval <= 0 {
+		return out
+	}
+
+	for _, t := range tape.trades {
+		if t.Timestamp.Before(since) {
+			continue
+		}
+		start := t.Timestamp.Truncate(interval)
+		n := len(out)
+		if n == 0 || !out[n-1].Start.Equal(start) {
+			out = append(out, Candle{
+				Start: start,
+				Open:  t.YesPrice,
+				High:  t.YesPrice,
+				Low:   t.YesPrice,
+			})
+			n++
+		}
+		c := &out[n-1]
+		c.High = max(c.High, t.YesPrice)
+		c.Low = min(c.Low, t.YesPrice)
+		c.Close = t.YesPrice
+		c.Volume += t.Quantity
+		c.Trades++
+	}
+	return out
+}
+
+func (ts *TradeStore) RoundMarkers(marketID string, since time.Time) []RoundMarker {
+	ts.mu.RLock()
+	defer ts.mu.RUnlock()
+
+	out := make([]RoundMarker, 0)
+	tape, ok := ts.markets[marketID]
+	if !ok {
+		return out
+	}
+	i := sort.Search(len(tape.markers), func(i int) bool {
+		return !tape.markers[i].Timestamp.Before(since)
+	})
+	return append(out, tape.markers[i:]...)
+}
@@ -19,20 +19,68 @@ const (
 )
 
 type Order struct {
-	ID        uint64    `json:"id"`
-	MarketID  string    `json:"market_id"` // E.g., "series_winner", "round_15_winner"
-	UserID    string    `json:"user_id"`
-	Side      Side      `json:"side"`
-	Outcome   Outcome   `json:"outcome"`
-	Price     int64     `json:"price"` // Fixed point: 1-99 for binary contracts
-	Quantity  int64     `json:"quantity"`
-	Timestamp time.Time `json:"timestamp"`
+	ID            uint64    `json:"id"`
+	ClientOrderID string    `json:"client_order_id,omitempty"` // Caller-chosen, unique per user
+	MarketID      string    `json:"market_id"`                 // E.g., "series_winner", "round_15_winner"
+	UserID        string    `json:"user_id"`
+	Side          Side      `json:"side"`
+	Outcome       Outcome   `json:"outcome"`
+	Price         int64     `json:"price"` // Fixed point: 1-99 for binary contracts
+	Quantity      int64     `json:"quantity"`
+	Sequence      uint64    `json:"sequence,omitempty"`        // Book-assigned time priority
+	CancelOnEvent bool      `json:"cancel_on_event,omitempty"` // Pulled by "flagged" event rules
+	Timestamp     time.Time `json:"timestamp"`
+
+	// SelfTradePrevention applies when this order would take liquidity from
+	// the same user; empty means cancel_newest.
+	SelfTradePrevention SelfTradeMode `json:"self_trade_prevention,omitempty"`
+	// CorrelationID ties the order's logs, audit entries and trace spans
+	// together; the engine assigns it when the order is received.
+	CorrelationID string `json:"correlation_id,omitempty"`
+	// ClientCorrelationID is the caller's own ID for the order, if it sent
+	// one. It is only logged and echoed back.
+	ClientCorrelationID string `json:"client_correlation_id,omitempty"`
 }
 
+// Match is one execution between a resting maker and an incoming taker.
+// Price is the taker's execution price in the taker's outcome; Maker and Taker
+// carry each leg's own price so complementary fills can be charged correctly.
 type Match struct {
-	MakerOrderID uint64    `json:"maker_order_id"`
-	TakerOrderID uint64    `json:"taker_order_id"`
-	Price        int64     `json:"price"`
-	Quantity     int64     `json:"quantity"`
-	Timestamp    time.Time `json:"timestamp"`
+	MakerOrderID  uint64    `json:"maker_order_id"`
+	TakerOrderID  uint64    `json:"taker_order_id"`
+	Price         int64     `json:"price"`
+	Quantity      int64     `json:"quantity"`
+	Complementary bool      `json:"complementary"`
+	Auction       bool      `json:"auction,omitempty"`  // Cleared in a batch auction at a uniform price
+	Sequence      uint64    `json:"sequence,omitempty"` // Sequencer command that produced the match
+	Maker         MatchLeg  `json:"maker"`
+	Taker         MatchLeg  `json:"taker"`
+	Timestamp     time.Time `json:"timestamp"`
+}
+
+// MatchLeg is one side of a match, priced in that order's own outcome.
+type MatchLeg struct {
+	OrderID uint64  `json:"order_id"`
+	UserID  string  `json:"user_id"`
+	Side    Side    `json:"side"`
+	Outcome Outcome `json:"outcome"`
+	Price   int64   `json:"price"`
+
+	CorrelationID string `json:"correlation_id,omitempty"`
+}
+
+// Quote is a top-of-book snapshot in YES terms. Zero means the value is absent,
+// since tradable prices are 1-99.
+type Quote struct {
+	BestBid   int64 `json:"best_bid,omitempty"`
+	BestAsk   int64 `json:"best_ask,omitempty"`
+	LastPrice int64 `json:"last_price,omitempty"`
+}
+
+// Mid returns the midpoint of a two-sided quote.
+func (q Quote) Mid() (int64, bool) {
+	if q.BestBid == 0 || q.BestAsk == 0 {
+		return 0, false
+	}
+	return (q.BestBid + q.BestAsk) / 2, true
 }
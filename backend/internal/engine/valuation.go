@@ -0,0 +1,199 @@
+package engine
+
+// MarkSource selects the price used to value open positions.
+type MarkSource string
+
+const (
+	MarkMid   MarkSource = "mid"
+	MarkLast  MarkSource = "last"
+	MarkModel MarkSource = "model"
+)
+
+func ParseMarkSource(s string) (MarkSource, bool) {
+	switch MarkSource(s) {
+	case MarkMid, MarkLast, MarkModel:
+		return MarkSource(s), true
+	case "":
+		return MarkMid, true
+	}
+	return "", false
+}
+
+const (
+	regulationRoundsToWin = 13
+	overtimeRegulation    = 12
+	overtimeHalfLength    = 3
+)
+
+// FairValue estimates the YES price (terrorist side wins the series) from the
+// live score, treating every remaining round as a coin flip in an MR12 race
+// with MR3 overtime. Without game state the market is valued at 50.
+func FairValue(gs *MarketGameState) int64 {
+	if gs == nil {
+		return 50
+	}
+	t, ct := gs.TerroristScore, gs.CTScore
+	if gs.Phase == "ended" {
+		if t > ct {
+			return 99
+		}
+		return 1
+	}
+
+	target := regulationRoundsToWin
+	if t >= overtimeRegulation && ct >= overtimeRegulation {
+		// Each overtime is first to 4 of 6; a 3-3 overtime starts a fresh one.
+		completed := (min(int64(t), int64(ct)) - overtimeRegulation) / overtimeHalfLength
+		target = overtimeRegulation + int(completed)*overtimeHalfLength + overtimeHalfLength + 1
+	}
+
+	p := raceProbability(target-t, target-ct)
+	price := int64(p*100 + 0.5)
+	if price < 1 {
+		price = 1
+	}
+	if price > 99 {
+		price = 99
+	}
+	return price
+}
+
+// raceProbability is the chance a side needing a rounds wins before a side
+// needing b rounds when each round is 50/50.
+func raceProbability(a, b int) float64 {
+	if a <= 0 {
+		return 1
+	}
+	if b <= 0 {
+		return 0
+	}
+	// P = sum_{k=0}^{b-1} C(a-1+k, k) / 2^(a+k)
+	total := 0.0
+	coeff := 1.0
+	scale := 1.0
+	for i := 0; i < a; i++ {
+		scale /= 2
+	}
+	for k := 0; k < b; k++ {
+		if k > 0 {
+			coeff = coeff * float64(a-1+k) / float64(k)
+			scale /= 2
+		}
+		total += coeff * scale
+	}
+	return total
+}
+
+// ResolveMark picks the YES mark for a market, falling back from the preferred
+// source through mid, last trade and the model so a price is always available.
+func ResolveMark(quote Quote, gs *MarketGameState, preferred MarkSource) (int64, MarkSource) {
+	order := []MarkSource{preferred, MarkMid, MarkLast, MarkModel}
+	for _, source := range order {
+		switch source {
+		case MarkMid:
+			if mid, ok := quote.Mid(); ok {
+				return mid, MarkMid
+			}
+		case MarkLast:
+			if quote.LastPrice > 0 {
+				return quote.LastPrice, MarkLast
+			}
+		case MarkModel:
+			return FairValue(gs), MarkModel
+		}
+	}
+	return FairValue(gs), MarkModel
+}
+
+type PositionValuation struct {
+	MarketID      string     `json:"market_id"`
+	YesShares     int64      `json:"yes_shares"`
+	NoShares      int64      `json:"no_shares"`
+	YesAvgPrice   float64    `json:"yes_avg_price"`
+	NoAvgPrice    float64    `json:"no_avg_price"`
+	CostBasis     int64      `json:"cost_basis"`
+	MarkPrice     int64      `json:"mark_price"`
+	MarkSource    MarkSource `json:"mark_source"`
+	MarketValue   int64      `json:"market_value"`
+	UnrealizedPnL int64      `json:"unrealized_pnl"`
+	MaxPayout     int64      `json:"max_payout"`
+	MaxLoss       int64      `json:"max_loss"`
+}
+
+type PortfolioTotals struct {
+	CostBasis     int64 `json:"cost_basis"`
+	MarketValue   int64 `json:"market_value"`
+	UnrealizedPnL int64 `json:"unrealized_pnl"`
+	MaxPayout     int64 `json:"max_payout"`
+	MaxLoss       int64 `json:"max_loss"`
+	Available     int64 `json:"available"`
+	Reserved      int64 `json:"reserved"`
+	RealizedPnL   int64 `json:"realized_pnl"`
+	Equity        int64 `json:"equity"`
+}
+
+type Portfolio struct {
+	UserID     string              `json:"user_id"`
+	MarkSource MarkSource          `json:"mark_source"`
+	Positions  []PositionValuation `json:"positions"`
+	Totals     PortfolioTotals     `json:"totals"`
+}
+
+// ValuePosition marks a position at a YES price. A NO share is worth 100-mark.
+func ValuePosition(p MarketPosition, mark int64, source MarkSource) PositionValuation {
+	v := PositionValuation{
+		MarketID:   p.MarketID,
+		YesShares:  p.YesShares,
+		NoShares:   p.NoShares,
+		CostBasis:  p.YesCost + p.NoCost,
+		MarkPrice:  mark,
+		MarkSource: source,
+	}
+	if p.YesShares > 0 {
+		v.YesAvgPrice = float64(p.YesCost) / float64(p.YesShares)
+	}
+	if p.NoShares > 0 {
+		v.NoAvgPrice = float64(p.NoCost) / float64(p.NoShares)
+	}
+
+	v.MarketValue = p.YesShares*mark + p.NoShares*(100-mark)
+	v.UnrealizedPnL = v.MarketValue - v.CostBasis
+
+	yesPayout := p.YesShares * 100
+	noPayout := p.NoShares * 100
+	v.MaxPayout = max(yesPayout, noPayout)
+	if loss := v.CostBasis - min(yesPayout, noPayout); loss > 0 {
+		v.MaxLoss = loss
+	}
+	return v
+}
+
+// BuildPortfolio values every unsettled position using markFor and rolls the
+// results up with the account balances.
+func BuildPortfolio(account Account, positions []MarketPosition, source MarkSource, markFor func(marketID string) (int64, MarkSource)) Portfolio {
+	portfolio := Portfolio{
+		UserID:     account.UserID,
+		MarkSource: source,
+		Positions:  make([]PositionValuation, 0, len(positions)),
+	}
+	totals := &portfolio.Totals
+	for _, p := range positions {
+		if p.Settled || (p.YesShares == 0 && p.NoShares == 0) {
+			continue
+		}
+		mark, used := markFor(p.MarketID)
+		v := ValuePosition(p, mark, used)
+		portfolio.Positions = append(portfolio.Positions, v)
+
+		totals.CostBasis += v.CostBasis
+		totals.MarketValue += v.MarketValue
+		totals.UnrealizedPnL += v.UnrealizedPnL
+		totals.MaxPayout += v.MaxPayout
+		totals.MaxLoss += v.MaxLoss
+	}
+	totals.Available = account.Available
+	totals.Reserved = account.Reserved
+	totals.RealizedPnL = account.RealizedPnL
+	totals.Equity = account.Available + account.Reserved + totals.MarketValue
+	return portfolio
+}
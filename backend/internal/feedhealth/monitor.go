@@ -0,0 +1,311 @@
+package feedhealth
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+type Severity string
+
+const (
+	// SeverityWarning is recorded and reported but leaves trading open.
+	SeverityWarning Severity = "warning"
+	// SeverityCritical suspends the market until the rule recovers.
+	SeverityCritical Severity = "critical"
+)
+
+type RecoveryMode string
+
+const (
+	// RecoverAuto clears a violation after HealthyUpdates clean checks in a row.
+	RecoverAuto RecoveryMode = "auto"
+	// RecoverManual holds a violation until an operator clears it.
+	RecoverManual RecoveryMode = "manual"
+)
+
+type Recovery struct {
+	Mode           RecoveryMode `json:"mode"`
+	HealthyUpdates int          `json:"healthy_updates,omitempty"`
+}
+
+// RuleConfig binds a rule to how the monitor reacts when it fires.
+type RuleConfig struct {
+	Rule     Rule
+	Severity Severity
+	Recovery Recovery
+}
+
+// RuleSettings tunes the thresholds of the default rule set.
+type RuleSettings struct {
+	StaleTimeout time.Duration
+	MaxRoundJump int
+	// HealthyUpdates is how many clean updates lift a critical anomaly.
+	HealthyUpdates int
+}
+
+var DefaultRuleSettings = RuleSettings{
+	StaleTimeout:   30 * time.Second,
+	MaxRoundJump:   2,
+	HealthyUpdates: 3,
+}
+
+// DefaultRules mirrors the old score-anomaly behaviour (suspend, resume after
+// three healthy updates) and adds the stale, timestamp, limit and map checks.
+func DefaultRules() []RuleConfig {
+	return Rules(DefaultRuleSettings)
+}
+
+// Rules builds the default rule set with the given thresholds.
+func Rules(s RuleSettings) []RuleConfig {
+	auto := func(n int) Recovery { return Recovery{Mode: RecoverAuto, HealthyUpdates: n} }
+	return []RuleConfig{
+		{Rule: StaleFeed{Timeout: s.StaleTimeout}, Severity: SeverityCritical, Recovery: auto(1)},
+		{Rule: TimestampRegression{}, Severity: SeverityCritical, Recovery: auto(s.HealthyUpdates)},
+		{Rule: RoundJump{MaxJump: s.MaxRoundJump}, Severity: SeverityCritical, Recovery: auto(s.HealthyUpdates)},
+		{Rule: ScoreConsistency{}, Severity: SeverityCritical, Recovery: auto(s.HealthyUpdates)},
+		{Rule: ScoreLimit{}, Severity: SeverityCritical, Recovery: Recovery{Mode: RecoverManual}},
+		{Rule: MapChange{}, Severity: SeverityWarning, Recovery: auto(1)},
+	}
+}
+
+// RuleStatus is the live state of one rule for one market.
+type RuleStatus struct {
+	Rule          string     `json:"rule"`
+	Severity      Severity   `json:"severity"`
+	Recovery      Recovery   `json:"recovery"`
+	Violated      bool       `json:"violated"`
+	Detail        string     `json:"detail,omitempty"`
+	Since         *time.Time `json:"since,omitempty"`
+	HealthyStreak int        `json:"healthy_streak"`
+	Violations    int        `json:"violations"`
+}
+
+// Event is one entry in a market's health history.
+type Event struct {
+	Rule     string    `json:"rule"`
+	Kind     string    `json:"kind"` // "violated" or "recovered"
+	Severity Severity  `json:"severity"`
+	Detail   string    `json:"detail,omitempty"`
+	At       time.Time `json:"at"`
+}
+
+// Transition is a rule entering or leaving violation, returned so the caller
+// can suspend or resume the market.
+type Transition struct {
+	MarketID string
+	Rule     string
+	Severity Severity
+	Violated bool
+	Detail   string
+}
+
+type Report struct {
+	MarketID   string       `json:"market_id"`
+	Healthy    bool         `json:"healthy"`
+	Blocking   []string     `json:"blocking"`
+	LastUpdate *time.Time   `json:"last_update,omitempty"`
+	Rules      []RuleStatus `json:"rules"`
+	History    []Event      `json:"history"`
+}
+
+type marketHealth struct {
+	last    Observation
+	hasLast bool
+	rules   []RuleStatus
+	history []Event
+}
+
+// Monitor evaluates the configured rules against every market's feed.
+type Monitor struct {
+	mu           sync.Mutex
+	rules        []RuleConfig
+	historyLimit int
+	markets      map[string]*marketHealth
+}
+
+func NewMonitor(rules []RuleConfig, historyLimit int) *Monitor {
+	return &Monitor{
+		rules:        rules,
+		historyLimit: historyLimit,
+		markets:      make(map[string]*marketHealth),
+	}
+}
+
+func (m *Monitor) market(marketID string) *marketHealth {
+	mh, ok := m.markets[marketID]
+	if !ok {
+		mh = &marketHealth{rules: make([]RuleStatus, len(m.rules))}
+		for i, cfg := range m.rules {
+			mh.rules[i] = RuleStatus{Rule: cfg.Rule.Name(), Severity: cfg.Severity, Recovery: cfg.Recovery}
+		}
+		m.markets[marketID] = mh
+	}
+	return mh
+}
+
+// Observe runs every rule against obs and returns the rules that changed state.
+func (m *Monitor) Observe(obs Observation) []Transition {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	mh := m.market(obs.MarketID)
+	var out []Transition
+	for i, cfg := range m.rules {
+		detail, violated := "", false
+		if mh.hasLast {
+			detail, violated = cfg.Rule.Check(mh.last, obs)
+		}
+		if t, ok := m.apply(mh, i, obs.MarketID, detail, violated, obs.ReceivedAt); ok {
+			out = append(out, t)
+		}
+	}
+	mh.last = obs
+	mh.hasLast = true
+	return out
+}
+
+// Tick evaluates idle rules for every market that has seen at least one
+// update.
+func (m *Monitor) Tick(now time.Time) []Transition {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var out []Transition
+	for marketID, mh := range m.markets {
+		if !mh.hasLast {
+			continue
+		}
+		for i, cfg := range m.rules {
+			idle, ok := cfg.Rule.(IdleRule)
+			if !ok || mh.rules[i].Violated {
+				continue
+			}
+			if detail, violated := idle.CheckIdle(mh.last, now); violated {
+				if t, ok := m.apply(mh, i, marketID, detail, true, now); ok {
+					out = append(out, t)
+				}
+			}
+		}
+	}
+	return out
+}
+
+func (m *Monitor) apply(mh *marketHealth, i int, marketID, detail string, violated bool, at time.Time) (Transition, bool) {
+	status := &mh.rules[i]
+	cfg := m.rules[i]
+
+	if violated {
+		status.HealthyStreak = 0
+		if status.Violated {
+			status.Detail = detail
+			return Transition{}, false
+		}
+		status.Violated = true
+		status.Detail = detail
+		since := at
+		status.Since = &since
+		status.Violations++
+		m.record(mh, Event{Rule: status.Rule, Kind: "violated", Severity: cfg.Severity, Detail: detail, At: at})
+		return Transition{MarketID: marketID, Rule: status.Rule, Severity: cfg.Severity, Violated: true, Detail: detail}, true
+	}
+
+	if !status.Violated || cfg.Recovery.Mode != RecoverAuto {
+		return Transition{}, false
+	}
+	status.HealthyStreak++
+	if status.HealthyStreak < cfg.Recovery.HealthyUpdates {
+		return Transition{}, false
+	}
+	return m.recover(mh, i, marketID, "healthy_streak", at), true
+}
+
+func (m *Monitor) recover(mh *marketHealth, i int, marketID, detail string, at time.Time) Transition {
+	status := &mh.rules[i]
+	status.Violated = false
+	status.Detail = ""
+	status.Since = nil
+	status.HealthyStreak = 0
+	m.record(mh, Event{Rule: status.Rule, Kind: "recovered", Severity: status.Severity, Detail: detail, At: at})
+	return Transition{MarketID: marketID, Rule: status.Rule, Severity: status.Severity, Detail: detail}
+}
+
+func (m *Monitor) record(mh *marketHealth, e Event) {
+	mh.history = append(mh.history, e)
+	if m.historyLimit > 0 && len(mh.history) > m.historyLimit {
+		mh.history = mh.history[len(mh.history)-m.historyLimit:]
+	}
+}
+
+// Clear recovers every violated rule for marketID, including those that wait
+// for an operator.
+func (m *Monitor) Clear(marketID, reason string) []Transition {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	mh, ok := m.markets[marketID]
+	if !ok {
+		return nil
+	}
+	var out []Transition
+	now := time.Now()
+	for i := range mh.rules {
+		if mh.rules[i].Violated {
+			out = append(out, m.recover(mh, i, marketID, reason, now))
+		}
+	}
+	return out
+}
+
+// Blocking lists the critical rules currently violated for marketID.
+func (m *Monitor) Blocking(marketID string) []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	mh, ok := m.markets[marketID]
+	if !ok {
+		return nil
+	}
+	return blocking(mh)
+}
+
+func blocking(mh *marketHealth) []string {
+	out := []string{}
+	for _, s := range mh.rules {
+		if s.Violated && s.Severity == SeverityCritical {
+			out = append(out, s.Rule)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// Forget stops idle checks for a market once it has settled. Its report and
+// history remain available.
+func (m *Monitor) Forget(marketID string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if mh, ok := m.markets[marketID]; ok {
+		// Keep the report but stop idle checks.
+		mh.hasLast = false
+	}
+}
+
+func (m *Monitor) Report(marketID string) Report {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	mh := m.market(marketID)
+	report := Report{
+		MarketID: marketID,
+		Blocking: blocking(mh),
+		Rules:    append([]RuleStatus(nil), mh.rules...),
+		History:  append([]Event{}, mh.history...),
+	}
+	report.Healthy = len(report.Blocking) == 0
+	if !mh.last.ReceivedAt.IsZero() {
+		at := mh.last.ReceivedAt
+		report.LastUpdate = &at
+	}
+	return report
+}
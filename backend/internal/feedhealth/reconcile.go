@@ -0,0 +1,270 @@
+package feedhealth
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+var ErrUnknownSource = errors.New("unknown feed source")
+
+const (
+	SourceGRID   = "grid_adapter"
+	SourceReplay = "mock_replay"
+	SourceManual = "manual"
+)
+
+// ReconcileConfig lists the feed sources a market accepts, in priority order,
+// and how many of them must agree before a result settles.
+type ReconcileConfig struct {
+	Sources []string
+	Quorum  int
+	// LeaderTimeout is how long a source may stay silent before the next
+	// source in priority order takes over driving the market.
+	LeaderTimeout time.Duration
+}
+
+// DefaultReconcileConfig settles a market only once two sources agree, so a
+// single compromised or broken feed cannot settle it alone.
+var DefaultReconcileConfig = ReconcileConfig{
+	Sources:       []string{SourceGRID, SourceReplay, SourceManual},
+	Quorum:        2,
+	LeaderTimeout: 15 * time.Second,
+}
+
+// SourceReport is one source's view of a series at one update.
+type SourceReport struct {
+	Source         string    `json:"source"`
+	Map            string    `json:"map"`
+	Round          int       `json:"round"`
+	TerroristScore int       `json:"terrorist_score"`
+	CTScore        int       `json:"ct_score"`
+	Phase          string    `json:"phase"`
+	ReceivedAt     time.Time `json:"received_at"`
+}
+
+func (r SourceReport) sameRound(o SourceReport) bool {
+	return r.Map == o.Map && r.Round == o.Round
+}
+
+func (r SourceReport) sameScore(o SourceReport) bool {
+	return r.TerroristScore == o.TerroristScore && r.CTScore == o.CTScore
+}
+
+// Winner is the side leading in the report, as used for settlement.
+func (r SourceReport) Winner() string {
+	if r.TerroristScore > r.CTScore {
+		return "terrorist"
+	}
+	return "ct"
+}
+
+// Verdict is what the reconciler concluded from one report.
+type Verdict struct {
+	// Lead is true when the report came from the source currently driving
+	// the market.
+	Lead   bool
+	Leader string
+	// Disagreement is set while sources report different scores for the same
+	// map and round, or different final results.
+	Disagreement string
+	// Settle is true once Quorum sources report the series ended with the
+	// same winner. SettleSources lists them.
+	Settle        bool
+	SettleSources []string
+}
+
+// roundsKept bounds the per-source history used to compare rounds.
+const roundsKept = 32
+
+type seriesSources struct {
+	rounds       map[string][]SourceReport
+	finals       map[string]SourceReport
+	disagreement string
+}
+
+// Reconciler cross-checks series_state from several sources.
+type Reconciler struct {
+	mu      sync.Mutex
+	cfg     ReconcileConfig
+	markets map[string]*seriesSources
+}
+
+func NewReconciler(cfg ReconcileConfig) *Reconciler {
+	if cfg.Quorum < 1 {
+		cfg.Quorum = 1
+	}
+	return &Reconciler{
+		cfg:     cfg,
+		markets: make(map[string]*seriesSources),
+	}
+}
+
+func (rc *Reconciler) known(source string) bool {
+	for _, s := range rc.cfg.Sources {
+		if s == source {
+			return true
+		}
+	}
+	return false
+}
+
+// DefaultSource is assumed for updates that do not name a source.
+func (rc *Reconciler) DefaultSource() string {
+	if len(rc.cfg.Sources) == 0 {
+		return ""
+	}
+	return rc.cfg.Sources[0]
+}
+
+func (rc *Reconciler) series(marketID string) *seriesSources {
+	ss, ok := rc.markets[marketID]
+	if !ok {
+		ss = &seriesSources{
+			rounds: make(map[string][]SourceReport),
+			finals: make(map[string]SourceReport),
+		}
+		rc.markets[marketID] = ss
+	}
+	return ss
+}
+
+// Report records one source's update and reconciles it against the others.
+func (rc *Reconciler) Report(marketID string, report SourceReport) (Verdict, error) {
+	if !rc.known(report.Source) {
+		return Verdict{}, fmt.Errorf("%w: %q", ErrUnknownSource, report.Source)
+	}
+
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	ss := rc.series(marketID)
+	history := append(ss.rounds[report.Source], report)
+	if len(history) > roundsKept {
+		history = history[len(history)-roundsKept:]
+	}
+	ss.rounds[report.Source] = history
+
+	// Compare against every other source that has reported the same round.
+	var conflict string
+	agreed := false
+	for source, reports := range ss.rounds {
+		if source == report.Source {
+			continue
+		}
+		for i := len(reports) - 1; i >= 0; i-- {
+			other := reports[i]
+			if !other.sameRound(report) {
+				continue
+			}
+			if other.sameScore(report) {
+				agreed = true
+			} else {
+				conflict = fmt.Sprintf("%s reports %d-%d, %s reports %d-%d on %s round %d",
+					report.Source, report.TerroristScore, report.CTScore,
+					source, other.TerroristScore, other.CTScore, report.Map, report.Round)
+			}
+			break
+		}
+	}
+	if conflict != "" {
+		ss.disagreement = conflict
+	} else if agreed {
+		ss.disagreement = ""
+	}
+
+	verdict := Verdict{Leader: rc.leaderLocked(ss, report.ReceivedAt)}
+	verdict.Lead = verdict.Leader == report.Source
+
+	if report.Phase == "ended" {
+		ss.finals[report.Source] = report
+	}
+	byWinner := map[string][]string{}
+	for source, final := range ss.finals {
+		byWinner[final.Winner()] = append(byWinner[final.Winner()], source)
+	}
+	if len(byWinner) > 1 {
+		ss.disagreement = "sources report different winners"
+	}
+	if ss.disagreement == "" && report.Phase == "ended" {
+		if sources := byWinner[report.Winner()]; len(sources) >= rc.cfg.Quorum {
+			sort.Strings(sources)
+			verdict.Settle = true
+			verdict.SettleSources = sources
+		}
+	}
+	verdict.Disagreement = ss.disagreement
+	return verdict, nil
+}
+
+// leaderLocked picks the highest-priority source heard from within the
+// leader timeout, falling back to the most recent reporter.
+func (rc *Reconciler) leaderLocked(ss *seriesSources, now time.Time) string {
+	var latest string
+	var latestAt time.Time
+	for _, source := range rc.cfg.Sources {
+		reports := ss.rounds[source]
+		if len(reports) == 0 {
+			continue
+		}
+		at := reports[len(reports)-1].ReceivedAt
+		if now.Sub(at) <= rc.cfg.LeaderTimeout {
+			return source
+		}
+		if at.After(latestAt) {
+			latest, latestAt = source, at
+		}
+	}
+	return latest
+}
+
+func (rc *Reconciler) Disagreement(marketID string) string {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	if ss, ok := rc.markets[marketID]; ok {
+		return ss.disagreement
+	}
+	return ""
+}
+
+// Forget drops all source state for a market, e.g. once it settles.
+func (rc *Reconciler) Forget(marketID string) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	delete(rc.markets, marketID)
+}
+
+// SourcesStatus summarises every source's latest report for a market.
+type SourcesStatus struct {
+	Leader       string                  `json:"leader,omitempty"`
+	Quorum       int                     `json:"quorum"`
+	Disagreement string                  `json:"disagreement,omitempty"`
+	Latest       map[string]SourceReport `json:"latest"`
+	Finals       map[string]SourceReport `json:"finals,omitempty"`
+}
+
+func (rc *Reconciler) Status(marketID string) SourcesStatus {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	ss, ok := rc.markets[marketID]
+	if !ok {
+		return SourcesStatus{Quorum: rc.cfg.Quorum, Latest: map[string]SourceReport{}}
+	}
+	status := SourcesStatus{
+		Leader:       rc.leaderLocked(ss, time.Now()),
+		Quorum:       rc.cfg.Quorum,
+		Disagreement: ss.disagreement,
+		Latest:       make(map[string]SourceReport, len(ss.rounds)),
+		Finals:       make(map[string]SourceReport, len(ss.finals)),
+	}
+	for source, reports := range ss.rounds {
+		status.Latest[source] = reports[len(reports)-1]
+	}
+	for source, final := range ss.finals {
+		status.Finals[source] = final
+	}
+	return status
+}
@@ -0,0 +1,162 @@
+package feedhealth
+
+import (
+	"fmt"
+	"time"
+
+	"cs2-prediction-engine/internal/engine"
+)
+
+// Observation is one series_state as the server received it.
+type Observation struct {
+	MarketID   string
+	State      engine.MarketGameState
+	FeedTime   time.Time // Zero when the feed sent no usable timestamp
+	ReceivedAt time.Time
+}
+
+// Rule inspects consecutive observations. Check returns a human-readable
+// detail and true when next violates the rule given prev.
+type Rule interface {
+	Name() string
+	Check(prev, next Observation) (string, bool)
+}
+
+// IdleRule is a Rule that can also fail between updates, such as a feed that
+// has gone quiet. CheckIdle is evaluated on every monitor tick.
+type IdleRule interface {
+	Rule
+	CheckIdle(last Observation, now time.Time) (string, bool)
+}
+
+// StaleFeed fires when no series_state has arrived within Timeout.
+type StaleFeed struct {
+	Timeout time.Duration
+}
+
+func (StaleFeed) Name() string { return "stale_feed" }
+
+func (StaleFeed) Check(prev, next Observation) (string, bool) { return "", false }
+
+func (r StaleFeed) CheckIdle(last Observation, now time.Time) (string, bool) {
+	if last.State.Phase == "ended" {
+		return "", false
+	}
+	if idle := now.Sub(last.ReceivedAt); idle > r.Timeout {
+		return fmt.Sprintf("no series_state for %s (timeout %s)", idle.Round(time.Second), r.Timeout), true
+	}
+	return "", false
+}
+
+// TimestampRegression fires when the feed's own timestamps go backwards.
+type TimestampRegression struct{}
+
+func (TimestampRegression) Name() string { return "timestamp_regression" }
+
+func (TimestampRegression) Check(prev, next Observation) (string, bool) {
+	if prev.FeedTime.IsZero() || next.FeedTime.IsZero() || !next.FeedTime.Before(prev.FeedTime) {
+		return "", false
+	}
+	return fmt.Sprintf("feed timestamp went back %s", prev.FeedTime.Sub(next.FeedTime)), true
+}
+
+// RoundJump fires when the round moves backwards or skips ahead by more than
+// MaxJump on the same map.
+type RoundJump struct {
+	MaxJump int
+}
+
+func (RoundJump) Name() string { return "round_jump" }
+
+func (r RoundJump) Check(prev, next Observation) (string, bool) {
+	if next.State.Map != prev.State.Map {
+		return "", false
+	}
+	delta := next.State.Round - prev.State.Round
+	if delta < 0 || delta > r.MaxJump {
+		return fmt.Sprintf("round %d -> %d (max jump %d)", prev.State.Round, next.State.Round, r.MaxJump), true
+	}
+	return "", false
+}
+
+// ScoreConsistency fires when the score moves without the round advancing,
+// or by more points than rounds played.
+type ScoreConsistency struct{}
+
+func (ScoreConsistency) Name() string { return "score_consistency" }
+
+func (ScoreConsistency) Check(prev, next Observation) (string, bool) {
+	if next.State.Map != prev.State.Map {
+		return "", false
+	}
+	roundDelta := next.State.Round - prev.State.Round
+	scoreDelta := abs(next.State.TerroristScore-prev.State.TerroristScore) + abs(next.State.CTScore-prev.State.CTScore)
+	if roundDelta == 0 && scoreDelta > 0 {
+		return fmt.Sprintf("score moved by %d within round %d", scoreDelta, next.State.Round), true
+	}
+	if roundDelta > 0 && scoreDelta > roundDelta {
+		return fmt.Sprintf("score moved by %d over %d rounds", scoreDelta, roundDelta), true
+	}
+	return "", false
+}
+
+// ScoreLimit fires on scores no MR12 map can reach: more than 13 in
+// regulation, or more than a 4-round lead inside an MR3 overtime block.
+type ScoreLimit struct{}
+
+func (ScoreLimit) Name() string { return "score_limit" }
+
+func (ScoreLimit) Check(prev, next Observation) (string, bool) {
+	t, ct := next.State.TerroristScore, next.State.CTScore
+	if !validMapScore(t, ct) {
+		return fmt.Sprintf("impossible score %d-%d", t, ct), true
+	}
+	return "", false
+}
+
+// MapChange fires when the map changes before the previous map was decided.
+type MapChange struct{}
+
+func (MapChange) Name() string { return "map_change" }
+
+func (MapChange) Check(prev, next Observation) (string, bool) {
+	if prev.State.Map == "" || next.State.Map == prev.State.Map {
+		return "", false
+	}
+	if mapDecided(prev.State.TerroristScore, prev.State.CTScore) {
+		return "", false
+	}
+	return fmt.Sprintf("map %s -> %s at %d-%d", prev.State.Map, next.State.Map, prev.State.TerroristScore, prev.State.CTScore), true
+}
+
+// overtimeBase is the score both sides held when the current MR3 overtime
+// block started, given the trailing side's score.
+func overtimeBase(trailing int) int {
+	return 12 + 3*((trailing-12)/3)
+}
+
+func validMapScore(a, b int) bool {
+	if a < 0 || b < 0 {
+		return false
+	}
+	hi, lo := max(a, b), min(a, b)
+	if lo < 12 {
+		return hi <= 13
+	}
+	return hi-overtimeBase(lo) <= 4
+}
+
+func mapDecided(a, b int) bool {
+	hi, lo := max(a, b), min(a, b)
+	if lo < 12 {
+		return hi == 13
+	}
+	return hi-overtimeBase(lo) == 4
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
@@ -0,0 +1,54 @@
+package gateway
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// FeedAuth authenticates feed adapters on the WebSocket upgrade. Each token
+// stands for one feed source, so an adapter can only ever report as itself.
+type FeedAuth struct {
+	tokens map[string]string
+}
+
+// ParseFeedTokens reads "token:source" entries separated by commas, e.g. the
+// FEED_TOKENS environment variable. The manual source is reserved for
+// operators and cannot be given to a token.
+func ParseFeedTokens(spec string, manual string) (map[string]string, error) {
+	tokens := make(map[string]string)
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		fields := strings.Split(entry, ":")
+		if len(fields) != 2 || fields[0] == "" || fields[1] == "" {
+			return nil, fmt.Errorf("invalid feed token entry %q (want token:source)", entry)
+		}
+		if fields[1] == manual {
+			return nil, fmt.Errorf("feed source %s is reserved for operators", manual)
+		}
+		tokens[fields[0]] = fields[1]
+	}
+	return tokens, nil
+}
+
+func NewFeedAuth(tokens map[string]string) *FeedAuth {
+	return &FeedAuth{tokens: tokens}
+}
+
+// Source returns the feed source behind the request's bearer token. presented
+// reports whether the request carried a token at all, so callers can refuse a
+// bad token outright while still serving plain clients.
+func (fa *FeedAuth) Source(r *http.Request) (source string, presented, ok bool) {
+	header := r.Header.Get("Authorization")
+	if !strings.HasPrefix(header, "Bearer ") {
+		return "", false, false
+	}
+	token := strings.TrimPrefix(header, "Bearer ")
+	if fa != nil {
+		source, ok = fa.tokens[token]
+	}
+	return source, true, ok
+}
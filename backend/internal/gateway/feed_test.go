@@ -0,0 +1,49 @@
+package gateway_test
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"cs2-prediction-engine/internal/gateway"
+)
+
+func TestParseFeedTokens(t *testing.T) {
+	tokens, err := gateway.ParseFeedTokens(" a:grid_adapter , b:mock_replay,", "manual")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tokens) != 2 || tokens["a"] != "grid_adapter" || tokens["b"] != "mock_replay" {
+		t.Errorf("tokens = %v", tokens)
+	}
+
+	for _, spec := range []string{"a", "a:", ":grid_adapter", "a:b:c", "a:manual"} {
+		if _, err := gateway.ParseFeedTokens(spec, "manual"); err == nil {
+			t.Errorf("ParseFeedTokens(%q) accepted", spec)
+		}
+	}
+}
+
+func TestFeedAuthSource(t *testing.T) {
+	auth := gateway.NewFeedAuth(map[string]string{"tok": "grid_adapter"})
+	tests := []struct {
+		header    string
+		source    string
+		presented bool
+		ok        bool
+	}{
+		{"", "", false, false},
+		{"Bearer tok", "grid_adapter", true, true},
+		{"Bearer other", "", true, false},
+		{"Basic tok", "", false, false},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest("GET", "/ws", nil)
+		if tt.header != "" {
+			r.Header.Set("Authorization", tt.header)
+		}
+		source, presented, ok := auth.Source(r)
+		if source != tt.source || presented != tt.presented || ok != tt.ok {
+			t.Errorf("Source(%q) = %q, %t, %t; want %q, %t, %t", tt.header, source, presented, ok, tt.source, tt.presented, tt.ok)
+		}
+	}
+}
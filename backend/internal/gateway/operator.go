@@ -0,0 +1,85 @@
+package gateway
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+type Role string
+
+const (
+	RoleOperator Role = "operator"
+	RoleViewer   Role = "viewer"
+)
+
+// Principal is the authenticated caller behind an admin request.
+type Principal struct {
+	ID   string `json:"id"`
+	Role Role   `json:"role"`
+}
+
+type principalKey struct{}
+
+// PrincipalFromContext returns the principal set by OperatorAuth.
+func PrincipalFromContext(ctx context.Context) (Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(Principal)
+	return p, ok
+}
+
+// OperatorAuth authenticates admin requests by bearer token. Tokens are
+// static for the PoC; a real deployment would swap this for the identity
+// provider behind IngressHandler.
+type OperatorAuth struct {
+	tokens map[string]Principal
+}
+
+// ParseOperatorTokens reads "token:operator_id:role" entries separated by
+// commas, e.g. the OPERATOR_TOKENS environment variable.
+func ParseOperatorTokens(spec string) (map[string]Principal, error) {
+	tokens := make(map[string]Principal)
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		fields := strings.Split(entry, ":")
+		if len(fields) != 3 || fields[0] == "" || fields[1] == "" {
+			return nil, fmt.Errorf("invalid operator token entry %q (want token:operator_id:role)", entry)
+		}
+		role := Role(fields[2])
+		if role != RoleOperator && role != RoleViewer {
+			return nil, fmt.Errorf("unknown role %q for operator %s", fields[2], fields[1])
+		}
+		tokens[fields[0]] = Principal{ID: fields[1], Role: role}
+	}
+	return tokens, nil
+}
+
+func NewOperatorAuth(tokens map[string]Principal) *OperatorAuth {
+	return &OperatorAuth{tokens: tokens}
+}
+
+func (oa *OperatorAuth) Authorize(ctx context.Context, token string) (bool, error) {
+	_, ok := oa.tokens[token]
+	return ok, nil
+}
+
+// Require rejects requests without a bearer token for the given role and
+// passes the principal to next through the request context.
+func (oa *OperatorAuth) Require(role Role, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		principal, ok := oa.tokens[token]
+		if token == "" || !ok {
+			http.Error(w, "operator authentication required", http.StatusUnauthorized)
+			return
+		}
+		if principal.Role != role {
+			http.Error(w, fmt.Sprintf("%s role required", role), http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), principalKey{}, principal)))
+	})
+}
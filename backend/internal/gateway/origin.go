@@ -0,0 +1,137 @@
+package gateway
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// OriginPolicy decides which browser origins may open WebSockets and read
+// REST responses. Entries are exact origins such as "https://app.example.com"
+// or, for development, path.Match patterns such as "http://localhost:*";
+// "*" allows every origin.
+type OriginPolicy struct {
+	allowed []string
+	// Methods and Headers are what preflighted requests may use.
+	Methods []string
+	Headers []string
+	// Expose lists response headers scripts may read.
+	Expose []string
+	MaxAge time.Duration
+}
+
+func NewOriginPolicy(allowed []string) *OriginPolicy {
+	return &OriginPolicy{
+		allowed: allowed,
+		Methods: []string{http.MethodGet, http.MethodOptions},
+		Headers: []string{"Content-Type", "X-Request-ID"},
+		Expose:  []string{"X-Request-ID"},
+		MaxAge:  10 * time.Minute,
+	}
+}
+
+// Allowed reports whether origin matches the allowlist.
+func (p *OriginPolicy) Allowed(origin string) bool {
+	origin = strings.ToLower(strings.TrimSuffix(origin, "/"))
+	for _, pattern := range p.allowed {
+		if pattern == "*" {
+			return true
+		}
+		if ok, _ := path.Match(strings.ToLower(pattern), origin); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// ExactOrigin checks that entry names a single origin, scheme://host[:port]
+// with no pattern characters. Production allowlists must use exact origins: a
+// wildcard host such as "https://*.vercel.app" admits every site anyone can
+// deploy under that domain.
+func ExactOrigin(entry string) error {
+	if strings.ContainsAny(entry, "*?[]\\") {
+		return fmt.Errorf("origin %q is a pattern, not an exact origin", entry)
+	}
+	u, err := url.Parse(entry)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" ||
+		u.User != nil || (u.Path != "" && u.Path != "/") || u.RawQuery != "" || u.Fragment != "" {
+		return fmt.Errorf("origin %q is not of the form scheme://host[:port]", entry)
+	}
+	return nil
+}
+
+// CheckOrigin is a websocket.Upgrader CheckOrigin. Requests without an
+// Origin header come from non-browser clients such as the feed adapter and
+// are let through, as are same-origin requests.
+func (p *OriginPolicy) CheckOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	if u, err := url.Parse(origin); err == nil && strings.EqualFold(u.Host, r.Host) {
+		return true
+	}
+	return p.Allowed(origin)
+}
+
+// CORS adds CORS headers for allowed origins and answers preflight requests.
+// Disallowed origins get no CORS headers, so browsers withhold the response;
+// their preflights are refused outright.
+func (p *OriginPolicy) CORS(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		w.Header().Add("Vary", "Origin")
+		preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+		if !p.Allowed(origin) {
+			if preflight {
+				http.Error(w, "origin not allowed", http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Set("Access-Control-Allow-Origin", origin)
+		if !preflight {
+			if len(p.Expose) > 0 {
+				w.Header().Set("Access-Control-Expose-Headers", strings.Join(p.Expose, ", "))
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Add("Vary", "Access-Control-Request-Method")
+		w.Header().Add("Vary", "Access-Control-Request-Headers")
+		if !containsFold(p.Methods, r.Header.Get("Access-Control-Request-Method")) {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		for _, h := range strings.Split(r.Header.Get("Access-Control-Request-Headers"), ",") {
+			if h = strings.TrimSpace(h); h != "" && !containsFold(p.Headers, h) {
+				http.Error(w, "header not allowed: "+h, http.StatusForbidden)
+				return
+			}
+		}
+		w.Header().Set("Access-Control-Allow-Methods", strings.Join(p.Methods, ", "))
+		w.Header().Set("Access-Control-Allow-Headers", strings.Join(p.Headers, ", "))
+		w.Header().Set("Access-Control-Max-Age", strconv.Itoa(int(p.MaxAge.Seconds())))
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+func containsFold(list []string, v string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, v) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,140 @@
+package gateway_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"cs2-prediction-engine/internal/gateway"
+)
+
+func TestOriginPolicyAllowed(t *testing.T) {
+	tests := []struct {
+		name    string
+		allowed []string
+		origin  string
+		want    bool
+	}{
+		{"exact", []string{"https://app.example.com"}, "https://app.example.com", true},
+		{"case and trailing slash", []string{"https://app.example.com"}, "HTTPS://App.Example.com/", true},
+		{"other host", []string{"https://app.example.com"}, "https://evil.example.com", false},
+		{"suffix is not a match", []string{"https://app.example.com"}, "https://app.example.com.evil.net", false},
+		{"scheme matters", []string{"https://app.example.com"}, "http://app.example.com", false},
+		{"port pattern", []string{"http://localhost:*"}, "http://localhost:3000", true},
+		{"port pattern needs the host", []string{"http://localhost:*"}, "http://localhost.evil.net:3000", false},
+		{"any origin", []string{"*"}, "https://anything.test", true},
+		{"empty allowlist", nil, "https://app.example.com", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := gateway.NewOriginPolicy(tt.allowed).Allowed(tt.origin); got != tt.want {
+				t.Errorf("Allowed(%q) = %t, want %t", tt.origin, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOriginPolicyCheckOrigin(t *testing.T) {
+	p := gateway.NewOriginPolicy([]string{"https://app.example.com"})
+	tests := []struct {
+		name   string
+		origin string
+		want   bool
+	}{
+		{"no origin header", "", true},
+		{"same origin", "https://engine.example.com", true},
+		{"allowed", "https://app.example.com", true},
+		{"cross-site", "https://evil.example.com", false},
+		{"malformed", "://", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "http://engine.example.com/ws", nil)
+			if tt.origin != "" {
+				r.Header.Set("Origin", tt.origin)
+			}
+			if got := p.CheckOrigin(r); got != tt.want {
+				t.Errorf("CheckOrigin(%q) = %t, want %t", tt.origin, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOriginPolicyCORS(t *testing.T) {
+	p := gateway.NewOriginPolicy([]string{"https://app.example.com"})
+	handler := p.CORS(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	}))
+
+	tests := []struct {
+		name        string
+		method      string
+		origin      string
+		reqMethod   string
+		reqHeaders  string
+		status      int
+		allowOrigin string
+	}{
+		{"no origin", http.MethodGet, "", "", "", http.StatusTeapot, ""},
+		{"allowed request", http.MethodGet, "https://app.example.com", "", "", http.StatusTeapot, "https://app.example.com"},
+		{"disallowed request gets no CORS", http.MethodGet, "https://evil.example.com", "", "", http.StatusTeapot, ""},
+		{"allowed preflight", http.MethodOptions, "https://app.example.com", "GET", "content-type, x-request-id", http.StatusNoContent, "https://app.example.com"},
+		{"disallowed preflight", http.MethodOptions, "https://evil.example.com", "GET", "", http.StatusForbidden, ""},
+		{"preflight for a disallowed method", http.MethodOptions, "https://app.example.com", "DELETE", "", http.StatusMethodNotAllowed, "https://app.example.com"},
+		{"preflight for a disallowed header", http.MethodOptions, "https://app.example.com", "GET", "Authorization", http.StatusForbidden, "https://app.example.com"},
+		{"plain OPTIONS is not a preflight", http.MethodOptions, "https://app.example.com", "", "", http.StatusTeapot, "https://app.example.com"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(tt.method, "/markets", nil)
+			if tt.origin != "" {
+				r.Header.Set("Origin", tt.origin)
+			}
+			if tt.reqMethod != "" {
+				r.Header.Set("Access-Control-Request-Method", tt.reqMethod)
+			}
+			if tt.reqHeaders != "" {
+				r.Header.Set("Access-Control-Request-Headers", tt.reqHeaders)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, r)
+
+			if rec.Code != tt.status {
+				t.Errorf("status = %d, want %d", rec.Code, tt.status)
+			}
+			if got := rec.Header().Get("Access-Control-Allow-Origin"); got != tt.allowOrigin {
+				t.Errorf("Access-Control-Allow-Origin = %q, want %q", got, tt.allowOrigin)
+			}
+			if tt.status == http.StatusNoContent {
+				if rec.Header().Get("Access-Control-Allow-Methods") == "" || rec.Header().Get("Access-Control-Max-Age") != "600" {
+					t.Errorf("preflight headers = %v", rec.Header())
+				}
+			}
+		})
+	}
+}
+
+func TestExactOrigin(t *testing.T) {
+	tests := []struct {
+		origin string
+		ok     bool
+	}{
+		{"https://app.example.com", true},
+		{"http://localhost:3000", true},
+		{"https://app.example.com/", true},
+		{"https://*.vercel.app", false},
+		{"https://app-*.example.com", false},
+		{"https://app.example.com:*", false},
+		{"https://app?.example.com", false},
+		{"*", false},
+		{"app.example.com", false},
+		{"ftp://app.example.com", false},
+		{"https://app.example.com/path", false},
+		{"https://user@app.example.com", false},
+	}
+	for _, tt := range tests {
+		if err := gateway.ExactOrigin(tt.origin); (err == nil) != tt.ok {
+			t.Errorf("ExactOrigin(%q) = %v, want ok %t", tt.origin, err, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,435 @@
+package surveillance
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"cs2-prediction-engine/internal/engine"
+)
+
+var ErrUnknownAlert = errors.New("unknown surveillance alert")
+
+type AlertKind string
+
+const (
+	AlertWashTrade     AlertKind = "wash_trade"
+	AlertSpoofing      AlertKind = "spoofing"
+	AlertLayering      AlertKind = "layering"
+	AlertInsiderTiming AlertKind = "insider_timing"
+)
+
+type AlertStatus string
+
+const (
+	StatusOpen         AlertStatus = "open"
+	StatusAcknowledged AlertStatus = "acknowledged"
+	StatusDismissed    AlertStatus = "dismissed"
+)
+
+// Evidence points at the audit log entry behind one observed event.
+type Evidence struct {
+	Kind      string    `json:"kind"` // order, cancel, match or feed
+	Ref       string    `json:"ref"`
+	Detail    string    `json:"detail"`
+	AuditHash string    `json:"audit_hash,omitempty"`
+	At        time.Time `json:"at"`
+}
+
+type Alert struct {
+	ID       uint64      `json:"id"`
+	Kind     AlertKind   `json:"kind"`
+	MarketID string      `json:"market_id"`
+	Users    []string    `json:"users"`
+	Detail   string      `json:"detail"`
+	Evidence []Evidence  `json:"evidence"`
+	Status   AlertStatus `json:"status"`
+	RaisedAt time.Time   `json:"raised_at"`
+	// ReviewedBy and Note are set when an operator acknowledges or dismisses.
+	ReviewedBy string     `json:"reviewed_by,omitempty"`
+	Note       string     `json:"note,omitempty"`
+	ReviewedAt *time.Time `json:"reviewed_at,omitempty"`
+}
+
+// Config tunes the detectors. Quantities are contracts, moves are YES cents.
+type Config struct {
+	// SpoofMinQuantity marks an order large enough to move other traders.
+	SpoofMinQuantity int64
+	// SpoofMaxLifetime is how long a large order may rest before its cancel
+	// stops looking like a feint.
+	SpoofMaxLifetime time.Duration
+	// SpoofFillWindow is how soon after the cancel an opposite fill counts.
+	SpoofFillWindow time.Duration
+	// LayeringMinOrders large cancels inside the window count as layering.
+	LayeringMinOrders int
+
+	// InsiderMinMove is the fair-value jump that makes prior fills suspect.
+	InsiderMinMove int64
+	// InsiderWindow is how far before the jump fills are examined.
+	InsiderWindow time.Duration
+	// InsiderMinQuantity ignores small bets.
+	InsiderMinQuantity int64
+
+	// HistoryLimit caps the alert queue.
+	HistoryLimit int
+}
+
+var DefaultConfig = Config{
+	SpoofMinQuantity:   500,
+	SpoofMaxLifetime:   10 * time.Second,
+	SpoofFillWindow:    5 * time.Second,
+	LayeringMinOrders:  3,
+	InsiderMinMove:     15,
+	InsiderWindow:      30 * time.Second,
+	InsiderMinQuantity: 100,
+	HistoryLimit:       1000,
+}
+
+// direction is +1 for orders long YES (buy YES, sell NO) and -1 otherwise.
+func direction(side engine.Side, outcome engine.Outcome) int {
+	if (side == engine.Buy) == (outcome == engine.Yes) {
+		return 1
+	}
+	return -1
+}
+
+type placedOrder struct {
+	order     engine.Order
+	at        time.Time
+	auditHash string
+}
+
+type largeCancel struct {
+	placedOrder
+	cancelledAt time.Time
+	cancelHash  string
+}
+
+type fill struct {
+	userID    string
+	orderID   uint64
+	direction int
+	quantity  int64
+	price     int64
+	at        time.Time
+	auditHash string
+}
+
+// Monitor flags manipulation patterns in the order, match and feed streams
+// and keeps the resulting alerts in a review queue.
+type Monitor struct {
+	mu     sync.Mutex
+	cfg    Config
+	links  map[string]string // user -> link group
+	placed map[uint64]placedOrder
+	// cancels and fills are recent activity per market, oldest first.
+	cancels map[string][]largeCancel
+	fills   map[string][]fill
+
+	alerts []*Alert
+	nextID uint64
+}
+
+func NewMonitor(cfg Config) *Monitor {
+	return &Monitor{
+		cfg:     cfg,
+		links:   make(map[string]string),
+		placed:  make(map[uint64]placedOrder),
+		cancels: make(map[string][]largeCancel),
+		fills:   make(map[string][]fill),
+	}
+}
+
+// LinkAccounts records that the given users are controlled by the same party.
+// Linking is transitive across calls.
+func (m *Monitor) LinkAccounts(users ...string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(users) == 0 {
+		return
+	}
+	group := users[0]
+	if g, ok := m.links[group]; ok {
+		group = g
+	}
+	for _, u := range users {
+		if old, ok := m.links[u]; ok && old != group {
+			for member, g := range m.links {
+				if g == old {
+					m.links[member] = group
+				}
+			}
+		}
+		m.links[u] = group
+	}
+}
+
+func (m *Monitor) linkedLocked(a, b string) bool {
+	if a == b {
+		return true
+	}
+	ga, ok := m.links[a]
+	return ok && ga == m.links[b]
+}
+
+// ObserveOrder notes an accepted order.
+func (m *Monitor) ObserveOrder(order engine.Order, at time.Time, auditHash string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.placed[order.ID] = placedOrder{order: order, at: at, auditHash: auditHash}
+}
+
+// ObserveCancel notes a user cancelling an order with remaining unfilled.
+// System cancels should not be passed in.
+func (m *Monitor) ObserveCancel(orderID uint64, remaining int64, at time.Time, auditHash string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	placed, ok := m.placed[orderID]
+	delete(m.placed, orderID)
+	if !ok || remaining < m.cfg.SpoofMinQuantity || at.Sub(placed.at) > m.cfg.SpoofMaxLifetime {
+		return
+	}
+	marketID := placed.order.MarketID
+	m.cancels[marketID] = append(m.pruneCancelsLocked(marketID, at), largeCancel{
+		placedOrder: placed,
+		cancelledAt: at,
+		cancelHash:  auditHash,
+	})
+}
+
+// ObserveOrderClosed forgets an order that filled or was cancelled by the system.
+func (m *Monitor) ObserveOrderClosed(orderID uint64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.placed, orderID)
+}
+
+func (m *Monitor) pruneCancelsLocked(marketID string, now time.Time) []largeCancel {
+	list := m.cancels[marketID]
+	i := 0
+	for i < len(list) && now.Sub(list[i].cancelledAt) > m.cfg.SpoofFillWindow {
+		i++
+	}
+	return list[i:]
+}
+
+// ObserveMatch checks an execution for wash trading and for fills that follow
+// a feint on the other side, and remembers it for insider timing checks.
+func (m *Monitor) ObserveMatch(marketID string, match engine.Match, auditHash string) []Alert {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var raised []Alert
+	matchEvidence := Evidence{
+		Kind:      "match",
+		Ref:       fmt.Sprintf("%d/%d", match.MakerOrderID, match.TakerOrderID),
+		Detail:    fmt.Sprintf("%d @ %d", match.Quantity, match.Price),
+		AuditHash: auditHash,
+		At:        match.Timestamp,
+	}
+
+	if match.Maker.UserID != match.Taker.UserID && m.linkedLocked(match.Maker.UserID, match.Taker.UserID) {
+		raised = append(raised, m.raiseLocked(Alert{
+			Kind:     AlertWashTrade,
+			MarketID: marketID,
+			Users:    []string{match.Maker.UserID, match.Taker.UserID},
+			Detail:   fmt.Sprintf("linked accounts traded %d contracts with each other", match.Quantity),
+			Evidence: []Evidence{matchEvidence},
+		}, match.Timestamp))
+	}
+
+	fills := m.pruneFillsLocked(marketID, match.Timestamp)
+	for _, leg := range []engine.MatchLeg{match.Maker, match.Taker} {
+		if a, ok := m.checkSpoofLocked(marketID, leg, matchEvidence, match.Timestamp); ok {
+			raised = append(raised, a)
+		}
+		// Both legs took a position, each in its own direction.
+		fills = append(fills, fill{
+			userID:    leg.UserID,
+			orderID:   leg.OrderID,
+			direction: direction(leg.Side, leg.Outcome),
+			quantity:  match.Quantity,
+			price:     leg.Price,
+			at:        match.Timestamp,
+			auditHash: auditHash,
+		})
+	}
+	m.fills[marketID] = fills
+	return raised
+}
+
+// checkSpoofLocked flags a fill that lands shortly after the same user pulled
+// large orders on the opposite side.
+func (m *Monitor) checkSpoofLocked(marketID string, leg engine.MatchLeg, matchEvidence Evidence, at time.Time) (Alert, bool) {
+	list := m.pruneCancelsLocked(marketID, at)
+	m.cancels[marketID] = list
+
+	dir := direction(leg.Side, leg.Outcome)
+	var feints []largeCancel
+	for _, c := range list {
+		if c.order.UserID == leg.UserID && direction(c.order.Side, c.order.Outcome) == -dir {
+			feints = append(feints, c)
+		}
+	}
+	if len(feints) == 0 {
+		return Alert{}, false
+	}
+
+	kind := AlertSpoofing
+	if len(feints) >= m.cfg.LayeringMinOrders {
+		kind = AlertLayering
+	}
+	evidence := make([]Evidence, 0, 2*len(feints)+1)
+	for _, c := range feints {
+		evidence = append(evidence,
+			Evidence{Kind: "order", Ref: fmt.Sprint(c.order.ID), AuditHash: c.auditHash, At: c.at,
+				Detail: fmt.Sprintf("%s %s %d @ %d", c.order.Side, c.order.Outcome, c.order.Quantity, c.order.Price)},
+			Evidence{Kind: "cancel", Ref: fmt.Sprint(c.order.ID), AuditHash: c.cancelHash, At: c.cancelledAt,
+				Detail: fmt.Sprintf("cancelled after %s", c.cancelledAt.Sub(c.at).Round(time.Millisecond))},
+		)
+	}
+	evidence = append(evidence, matchEvidence)
+
+	// Each feint is reported once.
+	kept := list[:0]
+	for _, c := range list {
+		if !(c.order.UserID == leg.UserID && direction(c.order.Side, c.order.Outcome) == -dir) {
+			kept = append(kept, c)
+		}
+	}
+	m.cancels[marketID] = kept
+
+	return m.raiseLocked(Alert{
+		Kind:     kind,
+		MarketID: marketID,
+		Users:    []string{leg.UserID},
+		Detail:   fmt.Sprintf("%d large opposite orders cancelled shortly before a fill on order %d", len(feints), leg.OrderID),
+		Evidence: evidence,
+	}, at), true
+}
+
+func (m *Monitor) pruneFillsLocked(marketID string, now time.Time) []fill {
+	list := m.fills[marketID]
+	i := 0
+	for i < len(list) && now.Sub(list[i].at) > m.cfg.InsiderWindow {
+		i++
+	}
+	return list[i:]
+}
+
+// ObserveGameState compares the fair value before and after a feed update and
+// flags users whose recent fills, as maker or taker, anticipated a large move.
+func (m *Monitor) ObserveGameState(marketID string, before, after int64, at time.Time, feedEvidence Evidence) []Alert {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	move := after - before
+	if move < m.cfg.InsiderMinMove && -move < m.cfg.InsiderMinMove {
+		return nil
+	}
+	moveDir, position := 1, "long YES"
+	if move < 0 {
+		moveDir, position = -1, "long NO"
+	}
+
+	list := m.pruneFillsLocked(marketID, at)
+	m.fills[marketID] = list
+	byUser := map[string][]fill{}
+	for _, f := range list {
+		if f.direction == moveDir {
+			byUser[f.userID] = append(byUser[f.userID], f)
+		}
+	}
+
+	users := make([]string, 0, len(byUser))
+	for u := range byUser {
+		users = append(users, u)
+	}
+	sort.Strings(users)
+
+	var raised []Alert
+	for _, u := range users {
+		fills := byUser[u]
+		var qty int64
+		evidence := make([]Evidence, 0, len(fills)+1)
+		for _, f := range fills {
+			qty += f.quantity
+			evidence = append(evidence, Evidence{
+				Kind: "match", Ref: fmt.Sprint(f.orderID), AuditHash: f.auditHash, At: f.at,
+				Detail: fmt.Sprintf("%d @ %d, %s before the move", f.quantity, f.price, at.Sub(f.at).Round(time.Millisecond)),
+			})
+		}
+		if qty < m.cfg.InsiderMinQuantity {
+			continue
+		}
+		feedEvidence.At = at
+		evidence = append(evidence, feedEvidence)
+		raised = append(raised, m.raiseLocked(Alert{
+			Kind:     AlertInsiderTiming,
+			MarketID: marketID,
+			Users:    []string{u},
+			Detail:   fmt.Sprintf("took %d contracts %s within %s before a %+d fair-value move", qty, position, m.cfg.InsiderWindow, move),
+			Evidence: evidence,
+		}, at))
+	}
+	return raised
+}
+
+func (m *Monitor) raiseLocked(a Alert, at time.Time) Alert {
+	m.nextID++
+	a.ID = m.nextID
+	a.Status = StatusOpen
+	a.RaisedAt = at
+	m.alerts = append(m.alerts, &a)
+	if m.cfg.HistoryLimit > 0 && len(m.alerts) > m.cfg.HistoryLimit {
+		m.alerts = m.alerts[len(m.alerts)-m.cfg.HistoryLimit:]
+	}
+	return a
+}
+
+// Alerts returns the queue newest first, optionally filtered by status.
+func (m *Monitor) Alerts(status AlertStatus) []Alert {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	out := make([]Alert, 0)
+	for i := len(m.alerts) - 1; i >= 0; i-- {
+		if status == "" || m.alerts[i].Status == status {
+			out = append(out, *m.alerts[i])
+		}
+	}
+	return out
+}
+
+func (m *Monitor) Alert(id uint64) (Alert, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, a := range m.alerts {
+		if a.ID == id {
+			return *a, true
+		}
+	}
+	return Alert{}, false
+}
+
+// Review moves an alert out of the open queue.
+func (m *Monitor) Review(id uint64, status AlertStatus, reviewer, note string, at time.Time) (Alert, error) {
+	if status != StatusAcknowledged && status != StatusDismissed {
+		return Alert{}, fmt.Errorf("cannot review alert into status %q", status)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, a := range m.alerts {
+		if a.ID != id {
+			continue
+		}
+		a.Status = status
+		a.ReviewedBy = reviewer
+		a.Note = note
+		a.ReviewedAt = &at
+		return *a, nil
+	}
+	return Alert{}, fmt.Errorf("%w: %d", ErrUnknownAlert, id)
+}
@@ -0,0 +1,168 @@
+package surveillance_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"cs2-prediction-engine/internal/engine"
+	"cs2-prediction-engine/internal/surveillance"
+)
+
+var t0 = time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+
+// match has maker sell YES to taker at price.
+func match(maker, taker string, makerOrder, takerOrder uint64, price, qty int64, at time.Time) engine.Match {
+	return engine.Match{
+		MakerOrderID: makerOrder,
+		TakerOrderID: takerOrder,
+		Price:        price,
+		Quantity:     qty,
+		Maker:        engine.MatchLeg{OrderID: makerOrder, UserID: maker, Side: engine.Sell, Outcome: engine.Yes, Price: price},
+		Taker:        engine.MatchLeg{OrderID: takerOrder, UserID: taker, Side: engine.Buy, Outcome: engine.Yes, Price: price},
+		Timestamp:    at,
+	}
+}
+
+func alertsOfKind(alerts []surveillance.Alert, kind surveillance.AlertKind) []surveillance.Alert {
+	var out []surveillance.Alert
+	for _, a := range alerts {
+		if a.Kind == kind {
+			out = append(out, a)
+		}
+	}
+	return out
+}
+
+func TestWashTrade(t *testing.T) {
+	m := surveillance.NewMonitor(surveillance.DefaultConfig)
+	m.LinkAccounts("alice", "alice_alt")
+
+	if got := alertsOfKind(m.ObserveMatch("m1", match("alice", "bob", 1, 2, 50, 10, t0), "h1"), surveillance.AlertWashTrade); len(got) != 0 {
+		t.Errorf("unlinked users flagged: %+v", got)
+	}
+	got := alertsOfKind(m.ObserveMatch("m1", match("alice", "alice_alt", 3, 4, 50, 10, t0), "h2"), surveillance.AlertWashTrade)
+	if len(got) != 1 {
+		t.Fatalf("wash alerts = %+v, want one", got)
+	}
+	if got[0].Evidence[0].AuditHash != "h2" {
+		t.Errorf("evidence = %+v, want the match's audit hash", got[0].Evidence)
+	}
+}
+
+func TestLinkAccountsIsTransitive(t *testing.T) {
+	tests := []struct {
+		name   string
+		links  [][]string
+		a, b   string
+		linked bool
+	}{
+		{"direct", [][]string{{"a", "b"}}, "a", "b", true},
+		{"through a shared member", [][]string{{"a", "b"}, {"b", "c"}}, "a", "c", true},
+		{"merging two groups", [][]string{{"a", "b"}, {"c", "d"}, {"b", "d"}}, "a", "c", true},
+		{"merging from the newer group", [][]string{{"a", "b"}, {"c", "d"}, {"d", "a"}}, "b", "c", true},
+		{"separate groups", [][]string{{"a", "b"}, {"c", "d"}}, "a", "c", false},
+		{"unlinked user", [][]string{{"a", "b"}}, "a", "z", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := surveillance.NewMonitor(surveillance.DefaultConfig)
+			for _, users := range tt.links {
+				m.LinkAccounts(users...)
+			}
+			got := len(alertsOfKind(m.ObserveMatch("m1", match(tt.a, tt.b, 1, 2, 50, 10, t0), ""), surveillance.AlertWashTrade)) > 0
+			if got != tt.linked {
+				t.Errorf("%s and %s linked = %t, want %t", tt.a, tt.b, got, tt.linked)
+			}
+		})
+	}
+}
+
+func TestSpoofingAndLayering(t *testing.T) {
+	tests := []struct {
+		name    string
+		feints  int
+		qty     int64
+		life    time.Duration
+		want    surveillance.AlertKind
+		flagged bool
+	}{
+		{"one feint", 1, 600, time.Second, surveillance.AlertSpoofing, true},
+		{"layered feints", 3, 600, time.Second, surveillance.AlertLayering, true},
+		{"small orders", 3, 100, time.Second, "", false},
+		{"long-lived orders", 1, 600, time.Minute, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := surveillance.NewMonitor(surveillance.DefaultConfig)
+			// mallory bids YES in size, pulls the bids, then sells YES as maker.
+			for i := 0; i < tt.feints; i++ {
+				id := uint64(10 + i)
+				m.ObserveOrder(engine.Order{ID: id, MarketID: "m1", UserID: "mallory", Side: engine.Buy, Outcome: engine.Yes, Price: 45, Quantity: tt.qty}, t0, "o")
+				m.ObserveCancel(id, tt.qty, t0.Add(tt.life), "c")
+			}
+			alerts := m.ObserveMatch("m1", match("mallory", "bob", 20, 21, 55, 10, t0.Add(tt.life+time.Second)), "h")
+
+			var spoof []surveillance.Alert
+			spoof = append(spoof, alertsOfKind(alerts, surveillance.AlertSpoofing)...)
+			spoof = append(spoof, alertsOfKind(alerts, surveillance.AlertLayering)...)
+			if !tt.flagged {
+				if len(spoof) != 0 {
+					t.Errorf("alerts = %+v, want none", spoof)
+				}
+				return
+			}
+			if len(spoof) != 1 || spoof[0].Kind != tt.want || spoof[0].Users[0] != "mallory" {
+				t.Fatalf("alerts = %+v, want one %s for mallory", spoof, tt.want)
+			}
+			// Each feint contributes its order and cancel, then the match.
+			if n := len(spoof[0].Evidence); n != 2*tt.feints+1 {
+				t.Errorf("evidence has %d entries, want %d", n, 2*tt.feints+1)
+			}
+		})
+	}
+}
+
+// Both legs of a fill are remembered with their own direction, so a maker
+// who sold YES ahead of a drop is flagged as well as a taker who bought
+// ahead of a rise.
+func TestInsiderTimingCoversBothLegs(t *testing.T) {
+	tests := []struct {
+		name   string
+		after  int64
+		user   string
+		detail string
+	}{
+		{"rise flags the YES buyer", 70, "taker", "long YES"},
+		{"drop flags the YES seller", 30, "maker", "long NO"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := surveillance.NewMonitor(surveillance.DefaultConfig)
+			m.ObserveMatch("m1", match("maker", "taker", 1, 2, 50, 200, t0), "h")
+
+			alerts := m.ObserveGameState("m1", 50, tt.after, t0.Add(10*time.Second), surveillance.Evidence{Kind: "feed"})
+			if len(alerts) != 1 || alerts[0].Kind != surveillance.AlertInsiderTiming {
+				t.Fatalf("alerts = %+v, want one insider_timing", alerts)
+			}
+			if alerts[0].Users[0] != tt.user {
+				t.Errorf("flagged %v, want %s", alerts[0].Users, tt.user)
+			}
+			if !strings.Contains(alerts[0].Detail, tt.detail) || strings.Contains(alerts[0].Detail, "bought") {
+				t.Errorf("detail = %q, want it to say %s", alerts[0].Detail, tt.detail)
+			}
+		})
+	}
+}
+
+func TestInsiderTimingIgnoresSmallMovesAndOldFills(t *testing.T) {
+	m := surveillance.NewMonitor(surveillance.DefaultConfig)
+	m.ObserveMatch("m1", match("maker", "taker", 1, 2, 50, 200, t0), "h")
+
+	if alerts := m.ObserveGameState("m1", 50, 55, t0.Add(time.Second), surveillance.Evidence{}); len(alerts) != 0 {
+		t.Errorf("small move flagged: %+v", alerts)
+	}
+	if alerts := m.ObserveGameState("m1", 50, 80, t0.Add(time.Minute), surveillance.Evidence{}); len(alerts) != 0 {
+		t.Errorf("fill outside the window flagged: %+v", alerts)
+	}
+}
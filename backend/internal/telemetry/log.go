@@ -0,0 +1,143 @@
+// Package telemetry provides structured logging, correlation IDs and trace
+// spans exported over OTLP/HTTP.
+package telemetry
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// NewLogger builds a slog logger. format is "json" or "text"; level may be a
+// *slog.LevelVar so the level can change while the logger is in use.
+func NewLogger(w io.Writer, format string, level slog.Leveler) (*slog.Logger, error) {
+	opts := &slog.HandlerOptions{Level: level}
+	switch strings.ToLower(orDefault(format, "json")) {
+	case "json":
+		return slog.New(slog.NewJSONHandler(w, opts)), nil
+	case "text":
+		return slog.New(slog.NewTextHandler(w, opts)), nil
+	default:
+		return nil, fmt.Errorf("invalid log format %q", format)
+	}
+}
+
+func orDefault(v, def string) string {
+	if v == "" {
+		return def
+	}
+	return v
+}
+
+// NewCorrelationID returns a random 128-bit ID in hex. It doubles as the trace
+// ID of every span recorded for the work it identifies.
+func NewCorrelationID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// ValidCorrelationID reports whether id can be used as a trace ID.
+func ValidCorrelationID(id string) bool {
+	if len(id) != 32 || id == strings.Repeat("0", 32) {
+		return false
+	}
+	_, err := hex.DecodeString(id)
+	return err == nil
+}
+
+// maxClientCorrelationID bounds a caller's own correlation ID. It is only
+// logged and echoed back, never used as a trace ID.
+const maxClientCorrelationID = 64
+
+// ClientCorrelationID trims a caller-supplied ID to a length safe to log.
+func ClientCorrelationID(id string) string {
+	if len(id) > maxClientCorrelationID {
+		id = id[:maxClientCorrelationID]
+	}
+	return strings.ToValidUTF8(id, "")
+}
+
+type correlationKey struct{}
+
+func WithCorrelationID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, correlationKey{}, id)
+}
+
+func CorrelationID(ctx context.Context) string {
+	id, _ := ctx.Value(correlationKey{}).(string)
+	return id
+}
+
+// RequestHeader carries the correlation ID on REST requests and responses.
+const RequestHeader = "X-Request-ID"
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// Hijack passes WebSocket upgrades through to the underlying connection.
+func (r *statusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := r.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("response writer does not support hijacking")
+	}
+	r.status = http.StatusSwitchingProtocols
+	return h.Hijack()
+}
+
+// Middleware assigns each request a correlation ID and logs and traces the
+// request when it ends. The ID is always the server's own so callers cannot
+// collide with each other's traces; an X-Request-ID from the caller is logged
+// alongside it as client_request_id.
+func Middleware(logger *slog.Logger, tracer *Tracer, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := NewCorrelationID()
+		clientID := ClientCorrelationID(r.Header.Get(RequestHeader))
+		w.Header().Set(RequestHeader, id)
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		start := time.Now()
+
+		next.ServeHTTP(rec, r.WithContext(WithCorrelationID(r.Context(), id)))
+
+		elapsed := time.Since(start)
+		attrs := []slog.Attr{
+			slog.String("correlation_id", id),
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+			slog.Int("status", rec.status),
+			slog.Duration("duration", elapsed),
+		}
+		if clientID != "" {
+			attrs = append(attrs, slog.String("client_request_id", clientID))
+		}
+		logger.LogAttrs(r.Context(), slog.LevelInfo, "http request", attrs...)
+		if span := tracer.Start(id, "", "HTTP "+r.Method, start); span != nil {
+			span.Kind = SpanKindServer
+			span.SetAttr("http.method", r.Method)
+			span.SetAttr("http.route", r.URL.Path)
+			span.SetAttr("http.status_code", fmt.Sprint(rec.status))
+			span.Error = rec.status >= http.StatusInternalServerError
+			span.End(start.Add(elapsed))
+		}
+	})
+}
@@ -0,0 +1,46 @@
+package telemetry_test
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"cs2-prediction-engine/internal/telemetry"
+)
+
+func TestMiddlewareAssignsItsOwnCorrelationID(t *testing.T) {
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&logs, nil))
+	var seen string
+	handler := telemetry.Middleware(logger, nil, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = telemetry.CorrelationID(r.Context())
+	}))
+
+	const clientID = "0123456789abcdef0123456789abcdef"
+	req := httptest.NewRequest(http.MethodGet, "/markets", nil)
+	req.Header.Set(telemetry.RequestHeader, clientID)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if seen == clientID || !telemetry.ValidCorrelationID(seen) {
+		t.Errorf("correlation ID = %q, want a server-assigned ID", seen)
+	}
+	if got := rec.Header().Get(telemetry.RequestHeader); got != seen {
+		t.Errorf("%s = %q, want %q", telemetry.RequestHeader, got, seen)
+	}
+	if !strings.Contains(logs.String(), "client_request_id="+clientID) {
+		t.Errorf("caller's ID not logged: %s", logs.String())
+	}
+}
+
+func TestClientCorrelationIDIsBounded(t *testing.T) {
+	if got := telemetry.ClientCorrelationID(strings.Repeat("x", 500)); len(got) != 64 {
+		t.Errorf("len = %d, want 64", len(got))
+	}
+	if got := telemetry.ClientCorrelationID("bad\xffid"); got != "badid" {
+		t.Errorf("got %q, want invalid UTF-8 dropped", got)
+	}
+}
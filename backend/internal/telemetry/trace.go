@@ -0,0 +1,310 @@
+package telemetry
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// OTLP span kinds and status codes.
+const (
+	SpanKindInternal = 1
+	SpanKindServer   = 2
+
+	statusOK    = 1
+	statusError = 2
+)
+
+// Span is one timed operation within a trace. Spans are built in full and
+// exported once End is called.
+type Span struct {
+	TraceID  string
+	SpanID   string
+	ParentID string
+	Name     string
+	Kind     int
+	Start    time.Time
+	Error    bool
+	attrs    map[string]string
+	tracer   *Tracer
+}
+
+func (s *Span) SetAttr(key, value string) {
+	if s == nil {
+		return
+	}
+	if s.attrs == nil {
+		s.attrs = make(map[string]string)
+	}
+	s.attrs[key] = value
+}
+
+// End records the span as finished at end and queues it for export.
+func (s *Span) End(end time.Time) {
+	if s == nil || s.tracer == nil {
+		return
+	}
+	s.tracer.enqueue(exportedSpan{span: *s, end: end})
+}
+
+// RootSpanID is the span ID used for the top-level span of a correlation ID,
+// so work recorded at different stages can parent to it without passing the
+// span around.
+func RootSpanID(correlationID string) string {
+	if len(correlationID) < 16 {
+		return ""
+	}
+	return correlationID[:16]
+}
+
+func newSpanID() string {
+	var b [8]byte
+	rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+type exportedSpan struct {
+	span Span
+	end  time.Time
+}
+
+// TracerConfig points the tracer at an OTLP/HTTP collector. An empty endpoint
+// disables tracing.
+type TracerConfig struct {
+	Endpoint      string // Collector base URL, e.g. http://localhost:4318
+	ServiceName   string
+	BatchSize     int
+	FlushInterval time.Duration
+	QueueSize     int
+}
+
+var DefaultTracerConfig = TracerConfig{
+	ServiceName:   "cs2-prediction-engine",
+	BatchSize:     256,
+	FlushInterval: 2 * time.Second,
+	QueueSize:     4096,
+}
+
+// Tracer batches finished spans and posts them to the collector as OTLP/JSON.
+// A nil Tracer records nothing.
+type Tracer struct {
+	cfg     TracerConfig
+	logger  *slog.Logger
+	client  *http.Client
+	queue   chan exportedSpan
+	stop    chan struct{}
+	done    chan struct{}
+	dropped uint64
+	mu      sync.Mutex
+}
+
+// NewTracer starts the exporter, or returns nil when no endpoint is set.
+func NewTracer(cfg TracerConfig, logger *slog.Logger) *Tracer {
+	if cfg.Endpoint == "" {
+		return nil
+	}
+	t := &Tracer{
+		cfg:    cfg,
+		logger: logger,
+		client: &http.Client{Timeout: 5 * time.Second},
+		queue:  make(chan exportedSpan, cfg.QueueSize),
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	go t.run()
+	return t
+}
+
+// Start begins a span under parentID, or a parentless span when it is empty.
+func (t *Tracer) Start(traceID, parentID, name string, start time.Time) *Span {
+	if t == nil || !ValidCorrelationID(traceID) {
+		return nil
+	}
+	return &Span{
+		TraceID:  traceID,
+		SpanID:   newSpanID(),
+		ParentID: parentID,
+		Name:     name,
+		Kind:     SpanKindInternal,
+		Start:    start,
+		tracer:   t,
+	}
+}
+
+// StartRoot begins the top-level span of a correlation ID.
+func (t *Tracer) StartRoot(correlationID, name string, start time.Time) *Span {
+	span := t.Start(correlationID, "", name, start)
+	if span != nil {
+		span.SpanID = RootSpanID(correlationID)
+	}
+	return span
+}
+
+func (t *Tracer) enqueue(s exportedSpan) {
+	select {
+	case t.queue <- s:
+	default:
+		t.mu.Lock()
+		t.dropped++
+		t.mu.Unlock()
+	}
+}
+
+// Dropped counts spans discarded because the export queue was full.
+func (t *Tracer) Dropped() uint64 {
+	if t == nil {
+		return 0
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.dropped
+}
+
+func (t *Tracer) run() {
+	defer close(t.done)
+	ticker := time.NewTicker(t.cfg.FlushInterval)
+	defer ticker.Stop()
+
+	var batch []exportedSpan
+	send := func() {
+		if len(batch) > 0 {
+			t.export(batch)
+			batch = nil
+		}
+	}
+	for {
+		select {
+		case s := <-t.queue:
+			batch = append(batch, s)
+			if len(batch) >= t.cfg.BatchSize {
+				send()
+			}
+		case <-ticker.C:
+			send()
+		case <-t.stop:
+			t.drainQueue(&batch)
+			send()
+			return
+		}
+	}
+}
+
+func (t *Tracer) drainQueue(batch *[]exportedSpan) {
+	for {
+		select {
+		case s := <-t.queue:
+			*batch = append(*batch, s)
+		default:
+			return
+		}
+	}
+}
+
+// Shutdown exports every queued span and stops the exporter.
+func (t *Tracer) Shutdown(ctx context.Context) error {
+	if t == nil {
+		return nil
+	}
+	close(t.stop)
+	select {
+	case <-t.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (t *Tracer) export(batch []exportedSpan) {
+	body, err := json.Marshal(t.payload(batch))
+	if err != nil {
+		t.logger.Error("encode trace spans", "error", err)
+		return
+	}
+	url := strings.TrimRight(t.cfg.Endpoint, "/") + "/v1/traces"
+	resp, err := t.client.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		t.logger.Warn("export trace spans", "spans", len(batch), "error", err)
+		return
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		t.logger.Warn("export trace spans", "spans", len(batch), "status", resp.StatusCode)
+	}
+}
+
+// OTLP/JSON wire types.
+type (
+	otlpAnyValue struct {
+		StringValue string `json:"stringValue"`
+	}
+	otlpKeyValue struct {
+		Key   string       `json:"key"`
+		Value otlpAnyValue `json:"value"`
+	}
+	otlpStatus struct {
+		Code int `json:"code"`
+	}
+	otlpSpan struct {
+		TraceID           string         `json:"traceId"`
+		SpanID            string         `json:"spanId"`
+		ParentSpanID      string         `json:"parentSpanId,omitempty"`
+		Name              string         `json:"name"`
+		Kind              int            `json:"kind"`
+		StartTimeUnixNano string         `json:"startTimeUnixNano"`
+		EndTimeUnixNano   string         `json:"endTimeUnixNano"`
+		Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+		Status            otlpStatus     `json:"status"`
+	}
+	otlpScopeSpans struct {
+		Scope struct {
+			Name string `json:"name"`
+		} `json:"scope"`
+		Spans []otlpSpan `json:"spans"`
+	}
+	otlpResourceSpans struct {
+		Resource struct {
+			Attributes []otlpKeyValue `json:"attributes"`
+		} `json:"resource"`
+		ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+	}
+	otlpTraces struct {
+		ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+	}
+)
+
+func (t *Tracer) payload(batch []exportedSpan) otlpTraces {
+	scope := otlpScopeSpans{Spans: make([]otlpSpan, 0, len(batch))}
+	scope.Scope.Name = t.cfg.ServiceName
+	for _, e := range batch {
+		s := e.span
+		out := otlpSpan{
+			TraceID:           s.TraceID,
+			SpanID:            s.SpanID,
+			ParentSpanID:      s.ParentID,
+			Name:              s.Name,
+			Kind:              s.Kind,
+			StartTimeUnixNano: strconv.FormatInt(s.Start.UnixNano(), 10),
+			EndTimeUnixNano:   strconv.FormatInt(e.end.UnixNano(), 10),
+			Status:            otlpStatus{Code: statusOK},
+		}
+		if s.Error {
+			out.Status.Code = statusError
+		}
+		for k, v := range s.attrs {
+			out.Attributes = append(out.Attributes, otlpKeyValue{Key: k, Value: otlpAnyValue{StringValue: v}})
+		}
+		scope.Spans = append(scope.Spans, out)
+	}
+
+	resource := otlpResourceSpans{ScopeSpans: []otlpScopeSpans{scope}}
+	resource.Resource.Attributes = []otlpKeyValue{{Key: "service.name", Value: otlpAnyValue{StringValue: t.cfg.ServiceName}}}
+	return otlpTraces{ResourceSpans: []otlpResourceSpans{resource}}
+}
@@ -22,7 +22,7 @@ type EngineMarket = {
   tournament: string;
   teams: string[];
   start_time?: string;
-  status: "active" | "suspended" | "settled";
+  status: "scheduled" | "open" | "suspended" | "closed" | "resolving" | "settled" | "voided";
   winner?: string;
   final_score?: string;
   settled_at?: string;
@@ -250,7 +250,7 @@ export default function CS2Dashboard() {
     };
   }, []);
 
-  const activeMarket = engineMarkets.find((m) => m.status === "active") || engineMarkets[0];
+  const activeMarket = engineMarkets.find((m) => m.status === "open") || engineMarkets[0];
   const focusedMarket =
     (pinnedMarketId ? engineMarkets.find((m) => m.market_id === pinnedMarketId) : undefined) || activeMarket;
   const visibleMarkets = focusedMarket ? [focusedMarket] : engineMarkets;